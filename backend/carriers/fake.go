@@ -0,0 +1,50 @@
+// Package carriers contiene las integraciones con transportistas para el seguimiento de
+// envíos (ver services.CarrierTracker).
+package carriers
+
+import (
+	"fmt"
+	"sync"
+
+	"tiendaedgar/backend/models"
+)
+
+// Fake simula un transportista en memoria para desarrollo y tests: los estados de los
+// envíos se cargan con SetStatus y los números desconocidos devuelven error.
+type Fake struct {
+	mu       sync.Mutex
+	statuses map[string]models.CarrierStatus
+	queries  int
+}
+
+// NewFake crea un transportista falso sin envíos
+func NewFake() *Fake {
+	return &Fake{statuses: map[string]models.CarrierStatus{}}
+}
+
+// SetStatus define el estado que se informará para el número de seguimiento
+func (f *Fake) SetStatus(trackingNumber string, status models.CarrierStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[trackingNumber] = status
+}
+
+// Queries devuelve cuántas consultas recibió (para verificar qué envíos se consultan)
+func (f *Fake) Queries() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries
+}
+
+// TrackShipment implementa services.CarrierTracker
+func (f *Fake) TrackShipment(trackingNumber string) (*models.CarrierStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+
+	status, ok := f.statuses[trackingNumber]
+	if !ok {
+		return nil, fmt.Errorf("envío %s no encontrado en el transportista", trackingNumber)
+	}
+	return &status, nil
+}
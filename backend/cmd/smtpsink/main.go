@@ -0,0 +1,37 @@
+// smtpsink es un servidor SMTP de prueba para desarrollo: acepta todos los emails y los
+// muestra por consola en lugar de entregarlos. Uso:
+//
+//	go run ./cmd/smtpsink -addr 127.0.0.1:2525
+//	SMTP_HOST=127.0.0.1 SMTP_PORT=2525 SMTP_FROM=tienda@localhost go run .
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"tiendaedgar/backend/mailer"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2525", "dirección en la que escuchar")
+	flag.Parse()
+
+	sink, err := mailer.NewSink(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sink.Close()
+
+	host, port := sink.Addr()
+	log.Printf("smtpsink escuchando en %s:%d", host, port)
+
+	shown := 0
+	for range sink.Received() {
+		messages := sink.Messages()
+		for _, msg := range messages[shown:] {
+			log.Printf("Email de %s para %s\n%s", msg.From, strings.Join(msg.To, ", "), msg.Data)
+		}
+		shown = len(messages)
+	}
+}
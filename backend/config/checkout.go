@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// CheckoutConfig contiene la configuración de los pedidos del checkout público
+type CheckoutConfig struct {
+	ReservationTTL time.Duration // CHECKOUT_RESERVATION_TTL: tiempo que un pedido Pendiente sin pago reserva el stock (0 = sin vencimiento)
+	ExpiryInterval time.Duration // CHECKOUT_EXPIRY_INTERVAL: cada cuánto se cancelan las reservas vencidas
+}
+
+// CheckoutFromEnv lee la configuración del checkout de las variables de entorno. Los valores
+// inválidos se reemplazan por los valores por defecto (reservas de 2 horas, revisadas cada 5 minutos).
+func CheckoutFromEnv() CheckoutConfig {
+	ttl, err := time.ParseDuration(envOrDefault("CHECKOUT_RESERVATION_TTL", "2h"))
+	if err != nil || ttl < 0 {
+		ttl = 2 * time.Hour
+	}
+	interval, err := time.ParseDuration(envOrDefault("CHECKOUT_EXPIRY_INTERVAL", "5m"))
+	if err != nil || interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return CheckoutConfig{
+		ReservationTTL: ttl,
+		ExpiryInterval: interval,
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import "os"
+
+// MercadoPagoConfig contiene las credenciales y URLs de la integración con Mercado Pago.
+// Sin AccessToken la integración queda deshabilitada.
+type MercadoPagoConfig struct {
+	AccessToken   string // MP_ACCESS_TOKEN
+	WebhookSecret string // MP_WEBHOOK_SECRET: clave para validar el header x-signature
+	BaseURL       string // MP_BASE_URL: permite apuntar a un servidor falso en desarrollo
+	PublicURL     string // PUBLIC_BASE_URL: URL pública del backend (para el webhook)
+	StoreURL      string // STORE_BASE_URL: URL de la tienda (para volver después de pagar)
+}
+
+// MercadoPagoFromEnv lee la configuración de Mercado Pago de las variables de entorno
+func MercadoPagoFromEnv() MercadoPagoConfig {
+	return MercadoPagoConfig{
+		AccessToken:   os.Getenv("MP_ACCESS_TOKEN"),
+		WebhookSecret: os.Getenv("MP_WEBHOOK_SECRET"),
+		BaseURL:       envOrDefault("MP_BASE_URL", "https://api.mercadopago.com"),
+		PublicURL:     os.Getenv("PUBLIC_BASE_URL"),
+		StoreURL:      os.Getenv("STORE_BASE_URL"),
+	}
+}
+
+// Enabled indica si la integración está configurada
+func (c MercadoPagoConfig) Enabled() bool {
+	return c.AccessToken != ""
+}
+
+// envOrDefault devuelve la variable de entorno o el valor por defecto si está vacía
+func envOrDefault(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
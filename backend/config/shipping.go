@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// ShippingConfig contiene la configuración de los envíos de los pedidos
+type ShippingConfig struct {
+	LabelsDir    string        // SHIPPING_LABELS_DIR: carpeta privada de las etiquetas (no se sirve en /uploads)
+	SyncInterval time.Duration // SHIPMENT_SYNC_INTERVAL: cada cuánto se consulta a los transportistas (0 = nunca)
+}
+
+// ShippingFromEnv lee la configuración de envíos de las variables de entorno. Un intervalo
+// inválido se reemplaza por el valor por defecto (30 minutos).
+func ShippingFromEnv() ShippingConfig {
+	interval, err := time.ParseDuration(envOrDefault("SHIPMENT_SYNC_INTERVAL", "30m"))
+	if err != nil || interval < 0 {
+		interval = 30 * time.Minute
+	}
+	return ShippingConfig{
+		LabelsDir:    envOrDefault("SHIPPING_LABELS_DIR", "./shipping-labels"),
+		SyncInterval: interval,
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig contiene el servidor SMTP para los emails de los pedidos. Sin SMTP_HOST los
+// emails quedan deshabilitados.
+type SMTPConfig struct {
+	Host             string        // SMTP_HOST
+	Port             int           // SMTP_PORT (por defecto 587)
+	Username         string        // SMTP_USERNAME: sin usuario no se autentica (ej. mailer.Sink)
+	Password         string        // SMTP_PASSWORD
+	From             string        // SMTP_FROM: dirección del remitente
+	AdminEmail       string        // ORDER_ALERTS_EMAIL: recibe una copia oculta de cada email y las alertas de stock
+	DispatchInterval time.Duration // EMAIL_DISPATCH_INTERVAL: cada cuánto se envían los emails en cola
+}
+
+// SMTPFromEnv lee la configuración SMTP de las variables de entorno. Un puerto o un
+// intervalo inválidos se reemplazan por los valores por defecto (587 y 15 segundos).
+func SMTPFromEnv() SMTPConfig {
+	port, err := strconv.Atoi(envOrDefault("SMTP_PORT", "587"))
+	if err != nil || port <= 0 {
+		port = 587
+	}
+	interval, err := time.ParseDuration(envOrDefault("EMAIL_DISPATCH_INTERVAL", "15s"))
+	if err != nil || interval <= 0 {
+		interval = 15 * time.Second
+	}
+	return SMTPConfig{
+		Host:             os.Getenv("SMTP_HOST"),
+		Port:             port,
+		Username:         os.Getenv("SMTP_USERNAME"),
+		Password:         os.Getenv("SMTP_PASSWORD"),
+		From:             os.Getenv("SMTP_FROM"),
+		AdminEmail:       os.Getenv("ORDER_ALERTS_EMAIL"),
+		DispatchInterval: interval,
+	}
+}
+
+// Enabled indica si hay un servidor SMTP configurado
+func (c SMTPConfig) Enabled() bool {
+	return c.Host != "" && c.From != ""
+}
@@ -2,8 +2,12 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"time"
+
+	"tiendaedgar/backend/models"
 )
 
 // RunMigrations ejecuta las migraciones de la base de datos
@@ -282,6 +286,773 @@ func RunMigrations() error {
 	}
 	log.Println("Tabla site_configs creada o ya existe")
 
+	// Crear tabla product_variants (talla × color con stock propio)
+	createProductVariantsTableSQL := `
+	CREATE TABLE IF NOT EXISTS product_variants (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL,
+		talla TEXT NOT NULL,
+		color TEXT NOT NULL DEFAULT '',
+		sku TEXT,
+		stock INTEGER NOT NULL DEFAULT 0 CHECK(stock >= 0),
+		precio REAL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+		UNIQUE (product_id, talla, color)
+	);
+	`
+	_, err = DB.Exec(createProductVariantsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla product_variants creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants(product_id)`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_product_variants_talla ON product_variants(talla)`)
+	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants(sku) WHERE sku IS NOT NULL AND sku <> ''`)
+	log.Println("Índices de product_variants creados correctamente")
+
+	// Los items de pedido apuntan a la variante vendida
+	if err := AddColumnIfNotExists("order_items", "variant_id", "INTEGER REFERENCES product_variants(id) ON DELETE SET NULL"); err != nil {
+		log.Printf("Nota: Columna variant_id probablemente ya existe o error: %v", err)
+	}
+
+	// Snapshot de talla y color en los items de pedido
+	if err := AddColumnIfNotExists("order_items", "talla", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna talla probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("order_items", "color", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna color probablemente ya existe o error: %v", err)
+	}
+
+	// Crear tabla order_status_history
+	createOrderStatusHistoryTableSQL := `
+	CREATE TABLE IF NOT EXISTS order_status_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER NOT NULL,
+		from_status TEXT NOT NULL DEFAULT '',
+		to_status TEXT NOT NULL,
+		user_id INTEGER,
+		changed_by TEXT,
+		note TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+	`
+	_, err = DB.Exec(createOrderStatusHistoryTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla order_status_history creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id)`)
+
+	if err := backfillProductVariants(); err != nil {
+		log.Printf("Error migrando stock_by_size a product_variants: %v", err)
+	}
+
+	// Crear tabla stock_movements (ledger de inventario)
+	createStockMovementsTableSQL := `
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL,
+		variant_id INTEGER,
+		talla TEXT NOT NULL DEFAULT '',
+		color TEXT NOT NULL DEFAULT '',
+		delta INTEGER NOT NULL,
+		stock_after INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		order_id INTEGER,
+		user_id INTEGER,
+		created_by TEXT,
+		note TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+		FOREIGN KEY (variant_id) REFERENCES product_variants(id) ON DELETE SET NULL,
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+	`
+	_, err = DB.Exec(createStockMovementsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla stock_movements creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id, created_at)`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_stock_movements_created_at ON stock_movements(created_at)`)
+
+	if err := seedOpeningStockMovements(); err != nil {
+		log.Printf("Error registrando saldos iniciales de stock: %v", err)
+	}
+
+	// Referencia pública de pedidos (checkout de la tienda)
+	if err := AddColumnIfNotExists("orders", "reference", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna reference probablemente ya existe o error: %v", err)
+	}
+	if err := backfillOrderReferences(); err != nil {
+		log.Printf("Error generando referencias de pedidos: %v", err)
+	}
+	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_reference ON orders(reference) WHERE reference <> ''`)
+
+	// Medio de pago e importes calculados en el servidor
+	if err := AddColumnIfNotExists("orders", "payment_method", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna payment_method probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "surcharge", "REAL NOT NULL DEFAULT 0"); err != nil {
+		log.Printf("Nota: Columna surcharge probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "subtotal", "REAL"); err != nil {
+		log.Printf("Nota: Columna subtotal probablemente ya existe o error: %v", err)
+	}
+	// Pedidos anteriores: sin recargo, el subtotal es el total registrado
+	DB.Exec(`UPDATE orders SET subtotal = total_amount WHERE subtotal IS NULL`)
+
+	// Cuotas del pedido y reglas de medios de pago (JSON) en la configuración
+	if err := AddColumnIfNotExists("orders", "installments", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		log.Printf("Nota: Columna installments probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("site_configs", "payment_methods", "TEXT"); err != nil {
+		log.Printf("Nota: Columna payment_methods probablemente ya existe o error: %v", err)
+	}
+
+	// Crear tabla order_payments (cobros, señas y devoluciones de pedidos)
+	createOrderPaymentsTableSQL := `
+	CREATE TABLE IF NOT EXISTS order_payments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER NOT NULL,
+		kind TEXT NOT NULL DEFAULT 'payment',
+		amount REAL NOT NULL,
+		method TEXT NOT NULL,
+		reference TEXT NOT NULL DEFAULT '',
+		note TEXT,
+		paid_at DATETIME NOT NULL,
+		user_id INTEGER,
+		created_by TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+	`
+	_, err = DB.Exec(createOrderPaymentsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla order_payments creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_order_payments_order_id ON order_payments(order_id)`)
+
+	// Crear tabla coupons (códigos de descuento)
+	createCouponsTableSQL := `
+	CREATE TABLE IF NOT EXISTS coupons (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		code TEXT NOT NULL UNIQUE,
+		description TEXT,
+		type TEXT NOT NULL,
+		value REAL NOT NULL,
+		min_purchase REAL NOT NULL DEFAULT 0,
+		starts_at DATETIME,
+		ends_at DATETIME,
+		usage_limit INTEGER NOT NULL DEFAULT 0,
+		usage_count INTEGER NOT NULL DEFAULT 0,
+		categories TEXT,
+		product_ids TEXT,
+		active BOOLEAN DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err = DB.Exec(createCouponsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla coupons creada o ya existe")
+
+	// Cupón aplicado al pedido
+	if err := AddColumnIfNotExists("orders", "coupon_code", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna coupon_code probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "discount", "REAL NOT NULL DEFAULT 0"); err != nil {
+		log.Printf("Nota: Columna discount probablemente ya existe o error: %v", err)
+	}
+
+	// Métodos de envío (JSON) en la configuración y envío elegido en el pedido
+	if err := AddColumnIfNotExists("site_configs", "shipping_methods", "TEXT"); err != nil {
+		log.Printf("Nota: Columna shipping_methods probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("site_configs", "free_shipping_threshold", "REAL NOT NULL DEFAULT 0"); err != nil {
+		log.Printf("Nota: Columna free_shipping_threshold probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "shipping_method", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna shipping_method probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "shipping_cost", "REAL NOT NULL DEFAULT 0"); err != nil {
+		log.Printf("Nota: Columna shipping_cost probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "shipping_postal_code", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna shipping_postal_code probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "shipping_province", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna shipping_province probablemente ya existe o error: %v", err)
+	}
+
+	// Crear tabla order_revisions (auditoría de cambios de productos de pedidos)
+	createOrderRevisionsTableSQL := `
+	CREATE TABLE IF NOT EXISTS order_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER NOT NULL,
+		changes TEXT NOT NULL,
+		total_before REAL NOT NULL,
+		total_after REAL NOT NULL,
+		user_id INTEGER,
+		changed_by TEXT,
+		note TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+	`
+	_, err = DB.Exec(createOrderRevisionsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla order_revisions creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_order_revisions_order_id ON order_revisions(order_id)`)
+
+	// Crear tabla order_returns (devoluciones y cambios de talla)
+	createOrderReturnsTableSQL := `
+	CREATE TABLE IF NOT EXISTS order_returns (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER NOT NULL,
+		resolution TEXT NOT NULL,
+		items TEXT NOT NULL,
+		replacements TEXT NOT NULL,
+		returned_amount REAL NOT NULL,
+		replacement_amount REAL NOT NULL DEFAULT 0,
+		difference REAL NOT NULL DEFAULT 0,
+		store_credit_code TEXT NOT NULL DEFAULT '',
+		refund_payment_id INTEGER,
+		note TEXT,
+		user_id INTEGER,
+		created_by TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+	`
+	_, err = DB.Exec(createOrderReturnsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla order_returns creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_order_returns_order_id ON order_returns(order_id)`)
+
+	// Ajuste del saldo del pedido por devoluciones y cambios
+	if err := AddColumnIfNotExists("orders", "return_adjustment", "REAL NOT NULL DEFAULT 0"); err != nil {
+		log.Printf("Nota: Columna return_adjustment probablemente ya existe o error: %v", err)
+	}
+
+	// Crear tabla customers (clientes deduplicados por teléfono o email)
+	createCustomersTableSQL := `
+	CREATE TABLE IF NOT EXISTS customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL DEFAULT '',
+		phone TEXT NOT NULL DEFAULT '',
+		phone_normalized TEXT NOT NULL DEFAULT '',
+		address TEXT NOT NULL DEFAULT '',
+		notes TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err = DB.Exec(createCustomersTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla customers creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_customers_phone_normalized ON customers(phone_normalized)`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_customers_email ON customers(email)`)
+
+	if err := AddColumnIfNotExists("orders", "customer_id", "INTEGER REFERENCES customers(id) ON DELETE SET NULL"); err != nil {
+		log.Printf("Nota: Columna customer_id probablemente ya existe o error: %v", err)
+	}
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id)`)
+	if err := backfillOrderCustomers(); err != nil {
+		log.Printf("Error asociando pedidos a clientes: %v", err)
+	}
+
+	// Número de pedido legible (ej. CS-2026-000123), configurable desde site_configs
+	if err := AddColumnIfNotExists("site_configs", "order_number_prefix", "TEXT NOT NULL DEFAULT 'CS'"); err != nil {
+		log.Printf("Nota: Columna order_number_prefix probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("site_configs", "order_number_digits", "INTEGER NOT NULL DEFAULT 6"); err != nil {
+		log.Printf("Nota: Columna order_number_digits probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "order_number", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna order_number probablemente ya existe o error: %v", err)
+	}
+	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_order_number ON orders(order_number) WHERE order_number <> ''`)
+
+	// Crear tabla order_sequences (último número de pedido asignado en cada año)
+	createOrderSequencesTableSQL := `
+	CREATE TABLE IF NOT EXISTS order_sequences (
+		year INTEGER PRIMARY KEY,
+		last_number INTEGER NOT NULL DEFAULT 0
+	);
+	`
+	_, err = DB.Exec(createOrderSequencesTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla order_sequences creada o ya existe")
+
+	if err := backfillOrderNumbers(); err != nil {
+		log.Printf("Error asignando números a los pedidos: %v", err)
+	}
+
+	// Crear tabla idempotency_keys (respuestas guardadas de peticiones con Idempotency-Key)
+	createIdempotencyKeysTableSQL := `
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		idempotency_key TEXT NOT NULL,
+		scope TEXT NOT NULL,
+		request_hash TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		content_type TEXT NOT NULL DEFAULT '',
+		response_body BLOB,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (scope, idempotency_key)
+	);
+	`
+	_, err = DB.Exec(createIdempotencyKeysTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla idempotency_keys creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at)`)
+
+	// Seguimiento público del pedido (token no adivinable) y número de seguimiento del envío
+	if err := AddColumnIfNotExists("orders", "tracking_token", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna tracking_token probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("orders", "tracking_number", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna tracking_number probablemente ya existe o error: %v", err)
+	}
+	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_tracking_token ON orders(tracking_token) WHERE tracking_token <> ''`)
+	if err := backfillOrderTrackingTokens(); err != nil {
+		log.Printf("Error generando tokens de seguimiento: %v", err)
+	}
+
+	// Plantillas de los mensajes de WhatsApp (vacías: se usan las de por defecto)
+	if err := AddColumnIfNotExists("site_configs", "whatsapp_product_message", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna whatsapp_product_message probablemente ya existe o error: %v", err)
+	}
+	if err := AddColumnIfNotExists("site_configs", "whatsapp_order_message", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		log.Printf("Nota: Columna whatsapp_order_message probablemente ya existe o error: %v", err)
+	}
+
+	// Crear tabla order_shipments (despachos: transportista, seguimiento, etiqueta y estado informado)
+	createOrderShipmentsTableSQL := `
+	CREATE TABLE IF NOT EXISTS order_shipments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER NOT NULL,
+		carrier TEXT NOT NULL,
+		tracking_number TEXT NOT NULL DEFAULT '',
+		shipped_at DATETIME NOT NULL,
+		estimated_delivery DATETIME,
+		label_file TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'in_transit',
+		status_detail TEXT NOT NULL DEFAULT '',
+		delivered_at DATETIME,
+		last_checked_at DATETIME,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+	);
+	`
+	_, err = DB.Exec(createOrderShipmentsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla order_shipments creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_order_shipments_order_id ON order_shipments(order_id)`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_order_shipments_status ON order_shipments(status)`)
+
+	// Crear tabla order_notifications (cola de emails al cliente, uno por tipo y pedido)
+	createOrderNotificationsTableSQL := `
+	CREATE TABLE IF NOT EXISTS order_notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		sent_at DATETIME,
+		UNIQUE (order_id, kind),
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+	);
+	`
+	_, err = DB.Exec(createOrderNotificationsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla order_notifications creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_order_notifications_status ON order_notifications(status)`)
+
+	// Crear tabla stock_alerts (avisos de stock bajo y agotado para la tienda)
+	createStockAlertsTableSQL := `
+	CREATE TABLE IF NOT EXISTS stock_alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL,
+		variant_id INTEGER,
+		product_name TEXT NOT NULL,
+		talla TEXT NOT NULL DEFAULT '',
+		color TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		stock INTEGER NOT NULL,
+		threshold INTEGER NOT NULL,
+		email_status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		read_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		sent_at DATETIME,
+		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+		FOREIGN KEY (variant_id) REFERENCES product_variants(id) ON DELETE SET NULL
+	);
+	`
+	_, err = DB.Exec(createStockAlertsTableSQL)
+	if err != nil {
+		return err
+	}
+	log.Println("Tabla stock_alerts creada o ya existe")
+
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_stock_alerts_email_status ON stock_alerts(email_status)`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_stock_alerts_created_at ON stock_alerts(created_at)`)
+
+	return nil
+}
+
+// backfillOrderReferences asigna una referencia pública a los pedidos que no la tienen
+func backfillOrderReferences() error {
+	rows, err := DB.Query("SELECT id FROM orders WHERE reference = ''")
+	if err != nil {
+		return err
+	}
+
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, err := DB.Exec("UPDATE orders SET reference = ? WHERE id = ?", models.NewOrderReference(), id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillOrderTrackingTokens genera el token de seguimiento de los pedidos que no lo tienen
+func backfillOrderTrackingTokens() error {
+	rows, err := DB.Query("SELECT id FROM orders WHERE tracking_token = ''")
+	if err != nil {
+		return err
+	}
+
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, err := DB.Exec("UPDATE orders SET tracking_token = ? WHERE id = ?", models.NewTrackingToken(), id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillOrderNumbers numera los pedidos que no tienen número, en orden cronológico y
+// con el secuencial de su año de creación, y deja order_sequences al día
+func backfillOrderNumbers() error {
+	format := models.OrderNumberFormat{Prefix: models.DefaultOrderNumberPrefix, Digits: models.DefaultOrderNumberDigits}
+	err := DB.QueryRow("SELECT order_number_prefix, order_number_digits FROM site_configs LIMIT 1").Scan(&format.Prefix, &format.Digits)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	type pendingOrder struct {
+		id        uint
+		createdAt time.Time
+	}
+	rows, err := DB.Query("SELECT id, created_at FROM orders WHERE order_number = '' ORDER BY created_at, id")
+	if err != nil {
+		return err
+	}
+	var pending []pendingOrder
+	for rows.Next() {
+		var o pendingOrder
+		if err := rows.Scan(&o.id, &o.createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, o)
+	}
+	rows.Close()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, o := range pending {
+		year := o.createdAt.Year()
+		var seq int
+		err := tx.QueryRow(`
+			INSERT INTO order_sequences (year, last_number) VALUES (?, 1)
+			ON CONFLICT(year) DO UPDATE SET last_number = last_number + 1
+			RETURNING last_number
+		`, year).Scan(&seq)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE orders SET order_number = ? WHERE id = ?", format.Format(year, seq), o.id); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Números de pedido asignados a %d pedidos existentes", len(pending))
+	return tx.Commit()
+}
+
+// backfillOrderCustomers asocia a un cliente los pedidos que no lo tienen, con el mismo
+// criterio que los pedidos nuevos: primero por teléfono normalizado y luego por email.
+// Los pedidos se recorren en orden cronológico para que el cliente quede con los datos más recientes.
+func backfillOrderCustomers() error {
+	customers := map[uint]*models.Customer{}
+	byPhone := map[string]uint{}
+	byEmail := map[string]uint{}
+	register := func(c *models.Customer) {
+		if phone := models.NormalizePhone(c.Phone); phone != "" {
+			if _, ok := byPhone[phone]; !ok {
+				byPhone[phone] = c.ID
+			}
+		}
+		if c.Email != "" {
+			if _, ok := byEmail[c.Email]; !ok {
+				byEmail[c.Email] = c.ID
+			}
+		}
+	}
+
+	rows, err := DB.Query("SELECT id, name, email, phone, address FROM customers ORDER BY id")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		c := &models.Customer{}
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Address); err != nil {
+			rows.Close()
+			return err
+		}
+		customers[c.ID] = c
+		register(c)
+	}
+	rows.Close()
+
+	type pendingOrder struct {
+		id                          uint
+		name, email, phone, address string
+	}
+	rows, err = DB.Query(`
+		SELECT id, customer_name, COALESCE(customer_email, ''), COALESCE(customer_phone, ''), COALESCE(customer_address, '')
+		FROM orders WHERE customer_id IS NULL ORDER BY created_at, id
+	`)
+	if err != nil {
+		return err
+	}
+	var pending []pendingOrder
+	for rows.Next() {
+		var o pendingOrder
+		if err := rows.Scan(&o.id, &o.name, &o.email, &o.phone, &o.address); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, o)
+	}
+	rows.Close()
+
+	touched := map[uint]bool{}
+	linked := 0
+	for _, o := range pending {
+		phone, email := models.NormalizePhone(o.phone), models.NormalizeEmail(o.email)
+		if phone == "" && email == "" {
+			continue
+		}
+
+		id := byPhone[phone]
+		if id == 0 {
+			id = byEmail[email]
+		}
+
+		if id == 0 {
+			c := &models.Customer{Name: o.name, Email: email, Phone: o.phone, Address: o.address}
+			c.Normalize()
+			res, err := DB.Exec(`INSERT INTO customers (name, email, phone, phone_normalized, address) VALUES (?, ?, ?, ?, ?)`,
+				c.Name, c.Email, c.Phone, phone, c.Address)
+			if err != nil {
+				return err
+			}
+			newID, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			c.ID = uint(newID)
+			customers[c.ID] = c
+			id = c.ID
+		} else {
+			customers[id].MergeContact(o.name, o.email, o.phone, o.address)
+			touched[id] = true
+		}
+		register(customers[id])
+
+		if _, err := DB.Exec("UPDATE orders SET customer_id = ? WHERE id = ?", id, o.id); err != nil {
+			return err
+		}
+		linked++
+	}
+
+	for id := range touched {
+		c := customers[id]
+		if _, err := DB.Exec(`UPDATE customers SET name = ?, email = ?, phone = ?, phone_normalized = ?, address = ? WHERE id = ?`,
+			c.Name, c.Email, c.Phone, models.NormalizePhone(c.Phone), c.Address, c.ID); err != nil {
+			return err
+		}
+	}
+
+	if linked > 0 {
+		log.Printf("Pedidos asociados a clientes: %d", linked)
+	}
+	return nil
+}
+
+// seedOpeningStockMovements registra el stock existente como saldo inicial del ledger
+// la primera vez que se crea la tabla, para que la suma de movimientos coincida con el stock
+func seedOpeningStockMovements() error {
+	var count int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM stock_movements").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	// Variantes con stock
+	if _, err := DB.Exec(`
+		INSERT INTO stock_movements (product_id, variant_id, talla, color, delta, stock_after, reason, created_by, note)
+		SELECT product_id, id, talla, color, stock, stock, ?, 'sistema', 'Saldo inicial'
+		FROM product_variants WHERE stock <> 0
+	`, models.StockMovementImport); err != nil {
+		return err
+	}
+
+	// Productos sin variantes con stock total
+	if _, err := DB.Exec(`
+		INSERT INTO stock_movements (product_id, delta, stock_after, reason, created_by, note)
+		SELECT id, stock, stock, ?, 'sistema', 'Saldo inicial'
+		FROM products
+		WHERE stock <> 0 AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+	`, models.StockMovementImport); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// backfillProductVariants crea variantes (sin color) a partir del stock_by_size
+// de los productos que todavía no tienen variantes cargadas
+func backfillProductVariants() error {
+	rows, err := DB.Query(`
+		SELECT id, stock_by_size FROM products
+		WHERE stock_by_size IS NOT NULL AND stock_by_size NOT IN ('', 'null', '{}')
+		  AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	stockByProduct := map[uint]map[string]int{}
+	for rows.Next() {
+		var id uint
+		var stockBySizeJSON string
+		if err := rows.Scan(&id, &stockBySizeJSON); err != nil {
+			rows.Close()
+			return err
+		}
+		var stockBySize map[string]int
+		if err := json.Unmarshal([]byte(stockBySizeJSON), &stockBySize); err != nil {
+			log.Printf("Nota: stock_by_size inválido en producto %d: %v", id, err)
+			continue
+		}
+		stockByProduct[id] = stockBySize
+	}
+	rows.Close()
+
+	for productID, stockBySize := range stockByProduct {
+		for talla, stock := range stockBySize {
+			if stock < 0 {
+				stock = 0
+			}
+			_, err := DB.Exec(
+				`INSERT OR IGNORE INTO product_variants (product_id, talla, color, sku, stock) VALUES (?, ?, '', ?, ?)`,
+				productID, talla, models.BuildVariantSKU(productID, talla, ""), stock,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		// El stock total pasa a ser la suma de las variantes
+		_, err := DB.Exec(
+			`UPDATE products SET stock = (SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = ?) WHERE id = ?`,
+			productID, productID,
+		)
+		if err != nil {
+			return err
+		}
+		log.Printf("Variantes creadas para producto %d a partir de stock_by_size", productID)
+	}
+
 	return nil
 }
 
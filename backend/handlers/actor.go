@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"tiendaedgar/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFromContext obtiene el admin autenticado (seteado por middleware.AuthRequired)
+func actorFromContext(c *gin.Context) models.Actor {
+	var actor models.Actor
+
+	if userID, exists := c.Get("user_id"); exists {
+		if id, ok := userID.(uint); ok {
+			actor.UserID = &id
+		}
+	}
+	if username, exists := c.Get("username"); exists {
+		if name, ok := username.(string); ok {
+			actor.Username = name
+		}
+	}
+
+	return actor
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkoutMaxBodyBytes limita el tamaño del carrito recibido
+const checkoutMaxBodyBytes = 64 << 10
+
+// CheckoutHandler maneja el checkout público de la tienda
+type CheckoutHandler struct {
+	service *services.CheckoutService
+}
+
+// NewCheckoutHandler crea una nueva instancia del handler
+func NewCheckoutHandler(service *services.CheckoutService) *CheckoutHandler {
+	return &CheckoutHandler{service: service}
+}
+
+// Checkout maneja POST /api/checkout (no requiere autenticación)
+func (h *CheckoutHandler) Checkout(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, checkoutMaxBodyBytes)
+
+	var req models.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"message": "El carrito enviado no es válido",
+		})
+		return
+	}
+
+	order, err := h.service.Checkout(&req)
+	if err != nil {
+		var stockErr *services.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			respondOrderError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "No se pudo registrar el pedido",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.NewCheckoutResponse(order))
+}
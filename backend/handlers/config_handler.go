@@ -1,8 +1,8 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"tiendaedgar/backend/models"
 	"tiendaedgar/backend/services"
 
 	"github.com/gin-gonic/gin"
@@ -27,15 +27,33 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, config)
 }
 
+// UpdateConfig aplica los datos enviados sobre la configuración guardada: los campos que
+// no se envían conservan su valor actual
 func (h *ConfigHandler) UpdateConfig(c *gin.Context) {
-	var configInput models.SiteConfig
-	if err := c.ShouldBindJSON(&configInput); err != nil {
+	configInput, err := h.service.GetConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la configuración"})
+		return
+	}
+	id := configInput.ID
+	// Las listas enviadas reemplazan a las actuales en lugar de mezclarse con ellas;
+	// si no se envían, UpdateConfig conserva las guardadas
+	configInput.PaymentMethods, configInput.ShippingMethods = nil, nil
+	if err := c.ShouldBindJSON(configInput); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	if err := h.service.UpdateConfig(&configInput); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar la configuración"})
+	configInput.ID = id
+
+	if err := h.service.UpdateConfig(configInput); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidConfig) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   "Error al actualizar la configuración",
+			"message": err.Error(),
+		})
 		return
 	}
 
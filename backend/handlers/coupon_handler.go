@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CouponHandler maneja las peticiones HTTP de cupones de descuento (admin)
+type CouponHandler struct {
+	service *services.CouponService
+}
+
+// NewCouponHandler crea una nueva instancia del handler
+func NewCouponHandler(service *services.CouponService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+// parseCouponID obtiene el ID de cupón de la URL
+func parseCouponID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "ID inválido",
+			"message": "El ID debe ser un número válido",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetCoupons maneja GET /api/coupons
+func (h *CouponHandler) GetCoupons(c *gin.Context) {
+	coupons, err := h.service.GetAllCoupons()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error al obtener cupones",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": coupons, "total": len(coupons)})
+}
+
+// GetCoupon maneja GET /api/coupons/:id
+func (h *CouponHandler) GetCoupon(c *gin.Context) {
+	id, ok := parseCouponID(c)
+	if !ok {
+		return
+	}
+
+	coupon, err := h.service.GetCouponByID(id)
+	if err != nil {
+		respondCouponError(c, "Error al obtener cupón", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+// CreateCoupon maneja POST /api/coupons
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var coupon models.Coupon
+	if err := c.ShouldBindJSON(&coupon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.CreateCoupon(&coupon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Error al crear cupón",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, coupon)
+}
+
+// UpdateCoupon maneja PUT /api/coupons/:id
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, ok := parseCouponID(c)
+	if !ok {
+		return
+	}
+
+	var coupon models.Coupon
+	if err := c.ShouldBindJSON(&coupon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.UpdateCoupon(id, &coupon); err != nil {
+		respondCouponError(c, "Error al actualizar cupón", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+// DeleteCoupon maneja DELETE /api/coupons/:id
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, ok := parseCouponID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCoupon(id); err != nil {
+		respondCouponError(c, "Error al eliminar cupón", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cupón eliminado correctamente"})
+}
+
+// respondCouponError responde 404 si el cupón no existe y 400 en el resto de los casos
+func respondCouponError(c *gin.Context, title string, err error) {
+	if err.Error() == "cupón no encontrado" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Cupón no encontrado",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   title,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomerHandler maneja las peticiones HTTP de clientes (admin)
+type CustomerHandler struct {
+	service *services.CustomerService
+}
+
+// NewCustomerHandler crea una nueva instancia del handler
+func NewCustomerHandler(service *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// parseCustomerID obtiene el ID de cliente de la URL
+func parseCustomerID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "ID inválido",
+			"message": "El ID debe ser un número válido",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetCustomers maneja GET /api/customers?search=&page=&limit=
+func (h *CustomerHandler) GetCustomers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	customers, total, err := h.service.GetAllCustomers(page, limit, c.Query("search"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error al obtener clientes",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  customers,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetCustomer maneja GET /api/customers/:id (incluye historial de pedidos)
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
+	id, ok := parseCustomerID(c)
+	if !ok {
+		return
+	}
+
+	customer, err := h.service.GetCustomerByID(id)
+	if err != nil {
+		respondCustomerError(c, "Error al obtener cliente", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// UpdateCustomer maneja PUT /api/customers/:id
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	id, ok := parseCustomerID(c)
+	if !ok {
+		return
+	}
+
+	var customer models.Customer
+	if err := c.ShouldBindJSON(&customer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.UpdateCustomer(id, &customer); err != nil {
+		respondCustomerError(c, "Error al actualizar cliente", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// MergeCustomer maneja POST /api/customers/:id/merge { "source_id": 12 }: une la ficha
+// duplicada source_id en la del cliente :id
+func (h *CustomerHandler) MergeCustomer(c *gin.Context) {
+	id, ok := parseCustomerID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SourceID uint `json:"source_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"message": "source_id es requerido",
+		})
+		return
+	}
+
+	customer, err := h.service.MergeCustomers(id, req.SourceID)
+	if err != nil {
+		respondCustomerError(c, "Error al unir clientes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// respondCustomerError responde 404 si el cliente no existe y 400 en el resto de los casos
+func respondCustomerError(c *gin.Context, title string, err error) {
+	if err.Error() == "cliente no encontrado" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Cliente no encontrado",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   title,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler maneja las peticiones HTTP de los emails de los pedidos (admin)
+type NotificationHandler struct {
+	service *services.NotificationService
+}
+
+// NewNotificationHandler crea una nueva instancia del handler
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetOrderNotifications maneja GET /api/orders/:id/notifications
+func (h *NotificationHandler) GetOrderNotifications(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	notifications, err := h.service.GetOrderNotifications(uint(id))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": notifications})
+}
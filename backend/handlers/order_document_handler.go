@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderDocumentHandler maneja la descarga del comprobante y el remito de los pedidos
+type OrderDocumentHandler struct {
+	service *services.OrderDocumentService
+}
+
+// NewOrderDocumentHandler crea una nueva instancia del handler
+func NewOrderDocumentHandler(service *services.OrderDocumentService) *OrderDocumentHandler {
+	return &OrderDocumentHandler{service: service}
+}
+
+// GetInvoice maneja GET /api/orders/:id/invoice.pdf
+func (h *OrderDocumentHandler) GetInvoice(c *gin.Context) {
+	h.render(c, h.service.Invoice)
+}
+
+// GetPackingSlip maneja GET /api/orders/:id/packing-slip.pdf
+func (h *OrderDocumentHandler) GetPackingSlip(c *gin.Context) {
+	h.render(c, h.service.PackingSlip)
+}
+
+func (h *OrderDocumentHandler) render(c *gin.Context, generate func(uint) (*services.OrderDocument, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	document, err := generate(uint(id))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	// inline: el navegador lo muestra y desde ahí se puede descargar o compartir
+	c.Header("Content-Disposition", `inline; filename="`+document.Filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", document.Content)
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
 	"tiendaedgar/backend/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,7 +30,13 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CreateOrder(&order); err != nil {
+	if err := h.service.CreateOrder(&order, actorFromContext(c)); err != nil {
+		var stockErr *services.InsufficientStockError
+		var priceErr *services.PriceMismatchError
+		if errors.As(err, &stockErr) || errors.As(err, &priceErr) {
+			respondOrderError(c, err)
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -33,14 +44,32 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	c.JSON(http.StatusCreated, order)
 }
 
-// GetOrders maneja la obtención de la lista de pedidos
+// GetOrders maneja la obtención de la lista de pedidos. Filtros opcionales:
+// status y payment_method (separados por coma), from/to (YYYY-MM-DD, ambos inclusive),
+// min_total/max_total, product_id, customer_id, search y sort (newest, oldest, total_desc, total_asc).
+// Con include=items cada pedido incluye sus productos.
 func (h *OrderHandler) GetOrders(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")
-	search := c.Query("search")
 
-	orders, total, err := h.service.GetAllOrders(page, limit, status, search)
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	includeItems := false
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) == "items" {
+			includeItems = true
+		}
+	}
+
+	orders, total, err := h.service.GetAllOrders(page, limit, filter, includeItems)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener pedidos"})
 		return
@@ -54,21 +83,103 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	})
 }
 
-// GetOrder maneja la obtención de un pedido por ID
+// parseOrderFilter arma el filtro del listado de pedidos a partir de la query string
+func parseOrderFilter(c *gin.Context) (models.OrderFilter, error) {
+	filter := models.OrderFilter{
+		Search: strings.TrimSpace(c.Query("search")),
+		Sort:   models.OrderSort(c.Query("sort")),
+	}
+
+	if statusStr := c.Query("status"); statusStr != "" {
+		for _, status := range strings.Split(statusStr, ",") {
+			filter.Statuses = append(filter.Statuses, models.OrderStatus(strings.TrimSpace(status)))
+		}
+	}
+	if methodStr := c.Query("payment_method"); methodStr != "" {
+		for _, method := range strings.Split(methodStr, ",") {
+			filter.PaymentMethods = append(filter.PaymentMethods, models.PaymentMethod(strings.ToLower(strings.TrimSpace(method))))
+		}
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := parseDateParam(fromStr, time.Time{})
+		if err != nil {
+			return filter, fmt.Errorf("fecha 'from' inválida (formato esperado: YYYY-MM-DD)")
+		}
+		filter.From = &from
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := parseDateParam(toStr, time.Time{})
+		if err != nil {
+			return filter, fmt.Errorf("fecha 'to' inválida (formato esperado: YYYY-MM-DD)")
+		}
+		to = to.AddDate(0, 0, 1) // Incluye el día completo
+		filter.To = &to
+	}
+
+	for param, target := range map[string]**float64{"min_total": &filter.MinTotal, "max_total": &filter.MaxTotal} {
+		if value := c.Query(param); value != "" {
+			amount, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return filter, fmt.Errorf("importe '%s' inválido", param)
+			}
+			*target = &amount
+		}
+	}
+
+	for param, target := range map[string]*uint{"product_id": &filter.ProductID, "customer_id": &filter.CustomerID} {
+		if value := c.Query(param); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || id == 0 {
+				return filter, fmt.Errorf("'%s' inválido", param)
+			}
+			*target = uint(id)
+		}
+	}
+
+	return filter, nil
+}
+
+// GetOrder maneja la obtención de un pedido por ID o por número (ej. /api/orders/CS-2026-000123)
 func (h *OrderHandler) GetOrder(c *gin.Context) {
+	var order *models.Order
+	var err error
+	if id, convErr := strconv.Atoi(c.Param("id")); convErr == nil {
+		order, err = h.service.GetOrderByID(uint(id))
+	} else {
+		order, err = h.service.GetOrderByNumber(c.Param("id"))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener pedido"})
+		return
+	}
+	if order == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetOrderHistory maneja GET /api/orders/:id/history
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
 
-	order, err := h.service.GetOrderByID(uint(id))
+	history, err := h.service.GetOrderHistory(uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		if err.Error() == "orden no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	c.JSON(http.StatusOK, gin.H{"data": history})
 }
 
 // UpdateStatus maneja la actualización del estado
@@ -81,6 +192,7 @@ func (h *OrderHandler) UpdateStatus(c *gin.Context) {
 
 	var req struct {
 		Status string `json:"status" binding:"required"`
+		Note   string `json:"note"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -88,8 +200,8 @@ func (h *OrderHandler) UpdateStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateOrderStatus(uint(id), models.OrderStatus(req.Status)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}) // Mostrar error real
+	if err := h.service.UpdateOrderStatus(uint(id), models.OrderStatus(req.Status), actorFromContext(c), req.Note); err != nil {
+		respondOrderError(c, err)
 		return
 	}
 
@@ -111,13 +223,106 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateOrder(uint(id), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondOrderError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Pedido actualizado correctamente"})
 }
 
+// UpdateOrderItems maneja PUT /api/orders/:id/items: agrega, quita o cambia cantidades
+// de productos, ajusta el stock por la diferencia y recalcula los totales
+func (h *OrderHandler) UpdateOrderItems(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.OrderItemsUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revision, err := h.service.UpdateOrderItems(uint(id), req.OrderItems(), actorFromContext(c), req.Note)
+	if err != nil {
+		var stockErr *services.InsufficientStockError
+		var priceErr *services.PriceMismatchError
+		if err.Error() == "orden no encontrada" || errors.As(err, &stockErr) || errors.As(err, &priceErr) ||
+			errors.Is(err, services.ErrOrderNotEditable) {
+			respondOrderError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.service.GetOrderByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener pedido"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": order, "revision": revision})
+}
+
+// GetOrderRevisions maneja GET /api/orders/:id/revisions (auditoría de cambios de productos)
+func (h *OrderHandler) GetOrderRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	revisions, err := h.service.GetOrderRevisions(uint(id))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": revisions})
+}
+
+// GetPublicTracking maneja GET /api/public/orders/:token (sin auth): estado, historial,
+// productos y envío del pedido para la página de seguimiento del cliente
+func (h *OrderHandler) GetPublicTracking(c *gin.Context) {
+	tracking, err := h.service.GetOrderTracking(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener pedido"})
+		return
+	}
+	if tracking == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tracking)
+}
+
+// RegenerateTrackingToken maneja POST /api/orders/:id/tracking-token: invalida el enlace
+// de seguimiento actual y genera uno nuevo
+func (h *OrderHandler) RegenerateTrackingToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	token, err := h.service.RegenerateTrackingToken(uint(id))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tracking_token": token})
+}
+
 // DeleteOrder maneja la eliminación de un pedido
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -126,10 +331,114 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteOrder(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.DeleteOrder(uint(id), actorFromContext(c)); err != nil {
+		respondOrderError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Pedido eliminado correctamente"})
 }
+
+// orderPaymentRequest es el cuerpo de POST /api/orders/:id/payments y /refunds
+type orderPaymentRequest struct {
+	Amount    float64              `json:"amount" binding:"required"`
+	Method    models.PaymentMethod `json:"method" binding:"required"`
+	Reference string               `json:"reference"`
+	Note      string               `json:"note"`
+	PaidAt    *time.Time           `json:"paid_at"` // Opcional: por defecto, ahora
+}
+
+// toPayment convierte el cuerpo recibido en un movimiento del ledger
+func (r orderPaymentRequest) toPayment() *models.OrderPayment {
+	payment := &models.OrderPayment{
+		Amount:    r.Amount,
+		Method:    r.Method,
+		Reference: r.Reference,
+		Note:      r.Note,
+	}
+	if r.PaidAt != nil {
+		payment.PaidAt = *r.PaidAt
+	}
+	return payment
+}
+
+// GetOrderPayments maneja GET /api/orders/:id/payments
+func (h *OrderHandler) GetOrderPayments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	payments, summary, err := h.service.GetOrderPayments(uint(id))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": payments, "summary": summary})
+}
+
+// AddPayment maneja POST /api/orders/:id/payments (seña, saldo o pago total)
+func (h *OrderHandler) AddPayment(c *gin.Context) {
+	h.recordPayment(c, h.service.RecordPayment)
+}
+
+// AddRefund maneja POST /api/orders/:id/refunds
+func (h *OrderHandler) AddRefund(c *gin.Context) {
+	h.recordPayment(c, h.service.RecordRefund)
+}
+
+func (h *OrderHandler) recordPayment(c *gin.Context, record func(uint, *models.OrderPayment, models.Actor) (models.PaymentSummary, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req orderPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Importe y medio de pago requeridos"})
+		return
+	}
+
+	payment := req.toPayment()
+	summary, err := record(uint(id), payment, actorFromContext(c))
+	if err != nil {
+		if err.Error() == "orden no encontrada" || errors.Is(err, services.ErrPaymentExceedsBalance) ||
+			errors.Is(err, services.ErrRefundExceedsPaid) || errors.Is(err, services.ErrOrderCancelled) ||
+			errors.Is(err, services.ErrInvalidStatusTransition) {
+			respondOrderError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": payment, "summary": summary})
+}
+
+// respondOrderError traduce los errores del servicio de pedidos a códigos HTTP
+func respondOrderError(c *gin.Context, err error) {
+	var stockErr *services.InsufficientStockError
+	var priceErr *services.PriceMismatchError
+	switch {
+	case errors.As(err, &stockErr):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "items": stockErr.Items})
+	case errors.As(err, &priceErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "items": priceErr.Items})
+	case errors.Is(err, services.ErrInvalidStatusTransition), errors.Is(err, services.ErrInvalidOrderStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPaymentExceedsBalance), errors.Is(err, services.ErrRefundExceedsPaid),
+		errors.Is(err, services.ErrReturnExceedsPurchased):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOrderCancelled), errors.Is(err, services.ErrCouponExhausted),
+		errors.Is(err, services.ErrOrderNotEditable), errors.Is(err, services.ErrOrderNotReturnable),
+		errors.Is(err, services.ErrOrderHasReturns), errors.Is(err, repositories.ErrStockManagedByVariants):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err.Error() == "orden no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookMaxBodyBytes limita el tamaño de los avisos recibidos en el webhook
+const webhookMaxBodyBytes = 16 << 10
+
+// PaymentHandler maneja los pagos online (Mercado Pago)
+type PaymentHandler struct {
+	service *services.PaymentService
+}
+
+// NewPaymentHandler crea una nueva instancia del handler
+func NewPaymentHandler(service *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+// CreateCheckoutPreference maneja POST /api/checkout/:reference/mercadopago (público)
+func (h *PaymentHandler) CreateCheckoutPreference(c *gin.Context) {
+	preference, err := h.service.CreatePreferenceByReference(c.Param("reference"))
+	if err != nil {
+		respondPaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, preference)
+}
+
+// CreateOrderPreference maneja POST /api/orders/:id/payment-preference (admin)
+func (h *PaymentHandler) CreateOrderPreference(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	preference, err := h.service.CreatePreference(uint(id))
+	if err != nil {
+		respondPaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, preference)
+}
+
+// MercadoPagoWebhook maneja POST /api/webhooks/mercadopago.
+// Mercado Pago envía el ID del pago en el query (?data.id=...&type=payment) y en el
+// cuerpo; el formato IPN anterior usa ?id=...&topic=payment. Cualquier respuesta
+// distinta de 2xx hace que Mercado Pago reintente el aviso: los avisos que no se pueden
+// procesar (otros tópicos, ID inválido) responden 200 y solo las fallas reales responden 500.
+func (h *PaymentHandler) MercadoPagoWebhook(c *gin.Context) {
+	notification := models.PaymentNotification{
+		Type:      firstNonEmpty(c.Query("type"), c.Query("topic")),
+		DataID:    firstNonEmpty(c.Query("data.id"), c.Query("id")),
+		RequestID: c.GetHeader("x-request-id"),
+		Signature: c.GetHeader("x-signature"),
+	}
+
+	var body struct {
+		Type string `json:"type"`
+		Data struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"data"`
+	}
+	if raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, webhookMaxBodyBytes)); err == nil && len(raw) > 0 {
+		if json.Unmarshal(raw, &body) == nil {
+			notification.Type = firstNonEmpty(notification.Type, body.Type)
+			notification.DataID = firstNonEmpty(notification.DataID, rawID(body.Data.ID))
+		}
+	}
+
+	result, err := h.service.HandleNotification(notification)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPaymentNotification):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Firma inválida"})
+		case errors.Is(err, services.ErrPaymentGatewayDisabled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			log.Printf("ERROR: webhook de Mercado Pago (pago %s): %v", notification.DataID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la notificación"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondPaymentError traduce los errores del servicio de pagos a códigos HTTP
+func respondPaymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPaymentGatewayDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Pagos online no disponibles", "message": err.Error()})
+	case errors.Is(err, services.ErrOrderNotPayable):
+		c.JSON(http.StatusConflict, gin.H{"error": "No se puede pagar el pedido", "message": err.Error()})
+	case err.Error() == "orden no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Error al iniciar el pago", "message": err.Error()})
+	}
+}
+
+// rawID acepta el ID del cuerpo del webhook como número o como texto
+func rawID(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var n json.Number
+	if json.Unmarshal(raw, &n) == nil {
+		return n.String()
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
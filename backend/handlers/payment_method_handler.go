@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentMethodHandler expone los medios de pago y sus recargos/descuentos
+type PaymentMethodHandler struct {
+	pricer *services.OrderPricer
+}
+
+// NewPaymentMethodHandler crea una nueva instancia del handler
+func NewPaymentMethodHandler(pricer *services.OrderPricer) *PaymentMethodHandler {
+	return &PaymentMethodHandler{pricer: pricer}
+}
+
+// GetPaymentMethods maneja GET /api/payment-methods (medios habilitados)
+func (h *PaymentMethodHandler) GetPaymentMethods(c *gin.Context) {
+	rules, err := h.pricer.Rules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener medios de pago"})
+		return
+	}
+
+	enabled := []models.PaymentMethodRule{}
+	for _, rule := range rules.PaymentMethods {
+		if rule.Enabled {
+			enabled = append(enabled, rule)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enabled})
+}
+
+// QuotePayment maneja GET /api/payment-methods/quote?amount=25000
+// Devuelve el total y el valor de cada cuota para cada medio de pago habilitado.
+func (h *PaymentMethodHandler) QuotePayment(c *gin.Context) {
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil || amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Importe inválido"})
+		return
+	}
+
+	rules, err := h.pricer.Rules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener medios de pago"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"amount": amount,
+		"data":   rules.Quote(amount),
+	})
+}
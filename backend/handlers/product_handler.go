@@ -37,7 +37,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	}
 
 	// Crear el producto
-	if err := h.service.CreateProduct(&product); err != nil {
+	if err := h.service.CreateProduct(&product, actorFromContext(c)); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Error al crear producto",
 			"message": err.Error(),
@@ -155,7 +155,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	product.ID = uint(id)
 
 	// Actualizar el producto
-	if err := h.service.UpdateProduct(&product); err != nil {
+	if err := h.service.UpdateProduct(&product, actorFromContext(c)); err != nil {
 		if err.Error() == "producto no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":   "Producto no encontrado",
@@ -200,7 +200,7 @@ func (h *ProductHandler) PartialUpdateProduct(c *gin.Context) {
 	}
 
 	// Actualizar el producto
-	if err := h.service.PartialUpdateProduct(uint(id), updates); err != nil {
+	if err := h.service.PartialUpdateProduct(uint(id), updates, actorFromContext(c)); err != nil {
 		if err.Error() == "producto no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":   "Producto no encontrado",
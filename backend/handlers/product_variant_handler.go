@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProductVariantHandler maneja las peticiones HTTP de variantes de producto
+type ProductVariantHandler struct {
+	service *services.ProductVariantService
+}
+
+// NewProductVariantHandler crea una nueva instancia del handler
+func NewProductVariantHandler(service *services.ProductVariantService) *ProductVariantHandler {
+	return &ProductVariantHandler{
+		service: service,
+	}
+}
+
+// parseVariantParams obtiene el ID de producto (y de variante si corresponde) de la URL
+func parseVariantParams(c *gin.Context, withVariant bool) (uint, uint, bool) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "ID inválido",
+			"message": "El ID debe ser un número válido",
+		})
+		return 0, 0, false
+	}
+
+	if !withVariant {
+		return uint(productID), 0, true
+	}
+
+	variantID, err := strconv.ParseUint(c.Param("variantId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "ID de variante inválido",
+			"message": "El ID debe ser un número válido",
+		})
+		return 0, 0, false
+	}
+
+	return uint(productID), uint(variantID), true
+}
+
+// GetVariants maneja GET /api/products/:id/variants
+func (h *ProductVariantHandler) GetVariants(c *gin.Context) {
+	productID, _, ok := parseVariantParams(c, false)
+	if !ok {
+		return
+	}
+
+	variants, err := h.service.GetVariants(productID)
+	if err != nil {
+		if err.Error() == "producto no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Producto no encontrado",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error al obtener variantes",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"variants": variants,
+		"total":    len(variants),
+	})
+}
+
+// CreateVariant maneja POST /api/products/:id/variants
+func (h *ProductVariantHandler) CreateVariant(c *gin.Context) {
+	productID, _, ok := parseVariantParams(c, false)
+	if !ok {
+		return
+	}
+
+	var variant models.ProductVariant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.CreateVariant(productID, &variant, actorFromContext(c)); err != nil {
+		if err.Error() == "producto no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Producto no encontrado",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Error al crear variante",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+// UpdateVariant maneja PUT /api/products/:id/variants/:variantId
+func (h *ProductVariantHandler) UpdateVariant(c *gin.Context) {
+	productID, variantID, ok := parseVariantParams(c, true)
+	if !ok {
+		return
+	}
+
+	var variant models.ProductVariant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	variant.ID = variantID
+
+	if err := h.service.UpdateVariant(productID, &variant, actorFromContext(c)); err != nil {
+		if err.Error() == "variante no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Variante no encontrada",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Error al actualizar variante",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// DeleteVariant maneja DELETE /api/products/:id/variants/:variantId
+func (h *ProductVariantHandler) DeleteVariant(c *gin.Context) {
+	productID, variantID, ok := parseVariantParams(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteVariant(productID, variantID, actorFromContext(c)); err != nil {
+		if err.Error() == "variante no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Variante no encontrada",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error al eliminar variante",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReturnHandler maneja las peticiones HTTP de devoluciones y cambios (admin)
+type ReturnHandler struct {
+	service *services.ReturnService
+}
+
+// NewReturnHandler crea una nueva instancia del handler
+func NewReturnHandler(service *services.ReturnService) *ReturnHandler {
+	return &ReturnHandler{service: service}
+}
+
+// GetOrderReturns maneja GET /api/orders/:id/returns
+func (h *ReturnHandler) GetOrderReturns(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	returns, err := h.service.GetOrderReturns(uint(id))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": returns})
+}
+
+// CreateReturn maneja POST /api/orders/:id/returns (devolución o cambio de talla)
+func (h *ReturnHandler) CreateReturn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.OrderReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret, err := h.service.CreateReturn(uint(id), &req, actorFromContext(c))
+	if err != nil {
+		var stockErr *services.InsufficientStockError
+		if err.Error() == "orden no encontrada" || errors.As(err, &stockErr) ||
+			errors.Is(err, services.ErrOrderNotReturnable) || errors.Is(err, services.ErrReturnExceedsPurchased) ||
+			errors.Is(err, services.ErrRefundExceedsPaid) {
+			respondOrderError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ret)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLabelSize es el tamaño máximo de una etiqueta de envío
+const maxLabelSize = 5 << 20
+
+// ShipmentHandler maneja las peticiones HTTP de los envíos de los pedidos (admin)
+type ShipmentHandler struct {
+	service *services.ShipmentService
+}
+
+// NewShipmentHandler crea una nueva instancia del handler
+func NewShipmentHandler(service *services.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{service: service}
+}
+
+// GetOrderShipments maneja GET /api/orders/:id/shipments
+func (h *ShipmentHandler) GetOrderShipments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	shipments, err := h.service.GetOrderShipments(uint(id))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shipments})
+}
+
+// CreateShipment maneja POST /api/orders/:id/shipments (despacho del pedido)
+func (h *ShipmentHandler) CreateShipment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	req, ok := bindShipmentRequest(c)
+	if !ok {
+		return
+	}
+
+	shipment, err := h.service.CreateShipment(uint(id), req, actorFromContext(c))
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// UpdateShipment maneja PUT /api/orders/:id/shipments/:shipmentId
+func (h *ShipmentHandler) UpdateShipment(c *gin.Context) {
+	orderID, shipmentID, ok := shipmentIDs(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindShipmentRequest(c)
+	if !ok {
+		return
+	}
+
+	shipment, err := h.service.UpdateShipment(orderID, shipmentID, req)
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// UploadLabel maneja POST /api/orders/:id/shipments/:shipmentId/label (form-data "file": PDF, PNG o JPG)
+func (h *ShipmentHandler) UploadLabel(c *gin.Context) {
+	orderID, shipmentID, ok := shipmentIDs(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se ha enviado ningún archivo"})
+		return
+	}
+	ext := filepath.Ext(file.Filename)
+	if !services.IsLabelExtension(ext) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de archivo no permitido. Use PDF, PNG o JPG"})
+		return
+	}
+	if file.Size > maxLabelSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La etiqueta no puede superar los 5 MB"})
+		return
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer content.Close()
+
+	shipment, err := h.service.SaveLabel(orderID, shipmentID, ext, content)
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// GetLabel maneja GET /api/orders/:id/shipments/:shipmentId/label
+func (h *ShipmentHandler) GetLabel(c *gin.Context) {
+	orderID, shipmentID, ok := shipmentIDs(c)
+	if !ok {
+		return
+	}
+
+	path, err := h.service.LabelPath(orderID, shipmentID)
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+
+	// inline: el navegador la muestra y desde ahí se imprime
+	c.Header("Content-Disposition", `inline; filename="`+filepath.Base(path)+`"`)
+	c.File(path)
+}
+
+// shipmentIDs lee los IDs del pedido y del envío de la ruta
+func shipmentIDs(c *gin.Context) (uint, uint, bool) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, 0, false
+	}
+	shipmentID, err := strconv.Atoi(c.Param("shipmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de envío inválido"})
+		return 0, 0, false
+	}
+	return uint(orderID), uint(shipmentID), true
+}
+
+// bindShipmentRequest lee y valida los datos del envío
+func bindShipmentRequest(c *gin.Context) (*models.ShipmentRequest, bool) {
+	var req models.ShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return nil, false
+	}
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &req, true
+}
+
+func respondShipmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrShipmentNotFound), errors.Is(err, services.ErrLabelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOrderNotShippable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidLabelType), errors.Is(err, services.ErrInvalidDelivery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		respondOrderError(c, err)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShippingHandler expone los costos de envío para la tienda
+type ShippingHandler struct {
+	pricer *services.OrderPricer
+}
+
+// NewShippingHandler crea una nueva instancia del handler
+func NewShippingHandler(pricer *services.OrderPricer) *ShippingHandler {
+	return &ShippingHandler{pricer: pricer}
+}
+
+// QuoteShipping maneja POST /api/shipping/quote
+// Devuelve el costo de cada método de envío habilitado que llega al destino.
+func (h *ShippingHandler) QuoteShipping(c *gin.Context) {
+	var req struct {
+		PostalCode string  `json:"postal_code"`
+		Province   string  `json:"province"`
+		Amount     float64 `json:"amount"` // Importe de la compra, para el envío gratis
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	req.PostalCode = strings.TrimSpace(req.PostalCode)
+	req.Province = strings.TrimSpace(req.Province)
+	if req.PostalCode == "" && req.Province == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Código postal o provincia requeridos"})
+		return
+	}
+	if req.PostalCode != "" && models.ParsePostalCode(req.PostalCode) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Código postal inválido"})
+		return
+	}
+	if req.Amount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Importe inválido"})
+		return
+	}
+
+	rules, err := h.pricer.Rules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener métodos de envío"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":                    rules.ShippingQuotes(req.PostalCode, req.Province, req.Amount),
+		"free_shipping_threshold": rules.FreeShippingOver,
+	})
+}
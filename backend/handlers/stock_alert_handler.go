@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StockAlertHandler maneja las peticiones HTTP del stock bajo y sus alertas (admin)
+type StockAlertHandler struct {
+	service *services.StockAlertService
+}
+
+// NewStockAlertHandler crea una nueva instancia del handler
+func NewStockAlertHandler(service *services.StockAlertService) *StockAlertHandler {
+	return &StockAlertHandler{service: service}
+}
+
+// GetLowStock maneja GET /api/inventory/low-stock
+func (h *StockAlertHandler) GetLowStock(c *gin.Context) {
+	items, threshold, err := h.service.GetLowStock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error al obtener productos con stock bajo",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      items,
+		"total":     len(items),
+		"threshold": threshold,
+	})
+}
+
+// GetAlerts maneja GET /api/inventory/alerts?unread=true&limit=50
+func (h *StockAlertHandler) GetAlerts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	alerts, err := h.service.GetAlerts(c.Query("unread") == "true", limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error al obtener alertas de stock",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": alerts})
+}
+
+// MarkAlertRead maneja PATCH /api/inventory/alerts/:id/read
+func (h *StockAlertHandler) MarkAlertRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.service.MarkRead(uint(id)); err != nil {
+		if err.Error() == "alerta no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alerta no encontrada"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alerta marcada como vista"})
+}
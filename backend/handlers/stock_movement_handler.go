@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StockMovementHandler maneja las peticiones HTTP del ledger de inventario
+type StockMovementHandler struct {
+	service *services.StockMovementService
+}
+
+// NewStockMovementHandler crea una nueva instancia del handler
+func NewStockMovementHandler(service *services.StockMovementService) *StockMovementHandler {
+	return &StockMovementHandler{
+		service: service,
+	}
+}
+
+// GetProductMovements maneja GET /api/products/:id/stock-movements
+func (h *StockMovementHandler) GetProductMovements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	movements, total, err := h.service.GetProductMovements(uint(id), page, limit)
+	if err != nil {
+		if err.Error() == "producto no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error al obtener movimientos",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  movements,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetReport maneja GET /api/inventory/movements/report?from=YYYY-MM-DD&to=YYYY-MM-DD
+// (por defecto, los últimos 30 días)
+func (h *StockMovementHandler) GetReport(c *gin.Context) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	from, err := parseDateParam(c.Query("from"), today.AddDate(0, 0, -30))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'from' inválida", "message": "Formato esperado: YYYY-MM-DD"})
+		return
+	}
+	to, err := parseDateParam(c.Query("to"), today)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'to' inválida", "message": "Formato esperado: YYYY-MM-DD"})
+		return
+	}
+
+	report, err := h.service.GetReport(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Error al generar reporte",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+		"from": from.Format("2006-01-02"),
+		"to":   to.Format("2006-01-02"),
+	})
+}
+
+// parseDateParam interpreta una fecha YYYY-MM-DD en hora local, o devuelve el valor por defecto
+func parseDateParam(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
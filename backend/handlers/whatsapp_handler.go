@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WhatsAppHandler maneja los enlaces de WhatsApp con el mensaje ya armado
+type WhatsAppHandler struct {
+	service *services.WhatsAppService
+}
+
+// NewWhatsAppHandler crea una nueva instancia del handler
+func NewWhatsAppHandler(service *services.WhatsAppService) *WhatsAppHandler {
+	return &WhatsAppHandler{service: service}
+}
+
+// GetProductLink maneja GET /api/products/:id/whatsapp-link?talla=42&color=negro (público):
+// la consulta del cliente a la tienda por el producto
+func (h *WhatsAppHandler) GetProductLink(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	link, err := h.service.ProductLink(uint(id), c.Query("talla"), c.Query("color"))
+	if err != nil {
+		switch {
+		case err.Error() == "producto no encontrado":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		case errors.Is(err, services.ErrVariantNotAvailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrWhatsAppNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el enlace de WhatsApp"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// GetOrderLink maneja GET /api/orders/:id/whatsapp-link: el mensaje de la tienda al
+// cliente por su pedido, dirigido al teléfono del pedido
+func (h *WhatsAppHandler) GetOrderLink(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	link, err := h.service.OrderLink(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrOrderWithoutPhone) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
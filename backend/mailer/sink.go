@@ -0,0 +1,151 @@
+package mailer
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// SinkMessage es un email recibido por el Sink
+type SinkMessage struct {
+	From string
+	To   []string
+	Data string // Mensaje completo (encabezados y cuerpo)
+}
+
+// Sink es un servidor SMTP mínimo que acepta cualquier mensaje y lo guarda en memoria, sin
+// entregarlo. Sirve para desarrollo (go run ./cmd/smtpsink) y para tests.
+type Sink struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []SinkMessage
+	received chan struct{}
+}
+
+// NewSink levanta el servidor en addr (ej. "127.0.0.1:0" para un puerto libre)
+func NewSink(addr string) (*Sink, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error al iniciar el servidor SMTP de prueba: %w", err)
+	}
+	s := &Sink{listener: listener, received: make(chan struct{}, 100)}
+	go s.serve()
+	return s, nil
+}
+
+// Addr devuelve host y puerto en los que escucha el servidor
+func (s *Sink) Addr() (string, int) {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+// Messages devuelve los mensajes recibidos hasta el momento
+func (s *Sink) Messages() []SinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SinkMessage{}, s.messages...)
+}
+
+// Received avisa cada vez que llega un mensaje (para esperar envíos asincrónicos en tests)
+func (s *Sink) Received() <-chan struct{} {
+	return s.received
+}
+
+// Close detiene el servidor
+func (s *Sink) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Sink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle atiende una sesión SMTP: HELO/EHLO, MAIL, RCPT, DATA, RSET, NOOP y QUIT
+func (s *Sink) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+
+	reply("220 smtpsink listo")
+	var current SinkMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 smtpsink")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			current = SinkMessage{From: trimAddress(line[len("MAIL FROM:"):])}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			current.To = append(current.To, trimAddress(line[len("RCPT TO:"):]))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 Terminar con <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" || dataLine == ".\n" {
+					break
+				}
+				// Quitar el punto agregado por el cliente a las líneas que empiezan con "."
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			current.Data = data.String()
+			s.store(current)
+			current = SinkMessage{}
+			reply("250 OK")
+		case command == "RSET":
+			current = SinkMessage{}
+			reply("250 OK")
+		case command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Chau")
+			return
+		default:
+			reply("502 Comando no soportado")
+		}
+	}
+}
+
+func (s *Sink) store(msg SinkMessage) {
+	s.mu.Lock()
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+
+	select {
+	case s.received <- struct{}{}:
+	default:
+	}
+}
+
+// trimAddress quita los <> y los parámetros de un argumento de MAIL FROM o RCPT TO
+func trimAddress(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if end := strings.Index(arg, ">"); strings.HasPrefix(arg, "<") && end > 0 {
+		return arg[1:end]
+	}
+	if fields := strings.Fields(arg); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
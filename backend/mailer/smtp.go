@@ -0,0 +1,127 @@
+// Package mailer envía emails por SMTP e incluye un servidor SMTP de prueba (Sink) que
+// guarda los mensajes en memoria, para desarrollo y tests.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// SMTPSender envía emails a través de un servidor SMTP. Usa STARTTLS si el servidor lo
+// ofrece y se autentica solo si hay usuario configurado.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string // Dirección del remitente
+}
+
+// NewSMTPSender crea el cliente para el servidor host:port
+func NewSMTPSender(host string, port int, username, password, from string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPSender{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+// Send implementa services.EmailSender
+func (s *SMTPSender) Send(msg models.EmailMessage) error {
+	recipients := msg.Recipients()
+	if len(recipients) == 0 {
+		return fmt.Errorf("el email no tiene destinatarios")
+	}
+
+	data, err := BuildMessage(s.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(s.addr, s.auth, s.from, recipients, data); err != nil {
+		return fmt.Errorf("error al enviar email: %w", err)
+	}
+	return nil
+}
+
+// BuildMessage arma el mensaje MIME (multipart/alternative con texto y HTML, en UTF-8).
+// Las copias ocultas no se incluyen en los encabezados.
+func BuildMessage(from string, msg models.EmailMessage) ([]byte, error) {
+	boundary, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	messageID, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", (&mail.Address{Name: msg.FromName, Address: from}).String())
+	if len(msg.To) > 0 {
+		to := make([]string, len(msg.To))
+		for i, address := range msg.To {
+			to[i] = (&mail.Address{Address: address}).String()
+		}
+		header("To", strings.Join(to, ", "))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+messageID+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		header("Content-Type", part.contentType+"; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		w := quotedprintable.NewWriter(&buf)
+		if _, err := w.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
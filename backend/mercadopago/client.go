@@ -0,0 +1,219 @@
+// Package mercadopago implementa la pasarela de pagos de Mercado Pago (Checkout Pro):
+// creación de preferencias, consulta de pagos y validación de notificaciones del webhook.
+package mercadopago
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// Errores del cliente de Mercado Pago
+var (
+	ErrInvalidSignature = errors.New("firma de notificación inválida")
+	ErrInvalidPaymentID = models.ErrInvalidPaymentID
+)
+
+// Client es el cliente HTTP de la API de Mercado Pago
+type Client struct {
+	accessToken   string
+	webhookSecret string
+	baseURL       string
+	httpClient    *http.Client
+}
+
+// NewClient crea un cliente. baseURL permite usar el servidor falso (ver mercadopagotest.NewServer).
+func NewClient(accessToken, webhookSecret, baseURL string) *Client {
+	return &Client{
+		accessToken:   accessToken,
+		webhookSecret: webhookSecret,
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// preferenceRequest es el cuerpo de POST /checkout/preferences
+type preferenceRequest struct {
+	Items             []preferenceItem `json:"items"`
+	Payer             *preferencePayer `json:"payer,omitempty"`
+	ExternalReference string           `json:"external_reference"`
+	NotificationURL   string           `json:"notification_url,omitempty"`
+	BackURLs          *backURLs        `json:"back_urls,omitempty"`
+	AutoReturn        string           `json:"auto_return,omitempty"`
+}
+
+type preferenceItem struct {
+	Title      string  `json:"title"`
+	Quantity   int     `json:"quantity"`
+	UnitPrice  float64 `json:"unit_price"`
+	CurrencyID string  `json:"currency_id"`
+}
+
+type preferencePayer struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type backURLs struct {
+	Success string `json:"success,omitempty"`
+	Failure string `json:"failure,omitempty"`
+	Pending string `json:"pending,omitempty"`
+}
+
+// paymentResponse es la respuesta de GET /v1/payments/{id}
+type paymentResponse struct {
+	ID                int64   `json:"id"`
+	Status            string  `json:"status"`
+	StatusDetail      string  `json:"status_detail"`
+	ExternalReference string  `json:"external_reference"`
+	TransactionAmount float64 `json:"transaction_amount"`
+	PaymentMethodID   string  `json:"payment_method_id"`
+	PaymentTypeID     string  `json:"payment_type_id"`
+	Installments      int     `json:"installments"`
+}
+
+// CreatePreference crea una preferencia de pago por el total del pedido
+func (c *Client) CreatePreference(req models.PaymentPreferenceRequest) (*models.PaymentPreference, error) {
+	body := preferenceRequest{
+		Items: []preferenceItem{{
+			Title:      req.Title,
+			Quantity:   1,
+			UnitPrice:  req.Amount,
+			CurrencyID: "ARS",
+		}},
+		ExternalReference: req.ExternalReference,
+		NotificationURL:   req.NotificationURL,
+	}
+	if req.PayerName != "" || req.PayerEmail != "" {
+		body.Payer = &preferencePayer{Name: req.PayerName, Email: req.PayerEmail}
+	}
+	if req.SuccessURL != "" {
+		body.BackURLs = &backURLs{Success: req.SuccessURL, Failure: req.FailureURL, Pending: req.PendingURL}
+		body.AutoReturn = "approved"
+	}
+
+	var preference models.PaymentPreference
+	if err := c.do(http.MethodPost, "/checkout/preferences", body, &preference); err != nil {
+		return nil, fmt.Errorf("error al crear preferencia de pago: %w", err)
+	}
+	return &preference, nil
+}
+
+// GetPayment consulta un pago por su ID. El ID llega del webhook, así que solo se
+// aceptan IDs numéricos (como los de Mercado Pago) antes de armar la URL.
+func (c *Client) GetPayment(id string) (*models.GatewayPayment, error) {
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPaymentID, id)
+	}
+
+	var payment paymentResponse
+	if err := c.do(http.MethodGet, "/v1/payments/"+id, nil, &payment); err != nil {
+		return nil, fmt.Errorf("error al consultar pago %s: %w", id, err)
+	}
+
+	return &models.GatewayPayment{
+		ID:                strconv.FormatInt(payment.ID, 10),
+		Status:            payment.Status,
+		StatusDetail:      payment.StatusDetail,
+		ExternalReference: payment.ExternalReference,
+		Amount:            payment.TransactionAmount,
+		PaymentMethodID:   payment.PaymentMethodID,
+		PaymentTypeID:     payment.PaymentTypeID,
+		Installments:      payment.Installments,
+	}, nil
+}
+
+// VerifyNotification valida el header x-signature de una notificación.
+// Sin clave configurada no se valida la firma: el pago igual se consulta a la API.
+func (c *Client) VerifyNotification(n models.PaymentNotification) error {
+	if c.webhookSecret == "" {
+		return nil
+	}
+
+	ts, v1 := parseSignature(n.Signature)
+	if ts == "" || v1 == "" {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(c.webhookSecret, n.DataID, n.RequestID, ts)
+	if !hmac.Equal([]byte(expected), []byte(v1)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sign calcula la firma v1 de una notificación: HMAC-SHA256 del manifiesto
+// "id:{data.id};request-id:{x-request-id};ts:{ts};"
+func Sign(secret, dataID, requestID, ts string) string {
+	manifest := "id:" + strings.ToLower(dataID) + ";"
+	if requestID != "" {
+		manifest += "request-id:" + requestID + ";"
+	}
+	manifest += "ts:" + ts + ";"
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(manifest))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseSignature separa ts y v1 del header "ts=...,v1=..."
+func parseSignature(header string) (ts, v1 string) {
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ts":
+			ts = value
+		case "v1":
+			v1 = value
+		}
+	}
+	return ts, v1
+}
+
+// do ejecuta una petición autenticada contra la API y decodifica la respuesta
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("respuesta %d de Mercado Pago: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,144 @@
+// Package mercadopagotest provee un servidor falso de la API de Mercado Pago para
+// desarrollo y tests (ver mercadopago.NewClient).
+package mercadopagotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// preferenceRequest es el cuerpo de POST /checkout/preferences (solo los campos que se verifican)
+type preferenceRequest struct {
+	Items []struct {
+		Title     string  `json:"title"`
+		UnitPrice float64 `json:"unit_price"`
+	} `json:"items"`
+	ExternalReference string `json:"external_reference"`
+}
+
+// paymentResponse es la respuesta de GET /v1/payments/{id}
+type paymentResponse struct {
+	ID                int64   `json:"id"`
+	Status            string  `json:"status"`
+	ExternalReference string  `json:"external_reference"`
+	TransactionAmount float64 `json:"transaction_amount"`
+	PaymentMethodID   string  `json:"payment_method_id"`
+	PaymentTypeID     string  `json:"payment_type_id"`
+	Installments      int     `json:"installments"`
+}
+
+// Server simula la API de Mercado Pago en memoria: crea preferencias y permite
+// registrar pagos sin tocar la API real.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	nextID      int64
+	preferences map[string]preferenceRequest
+	payments    map[int64]paymentResponse
+}
+
+// NewServer levanta el servidor falso; usar URL como baseURL de mercadopago.Client
+func NewServer() *Server {
+	f := &Server{
+		nextID:      1000,
+		preferences: map[string]preferenceRequest{},
+		payments:    map[int64]paymentResponse{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/checkout/preferences", f.handleCreatePreference)
+	mux.HandleFunc("/v1/payments/", f.handleGetPayment)
+	f.Server = httptest.NewServer(f.requireToken(mux))
+	return f
+}
+
+// AddPayment registra un pago para la referencia externa y devuelve su ID
+func (f *Server) AddPayment(externalReference, status string, amount float64) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	f.payments[f.nextID] = paymentResponse{
+		ID:                f.nextID,
+		Status:            status,
+		ExternalReference: externalReference,
+		TransactionAmount: amount,
+		PaymentMethodID:   "visa",
+		PaymentTypeID:     "credit_card",
+		Installments:      1,
+	}
+	return strconv.FormatInt(f.nextID, 10)
+}
+
+// Preference devuelve la preferencia creada (para verificar lo enviado en tests)
+func (f *Server) Preference(id string) (title string, amount float64, externalReference string, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pref, ok := f.preferences[id]
+	if !ok || len(pref.Items) == 0 {
+		return "", 0, "", false
+	}
+	return pref.Items[0].Title, pref.Items[0].UnitPrice, pref.ExternalReference, true
+}
+
+func (f *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			http.Error(w, `{"message":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *Server) handleCreatePreference(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"message":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req preferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Items) == 0 {
+		http.Error(w, `{"message":"invalid preference"}`, http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.nextID++
+	id := fmt.Sprintf("pref-%d", f.nextID)
+	f.preferences[id] = req
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"id":         id,
+		"init_point": f.URL + "/checkout/v1/redirect?pref_id=" + id,
+	})
+}
+
+func (f *Server) handleGetPayment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/v1/payments/"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"message":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	payment, ok := f.payments[id]
+	f.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"message":"payment not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
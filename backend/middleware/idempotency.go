@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"tiendaedgar/backend/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader es el header con el que el cliente identifica un intento de creación
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyStaleAfter es el tiempo tras el cual una petición sin terminar (ej. el
+// servidor se reinició a mitad) deja de bloquear su clave
+const idempotencyStaleAfter = 2 * time.Minute
+
+// maxIdempotencyKeyLength limita el largo de la clave (suele ser un UUID)
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMaxBodyBytes limita el cuerpo que se lee para calcular el hash de la petición
+const IdempotencyMaxBodyBytes = 64 << 10
+
+// idempotencyWriter copia la respuesta del handler para poder guardarla
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency evita que un reintento (doble toque, reconexión del celular) cree dos veces
+// lo mismo. Si la petición trae el header Idempotency-Key, la primera respuesta exitosa se
+// guarda durante `window` y los reintentos con la misma clave y el mismo cuerpo la reciben
+// sin volver a ejecutar el handler (con el header Idempotent-Replayed: true).
+//   - Misma clave con otro cuerpo: 422.
+//   - Misma clave mientras la primera petición sigue en curso: 409.
+//   - Si la primera petición falla (4xx/5xx) no se guarda y puede reintentarse con la misma clave.
+//   - Cuerpo de más de IdempotencyMaxBodyBytes: 413.
+//
+// Sin el header la petición se procesa normalmente.
+func Idempotency(repo *repositories.IdempotencyRepository, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key demasiado larga"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, IdempotencyMaxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "La solicitud es demasiado grande"})
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer la solicitud"})
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+		scope := c.Request.Method + " " + c.FullPath()
+
+		now := time.Now()
+		record, reserved, err := repo.Reserve(key, scope, requestHash, now.Add(-window), now.Add(-idempotencyStaleAfter))
+		if err != nil {
+			log.Printf("Error en Idempotency-Key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la solicitud"})
+			c.Abort()
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "La Idempotency-Key ya se usó con otra solicitud"})
+			case !record.Completed():
+				c.Header("Retry-After", "1")
+				c.JSON(http.StatusConflict, gin.H{"error": "La solicitud original todavía se está procesando"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.StatusCode, record.ContentType, record.ResponseBody)
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= 200 && status < 300 {
+			err = repo.Complete(record.ID, status, writer.Header().Get("Content-Type"), writer.body.Bytes())
+		} else {
+			err = repo.Release(record.ID)
+		}
+		if err != nil {
+			log.Printf("Error en Idempotency-Key %q: %v", key, err)
+		}
+	}
+}
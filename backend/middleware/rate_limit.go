@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow cuenta las peticiones de un cliente dentro de la ventana actual
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit limita a `limit` peticiones por IP cada `window` (ventana fija, en memoria).
+// Pensado para endpoints públicos como el checkout; con varias instancias el límite es por instancia.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	clients := map[string]*rateWindow{}
+	lastCleanup := time.Now()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Limpiar ventanas vencidas para que el mapa no crezca indefinidamente
+		if now.Sub(lastCleanup) > window {
+			for key, w := range clients {
+				if now.Sub(w.start) >= window {
+					delete(clients, key)
+				}
+			}
+			lastCleanup = now
+		}
+
+		w, ok := clients[ip]
+		if !ok || now.Sub(w.start) >= window {
+			w = &rateWindow{start: now}
+			clients[ip] = w
+		}
+		w.count++
+		count := w.count
+		retryAfter := w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if count > limit {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Demasiadas solicitudes, intente nuevamente en unos minutos",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
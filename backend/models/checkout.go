@@ -0,0 +1,190 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Límites del checkout público
+const (
+	CheckoutMaxItems       = 10 // Líneas distintas por pedido
+	CheckoutMaxQuantity    = 5  // Unidades por línea
+	checkoutMaxFieldLength = 200
+	checkoutMaxNotesLength = 1000
+)
+
+var (
+	checkoutEmailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	checkoutPhoneRegex = regexp.MustCompile(`^\+?[0-9 ()-]{8,20}$`)
+)
+
+// CheckoutRequest es el carrito que envía un cliente desde la tienda.
+// Solo trae qué quiere comprar: precios, nombres y totales se calculan en el servidor.
+type CheckoutRequest struct {
+	CustomerName    string             `json:"customer_name"`
+	CustomerEmail   string             `json:"customer_email"`
+	CustomerPhone   string             `json:"customer_phone"`
+	CustomerAddress string             `json:"customer_address"`
+	ShippingMethod  ShippingMethodCode `json:"shipping_method"`
+	PostalCode      string             `json:"postal_code"`
+	Province        string             `json:"province"`
+	PaymentMethod   PaymentMethod      `json:"payment_method"`
+	Installments    int                `json:"installments"`
+	CouponCode      string             `json:"coupon_code"`
+	Notes           string             `json:"notes"`
+	Items           []CheckoutItem     `json:"items"`
+}
+
+// CheckoutItem es una línea del carrito
+type CheckoutItem struct {
+	ProductID uint   `json:"product_id"`
+	VariantID *uint  `json:"variant_id"`
+	Talla     string `json:"talla"`
+	Color     string `json:"color"`
+	Quantity  int    `json:"quantity"`
+}
+
+// Normalize recorta espacios de los campos de texto
+func (r *CheckoutRequest) Normalize() {
+	r.CustomerName = strings.TrimSpace(r.CustomerName)
+	r.CustomerEmail = strings.ToLower(strings.TrimSpace(r.CustomerEmail))
+	r.CustomerPhone = strings.TrimSpace(r.CustomerPhone)
+	r.CustomerAddress = strings.TrimSpace(r.CustomerAddress)
+	r.PostalCode = strings.ToUpper(strings.TrimSpace(r.PostalCode))
+	r.Province = strings.TrimSpace(r.Province)
+	r.Notes = strings.TrimSpace(r.Notes)
+	r.CouponCode = NormalizeCouponCode(r.CouponCode)
+	for i := range r.Items {
+		r.Items[i].Talla = strings.TrimSpace(r.Items[i].Talla)
+		r.Items[i].Color = strings.TrimSpace(r.Items[i].Color)
+	}
+}
+
+// Validate valida los datos del cliente y el carrito
+func (r *CheckoutRequest) Validate() error {
+	if r.CustomerName == "" {
+		return errors.New("el nombre es requerido")
+	}
+	if utf8.RuneCountInString(r.CustomerName) > checkoutMaxFieldLength {
+		return errors.New("el nombre es demasiado largo")
+	}
+
+	if r.CustomerPhone == "" {
+		return errors.New("el teléfono es requerido")
+	}
+	if !checkoutPhoneRegex.MatchString(r.CustomerPhone) {
+		return errors.New("formato de teléfono inválido")
+	}
+
+	if r.CustomerEmail != "" && !checkoutEmailRegex.MatchString(r.CustomerEmail) {
+		return errors.New("formato de email inválido")
+	}
+	if utf8.RuneCountInString(r.CustomerEmail) > checkoutMaxFieldLength ||
+		utf8.RuneCountInString(r.CustomerAddress) > checkoutMaxFieldLength {
+		return errors.New("el email o la dirección son demasiado largos")
+	}
+	if utf8.RuneCountInString(r.Notes) > checkoutMaxNotesLength {
+		return errors.New("las notas son demasiado largas")
+	}
+
+	if utf8.RuneCountInString(r.PostalCode) > 10 || utf8.RuneCountInString(r.Province) > 60 {
+		return errors.New("código postal o provincia inválidos")
+	}
+
+	if !r.PaymentMethod.IsValid() {
+		return errors.New("medio de pago inválido")
+	}
+	if r.Installments < 0 || r.Installments > 24 {
+		return errors.New("cantidad de cuotas inválida")
+	}
+
+	if len(r.Items) == 0 {
+		return errors.New("el carrito está vacío")
+	}
+	if len(r.Items) > CheckoutMaxItems {
+		return fmt.Errorf("el carrito no puede tener más de %d productos", CheckoutMaxItems)
+	}
+
+	for i, item := range r.Items {
+		if item.ProductID == 0 {
+			return fmt.Errorf("item %d: producto requerido", i+1)
+		}
+		if item.Quantity < 1 || item.Quantity > CheckoutMaxQuantity {
+			return fmt.Errorf("item %d: la cantidad debe estar entre 1 y %d", i+1, CheckoutMaxQuantity)
+		}
+		if utf8.RuneCountInString(item.Talla) > 20 || utf8.RuneCountInString(item.Color) > 50 {
+			return fmt.Errorf("item %d: talla o color inválidos", i+1)
+		}
+	}
+
+	return nil
+}
+
+// CheckoutResponse es lo que se devuelve al cliente: el número y la referencia pública del
+// pedido y el detalle calculado por el servidor (sin IDs internos)
+type CheckoutResponse struct {
+	OrderNumber    string              `json:"order_number"`   // Número para comunicarse con la tienda (ej. CS-2026-000123)
+	Reference      string              `json:"reference"`      // Referencia no adivinable para consultar y pagar el pedido
+	TrackingToken  string              `json:"tracking_token"` // Token de la página de seguimiento (GET /api/public/orders/:token)
+	Status         OrderStatus         `json:"status"`
+	PaymentMethod  PaymentMethod       `json:"payment_method"`
+	Installments   int                 `json:"installments"`
+	Subtotal       float64             `json:"subtotal"`
+	CouponCode     string              `json:"coupon_code"`
+	Discount       float64             `json:"discount"`
+	Surcharge      float64             `json:"surcharge"`
+	ShippingMethod ShippingMethodCode  `json:"shipping_method"`
+	ShippingCost   float64             `json:"shipping_cost"`
+	TotalAmount    float64             `json:"total_amount"`
+	Items          []CheckoutItemQuote `json:"items"`
+	CreatedAt      time.Time           `json:"created_at"`
+}
+
+// CheckoutItemQuote es una línea del pedido tal como quedó registrada
+type CheckoutItemQuote struct {
+	ProductID   uint    `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Talla       string  `json:"talla"`
+	Color       string  `json:"color"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Subtotal    float64 `json:"subtotal"`
+}
+
+// NewCheckoutResponse arma la respuesta pública a partir del pedido creado
+func NewCheckoutResponse(order *Order) CheckoutResponse {
+	items := make([]CheckoutItemQuote, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = CheckoutItemQuote{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Talla:       item.Talla,
+			Color:       item.Color,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Subtotal:    item.Subtotal,
+		}
+	}
+
+	return CheckoutResponse{
+		OrderNumber:    order.OrderNumber,
+		Reference:      order.Reference,
+		TrackingToken:  order.TrackingToken,
+		Status:         order.Status,
+		PaymentMethod:  order.PaymentMethod,
+		Installments:   order.Installments,
+		Subtotal:       order.Subtotal,
+		CouponCode:     order.CouponCode,
+		Discount:       order.Discount,
+		Surcharge:      order.Surcharge,
+		ShippingMethod: order.ShippingMethod,
+		ShippingCost:   order.ShippingCost,
+		TotalAmount:    order.TotalAmount,
+		Items:          items,
+		CreatedAt:      order.CreatedAt,
+	}
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CouponType define cómo se calcula el descuento de un cupón
+type CouponType string
+
+const (
+	CouponTypePercentage CouponType = "percentage" // Porcentaje sobre los productos alcanzados
+	CouponTypeFixed      CouponType = "fixed"      // Monto fijo (nunca mayor a los productos alcanzados)
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,30}$`)
+
+// Coupon representa un código de descuento (Hot Sale, Cyber Monday, etc.)
+type Coupon struct {
+	ID          uint       `json:"id"`
+	Code        string     `json:"code"` // Se guarda en mayúsculas; único
+	Description string     `json:"description"`
+	Type        CouponType `json:"type"`
+	Value       float64    `json:"value"`        // Porcentaje (1-100) o monto fijo
+	MinPurchase float64    `json:"min_purchase"` // Subtotal mínimo del pedido (0 = sin mínimo)
+	StartsAt    *time.Time `json:"starts_at"`    // Vigencia; nil = sin límite
+	EndsAt      *time.Time `json:"ends_at"`
+	UsageLimit  int        `json:"usage_limit"` // Usos totales permitidos (0 = ilimitado)
+	UsageCount  int        `json:"usage_count"` // Pedidos que lo usan (los cancelados lo liberan)
+	Categories  []string   `json:"categories"`  // Restricción por categoría (vacío = todas)
+	ProductIDs  []uint     `json:"product_ids"` // Restricción por producto (vacío = todos)
+	Active      bool       `json:"active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NormalizeCouponCode normaliza un código ingresado por el cliente
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Normalize limpia código, descripción y restricciones
+func (c *Coupon) Normalize() {
+	c.Code = NormalizeCouponCode(c.Code)
+	c.Description = strings.TrimSpace(c.Description)
+	c.Type = CouponType(strings.ToLower(strings.TrimSpace(string(c.Type))))
+
+	categories := make([]string, 0, len(c.Categories))
+	for _, category := range c.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	c.Categories = categories
+	if c.ProductIDs == nil {
+		c.ProductIDs = []uint{}
+	}
+}
+
+// Validate valida la definición del cupón
+func (c *Coupon) Validate() error {
+	if !couponCodePattern.MatchString(c.Code) {
+		return fmt.Errorf("el código debe tener entre 3 y 30 letras, números, guiones o guiones bajos")
+	}
+
+	switch c.Type {
+	case CouponTypePercentage:
+		if c.Value <= 0 || c.Value > 100 {
+			return fmt.Errorf("el porcentaje de descuento debe estar entre 0 y 100")
+		}
+	case CouponTypeFixed:
+		if c.Value <= 0 {
+			return fmt.Errorf("el monto de descuento debe ser mayor a 0")
+		}
+	default:
+		return fmt.Errorf("tipo de cupón inválido: %s (usar percentage o fixed)", c.Type)
+	}
+
+	if c.MinPurchase < 0 {
+		return fmt.Errorf("la compra mínima no puede ser negativa")
+	}
+	if c.UsageLimit < 0 {
+		return fmt.Errorf("el límite de usos no puede ser negativo")
+	}
+	if c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt) {
+		return fmt.Errorf("la fecha de fin debe ser posterior a la de inicio")
+	}
+	if len(c.Description) > 200 {
+		return fmt.Errorf("la descripción no puede superar los 200 caracteres")
+	}
+
+	return nil
+}
+
+// CheckAvailable verifica que el cupón pueda usarse en la fecha indicada
+func (c *Coupon) CheckAvailable(now time.Time) error {
+	if !c.Active {
+		return fmt.Errorf("el cupón %s no está activo", c.Code)
+	}
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return fmt.Errorf("el cupón %s todavía no está vigente", c.Code)
+	}
+	if c.EndsAt != nil && !now.Before(*c.EndsAt) {
+		return fmt.Errorf("el cupón %s está vencido", c.Code)
+	}
+	if c.UsageLimit > 0 && c.UsageCount >= c.UsageLimit {
+		return fmt.Errorf("el cupón %s alcanzó su límite de usos", c.Code)
+	}
+	return nil
+}
+
+// AppliesTo indica si el descuento alcanza al producto. Con restricciones, alcanza a
+// los productos indicados y a los de las categorías indicadas.
+func (c *Coupon) AppliesTo(product *Product) bool {
+	if len(c.ProductIDs) == 0 && len(c.Categories) == 0 {
+		return true
+	}
+
+	for _, id := range c.ProductIDs {
+		if id == product.ID {
+			return true
+		}
+	}
+	for _, category := range c.Categories {
+		if strings.EqualFold(category, product.Categoria) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountFor calcula el descuento sobre el subtotal de los productos alcanzados
+func (c *Coupon) DiscountFor(eligibleSubtotal float64) float64 {
+	if eligibleSubtotal <= 0 {
+		return 0
+	}
+
+	discount := c.Value
+	if c.Type == CouponTypePercentage {
+		discount = eligibleSubtotal * c.Value / 100
+	}
+	return math.Round(math.Min(discount, eligibleSubtotal)*100) / 100
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var customerEmailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// Customer representa un cliente de la tienda. Los pedidos se asocian automáticamente
+// por teléfono o email normalizados (ver NormalizePhone y NormalizeEmail).
+type Customer struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"` // Tal como lo ingresó el cliente
+	Address   string    `json:"address"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	*CustomerStats         // orders_count, lifetime_value y last_purchase_at
+	Orders         []Order `json:"orders,omitempty"` // Historial de compras (solo en el detalle)
+}
+
+// CustomerStats resume las compras de un cliente (sin contar pedidos cancelados)
+type CustomerStats struct {
+	OrdersCount    int        `json:"orders_count"`
+	LifetimeValue  float64    `json:"lifetime_value"` // Suma de los totales menos devoluciones
+	LastPurchaseAt *time.Time `json:"last_purchase_at"`
+}
+
+// Normalize limpia los campos de texto del cliente
+func (c *Customer) Normalize() {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Email = NormalizeEmail(c.Email)
+	c.Phone = strings.TrimSpace(c.Phone)
+	c.Address = strings.TrimSpace(c.Address)
+	c.Notes = strings.TrimSpace(c.Notes)
+}
+
+// Validate valida los datos del cliente: debe tener nombre y al menos un teléfono o email
+func (c *Customer) Validate() error {
+	if c.Name == "" {
+		return errors.New("el nombre es requerido")
+	}
+	if c.Email == "" && NormalizePhone(c.Phone) == "" {
+		return errors.New("el cliente debe tener teléfono o email")
+	}
+	if c.Email != "" && !customerEmailRegex.MatchString(c.Email) {
+		return errors.New("formato de email inválido")
+	}
+	if utf8.RuneCountInString(c.Name) > 200 || utf8.RuneCountInString(c.Address) > 200 || utf8.RuneCountInString(c.Email) > 200 {
+		return errors.New("nombre, email o dirección demasiado largos")
+	}
+	if utf8.RuneCountInString(c.Notes) > 1000 {
+		return errors.New("las notas son demasiado largas")
+	}
+	return nil
+}
+
+// NormalizeEmail pasa el email a minúsculas y sin espacios
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone reduce un teléfono argentino a sus 10 dígitos nacionales (característica
+// + número) para poder comparar "+54 9 11 5555-1234", "011 15 5555-1234" y "11 5555 1234".
+// Devuelve vacío si no hay dígitos suficientes para identificar a un cliente.
+func NormalizePhone(phone string) string {
+	digits := make([]byte, 0, len(phone))
+	for i := 0; i < len(phone); i++ {
+		if phone[i] >= '0' && phone[i] <= '9' {
+			digits = append(digits, phone[i])
+		}
+	}
+	n := string(digits)
+
+	// Código de país y el 9 de los celulares en formato internacional
+	if strings.HasPrefix(n, "54") && len(n) >= 12 {
+		n = strings.TrimPrefix(n, "54")
+		if strings.HasPrefix(n, "9") && len(n) == 11 {
+			n = n[1:]
+		}
+	}
+	// Prefijo de larga distancia nacional
+	n = strings.TrimPrefix(n, "0")
+	// El 15 de los celulares va después de la característica (2 a 4 dígitos)
+	if len(n) == 12 {
+		for _, areaLen := range []int{2, 3, 4} {
+			if n[areaLen:areaLen+2] == "15" {
+				n = n[:areaLen] + n[areaLen+2:]
+				break
+			}
+		}
+	}
+
+	if len(n) < 8 {
+		return ""
+	}
+	return n
+}
+
+// MergeContact actualiza el cliente con los datos de un pedido nuevo: nombre y dirección
+// toman el último valor informado; email y teléfono solo se completan si faltaban.
+// Se usa para pedidos cargados por un admin (ver FillContact).
+func (c *Customer) MergeContact(name, email, phone, address string) {
+	if name = strings.TrimSpace(name); name != "" {
+		c.Name = name
+	}
+	if address = strings.TrimSpace(address); address != "" {
+		c.Address = address
+	}
+	if c.Email == "" {
+		c.Email = NormalizeEmail(email)
+	}
+	if NormalizePhone(c.Phone) == "" && NormalizePhone(phone) != "" {
+		c.Phone = strings.TrimSpace(phone)
+	}
+}
+
+// FillContact completa solo los datos que le faltan al cliente. Se usa para pedidos del
+// checkout público: quien conoce un teléfono o email no puede reemplazar el nombre ni la
+// dirección del cliente.
+func (c *Customer) FillContact(name, email, phone, address string) {
+	if c.Name == "" {
+		c.Name = strings.TrimSpace(name)
+	}
+	if c.Address == "" {
+		c.Address = strings.TrimSpace(address)
+	}
+	c.MergeContact("", email, phone, "")
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyRecord guarda la primera respuesta exitosa de una petición enviada con el
+// header Idempotency-Key, para devolverla sin volver a ejecutarla si el cliente reintenta
+type IdempotencyRecord struct {
+	ID           uint
+	Key          string // Valor del header Idempotency-Key
+	Scope        string // Método y ruta (ej. "POST /api/orders"): la misma clave en otra ruta es independiente
+	RequestHash  string // SHA-256 del cuerpo: la misma clave con otro cuerpo es un error del cliente
+	StatusCode   int    // 0 mientras la petición original se está procesando
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+// Completed indica si la petición original ya terminó y su respuesta puede repetirse
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"crypto/rand"
+	"fmt"
+	"math"
 	"time"
 )
 
@@ -16,19 +19,156 @@ const (
 	OrderStatusCancelled  OrderStatus = "Cancelado"
 )
 
+// orderStatusTransitions define el grafo de estados permitido.
+// Un pedido cancelado puede reactivarse a Pendiente (vuelve a reservar stock).
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:       {OrderStatusProcessing, OrderStatusCancelled},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:    {OrderStatusDelivered},
+	OrderStatusDelivered:  {},
+	OrderStatusCancelled:  {OrderStatusPending},
+}
+
+// IsValid indica si el estado es uno de los estados conocidos
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderStatusTransitions[s]
+	return ok
+}
+
+// IsInitial indica si un pedido nuevo puede crearse en este estado. Los demás estados se
+// alcanzan solo por las transiciones del grafo, que quedan en el historial.
+func (s OrderStatus) IsInitial() bool {
+	return s == OrderStatusPending
+}
+
+// CanTransitionTo indica si el pedido puede pasar del estado actual al siguiente
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses devuelve los estados a los que puede pasar el pedido
+func (s OrderStatus) NextStatuses() []OrderStatus {
+	return append([]OrderStatus{}, orderStatusTransitions[s]...)
+}
+
+// ReservesStock indica si un pedido en este estado tiene el stock descontado
+func (s OrderStatus) ReservesStock() bool {
+	return s != OrderStatusCancelled
+}
+
+// AllowsReturns indica si el pedido admite devoluciones y cambios (ya fue despachado)
+func (s OrderStatus) AllowsReturns() bool {
+	return s == OrderStatusShipped || s == OrderStatusDelivered
+}
+
+// AllowsItemChanges indica si todavía pueden modificarse los productos del pedido
+// (antes de despacharlo y mientras tenga el stock reservado)
+func (s OrderStatus) AllowsItemChanges() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusProcessing:
+		return true
+	}
+	return false
+}
+
 // Order representa un pedido en el sistema
 type Order struct {
-	ID              uint        `json:"id" db:"id"`
-	CustomerName    string      `json:"customer_name" db:"customer_name"`
-	CustomerEmail   string      `json:"customer_email" db:"customer_email"`
-	CustomerPhone   string      `json:"customer_phone" db:"customer_phone"`
-	CustomerAddress string      `json:"customer_address" db:"customer_address"`
-	TotalAmount     float64     `json:"total_amount" db:"total_amount"`
-	Status          OrderStatus `json:"status" db:"status"`
-	Notes           string      `json:"notes" db:"notes"`
-	Items           []OrderItem `json:"items" db:"-"` // Relación cargada manualmente o por GORM si se usara
-	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+	ID                 uint               `json:"id" db:"id"`
+	OrderNumber        string             `json:"order_number" db:"order_number"`       // Número visible del pedido (ej. CS-2026-000123)
+	Reference          string             `json:"reference" db:"reference"`             // Referencia pública (no secuencial) para el cliente
+	TrackingToken      string             `json:"tracking_token" db:"tracking_token"`   // Token del seguimiento público (ver OrderTracking)
+	TrackingNumber     string             `json:"tracking_number" db:"tracking_number"` // Número de seguimiento del envío (correo, moto)
+	CustomerID         *uint              `json:"customer_id" db:"customer_id"`         // Cliente asociado por teléfono o email (ver Customer)
+	CustomerName       string             `json:"customer_name" db:"customer_name"`
+	CustomerEmail      string             `json:"customer_email" db:"customer_email"`
+	CustomerPhone      string             `json:"customer_phone" db:"customer_phone"`
+	CustomerAddress    string             `json:"customer_address" db:"customer_address"`
+	ShippingMethod     ShippingMethodCode `json:"shipping_method" db:"shipping_method"`           // Vacío: sin envío (pedidos cargados a mano)
+	ShippingPostalCode string             `json:"shipping_postal_code" db:"shipping_postal_code"` // Destino usado para calcular el envío
+	ShippingProvince   string             `json:"shipping_province" db:"shipping_province"`
+	ShippingCost       float64            `json:"shipping_cost" db:"shipping_cost"`
+	PaymentMethod      PaymentMethod      `json:"payment_method" db:"payment_method"`
+	Installments       int                `json:"installments" db:"installments"`           // Cuotas (solo crédito)
+	Subtotal           float64            `json:"subtotal" db:"subtotal"`                   // Suma de los items
+	CouponCode         string             `json:"coupon_code" db:"coupon_code"`             // Cupón aplicado (ver Coupon)
+	Discount           float64            `json:"discount" db:"discount"`                   // Descuento del cupón
+	Surcharge          float64            `json:"surcharge" db:"surcharge"`                 // Recargo (o descuento, si es negativo) por medio de pago, sobre el subtotal con descuento
+	TotalAmount        float64            `json:"total_amount" db:"total_amount"`           // Subtotal - descuento + recargo + envío, calculado en el servidor
+	ReturnAdjustment   float64            `json:"return_adjustment" db:"return_adjustment"` // Devoluciones y cambios: valor devuelto menos lo entregado a cambio
+	Status             OrderStatus        `json:"status" db:"status"`
+	Notes              string             `json:"notes" db:"notes"`
+	Items              []OrderItem        `json:"items" db:"-"`              // Relación cargada manualmente o por GORM si se usara
+	Payments           []OrderPayment     `json:"payments,omitempty" db:"-"` // Cobros y devoluciones (solo en el detalle)
+	*PaymentSummary    `db:"-"`           // amount_paid, amount_refunded y balance_due (solo en el detalle)
+	CreatedAt          time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// AmountDue devuelve lo que el cliente debe pagar por el pedido: el total menos el ajuste
+// por devoluciones (lo devuelto con reintegro o crédito se descuenta y la diferencia a
+// favor de la tienda en un cambio se suma)
+func (o *Order) AmountDue() float64 {
+	return math.Round((o.TotalAmount-o.ReturnAdjustment)*100) / 100
+}
+
+// DisplayNumber devuelve el número con el que se identifica el pedido ante el cliente:
+// el número secuencial o, si todavía no lo tiene, la referencia pública
+func (o *Order) DisplayNumber() string {
+	if o.OrderNumber != "" {
+		return o.OrderNumber
+	}
+	return o.Reference
+}
+
+// Valores por defecto del número de pedido
+const (
+	DefaultOrderNumberPrefix = "CS"
+	DefaultOrderNumberDigits = 6
+)
+
+// OrderNumberFormat define cómo se arma el número de pedido: prefijo, año y un
+// secuencial que vuelve a empezar cada año, completado con ceros (ej. CS-2026-000123)
+type OrderNumberFormat struct {
+	Prefix string
+	Digits int
+}
+
+// Format arma el número del pedido seq del año indicado. Sin prefijo el número
+// empieza por el año (ej. 2026-000123).
+func (f OrderNumberFormat) Format(year, seq int) string {
+	digits := f.Digits
+	if digits <= 0 {
+		digits = DefaultOrderNumberDigits
+	}
+	number := fmt.Sprintf("%d-%0*d", year, digits, seq)
+	if f.Prefix == "" {
+		return number
+	}
+	return f.Prefix + "-" + number
+}
+
+// orderReferenceAlphabet excluye caracteres que se confunden al dictarlos (0/O, 1/I/L)
+const orderReferenceAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// NewOrderReference genera una referencia pública aleatoria con formato XXXX-XXXX
+func NewOrderReference() string {
+	buf := make([]byte, 8)
+	rand.Read(buf) // crypto/rand no devuelve error desde Go 1.24
+
+	ref := make([]byte, 0, 9)
+	for i, b := range buf {
+		if i == 4 {
+			ref = append(ref, '-')
+		}
+		ref = append(ref, orderReferenceAlphabet[int(b)%len(orderReferenceAlphabet)])
+	}
+	return string(ref)
 }
 
 // OrderItem representa un producto dentro de un pedido
@@ -36,8 +176,20 @@ type OrderItem struct {
 	ID          uint    `json:"id" db:"id"`
 	OrderID     uint    `json:"order_id" db:"order_id"`
 	ProductID   uint    `json:"product_id" db:"product_id"`
+	VariantID   *uint   `json:"variant_id" db:"variant_id"`     // Variante (talla × color) vendida, opcional
+	Talla       string  `json:"talla" db:"talla"`               // Snapshot de la talla
+	Color       string  `json:"color" db:"color"`               // Snapshot del color
 	ProductName string  `json:"product_name" db:"product_name"` // Snapshot del nombre
 	Quantity    int     `json:"quantity" db:"quantity"`
 	UnitPrice   float64 `json:"unit_price" db:"unit_price"` // Snapshot del precio
 	Subtotal    float64 `json:"subtotal" db:"subtotal"`
 }
+
+// StockKey identifica de dónde descuenta stock el item: su variante o, si el producto
+// no tiene variantes, el producto
+func (i OrderItem) StockKey() string {
+	if i.VariantID != nil {
+		return fmt.Sprintf("v%d", *i.VariantID)
+	}
+	return fmt.Sprintf("p%d", i.ProductID)
+}
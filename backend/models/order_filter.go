@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// OrderSort define el orden del listado de pedidos
+type OrderSort string
+
+const (
+	OrderSortNewest    OrderSort = "newest"     // Más recientes primero (por defecto)
+	OrderSortOldest    OrderSort = "oldest"     // Más antiguos primero
+	OrderSortTotalDesc OrderSort = "total_desc" // Mayor total primero
+	OrderSortTotalAsc  OrderSort = "total_asc"  // Menor total primero
+)
+
+// IsValid indica si el orden es uno de los soportados
+func (s OrderSort) IsValid() bool {
+	switch s {
+	case OrderSortNewest, OrderSortOldest, OrderSortTotalDesc, OrderSortTotalAsc:
+		return true
+	}
+	return false
+}
+
+// OrderFilter reúne los filtros del listado de pedidos. Los campos vacíos no filtran.
+type OrderFilter struct {
+	Statuses       []OrderStatus   // Alguno de estos estados
+	PaymentMethods []PaymentMethod // Alguno de estos medios de pago
+	From           *time.Time      // Creados desde este momento (inclusive)
+	To             *time.Time      // Creados antes de este momento (exclusive)
+	MinTotal       *float64        // Total mínimo (inclusive)
+	MaxTotal       *float64        // Total máximo (inclusive)
+	ProductID      uint            // Que contengan este producto
+	CustomerID     uint            // Del cliente (ver Customer)
+	Search         string          // Número, referencia, nombre o email del cliente
+	Sort           OrderSort
+}
+
+// Validate verifica estados, medios de pago, rangos y orden
+func (f *OrderFilter) Validate() error {
+	for _, status := range f.Statuses {
+		if !status.IsValid() {
+			return fmt.Errorf("estado de pedido inválido: %s", status)
+		}
+	}
+	for _, method := range f.PaymentMethods {
+		if method == "" || !method.IsValid() {
+			return fmt.Errorf("medio de pago inválido: %s", method)
+		}
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("la fecha desde debe ser anterior a la fecha hasta")
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && *f.MinTotal > *f.MaxTotal {
+		return fmt.Errorf("el total mínimo no puede superar al máximo")
+	}
+	if f.Sort == "" {
+		f.Sort = OrderSortNewest
+	}
+	if !f.Sort.IsValid() {
+		return fmt.Errorf("orden inválido: %s", f.Sort)
+	}
+	return nil
+}
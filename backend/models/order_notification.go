@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// OrderNotificationKind es el tipo de email que se envía al cliente por su pedido
+type OrderNotificationKind string
+
+const (
+	OrderNotificationReceived  OrderNotificationKind = "order_received"  // Pedido recibido
+	OrderNotificationPaid      OrderNotificationKind = "order_paid"      // Pago confirmado
+	OrderNotificationShipped   OrderNotificationKind = "order_shipped"   // Despachado (con seguimiento)
+	OrderNotificationDelivered OrderNotificationKind = "order_delivered" // Entregado
+)
+
+// NotificationForStatus devuelve el email que corresponde enviar cuando el pedido pasa a
+// ese estado (no todos los estados notifican al cliente)
+func NotificationForStatus(status OrderStatus) (OrderNotificationKind, bool) {
+	switch status {
+	case OrderStatusPending:
+		return OrderNotificationReceived, true
+	case OrderStatusPaid:
+		return OrderNotificationPaid, true
+	case OrderStatusShipped:
+		return OrderNotificationShipped, true
+	case OrderStatusDelivered:
+		return OrderNotificationDelivered, true
+	}
+	return "", false
+}
+
+// OrderNotificationStatus es el estado de envío de una notificación
+type OrderNotificationStatus string
+
+const (
+	OrderNotificationPending OrderNotificationStatus = "pending" // En cola
+	OrderNotificationSent    OrderNotificationStatus = "sent"    // Enviada
+	OrderNotificationFailed  OrderNotificationStatus = "failed"  // Falló MaxNotificationAttempts veces
+	OrderNotificationSkipped OrderNotificationStatus = "skipped" // No se envió (ver LastError)
+)
+
+// MaxNotificationAttempts es la cantidad de intentos antes de dar una notificación por fallida
+const MaxNotificationAttempts = 5
+
+// OrderNotification es un email al cliente en la cola de salida. Se encola en la misma
+// transacción que el cambio de estado del pedido y se envía después, de modo que un
+// servidor SMTP caído no frena ni revierte la operación. Cada tipo se envía una sola vez
+// por pedido.
+type OrderNotification struct {
+	ID        uint                    `json:"id"`
+	OrderID   uint                    `json:"order_id"`
+	Kind      OrderNotificationKind   `json:"kind"`
+	Status    OrderNotificationStatus `json:"status"`
+	Attempts  int                     `json:"attempts"`
+	LastError string                  `json:"last_error"` // Último error o motivo por el que no se envió
+	CreatedAt time.Time               `json:"created_at"`
+	SentAt    *time.Time              `json:"sent_at"`
+}
+
+// EmailMessage es un email listo para enviar, con versión en texto y en HTML
+type EmailMessage struct {
+	FromName string   // Nombre del remitente (la dirección es la del servidor SMTP configurado)
+	To       []string // Destinatarios visibles
+	Bcc      []string // Copias ocultas (ej. la copia para la tienda)
+	Subject  string
+	Text     string
+	HTML     string
+}
+
+// Recipients devuelve todos los destinatarios del mensaje
+func (m *EmailMessage) Recipients() []string {
+	return append(append([]string{}, m.To...), m.Bcc...)
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// OrderPaymentKind distingue cobros de devoluciones en el ledger de pagos
+type OrderPaymentKind string
+
+const (
+	OrderPaymentKindPayment OrderPaymentKind = "payment" // Cobro (seña, saldo o pago total)
+	OrderPaymentKindRefund  OrderPaymentKind = "refund"  // Devolución de dinero al cliente
+)
+
+// OrderPayment representa un cobro o una devolución registrada sobre un pedido.
+// El saldo del pedido es TotalAmount - (cobros - devoluciones).
+type OrderPayment struct {
+	ID        uint             `json:"id"`
+	OrderID   uint             `json:"order_id"`
+	Kind      OrderPaymentKind `json:"kind"`
+	Amount    float64          `json:"amount"` // Siempre positivo; Kind indica el sentido
+	Method    PaymentMethod    `json:"method"`
+	Reference string           `json:"reference"` // Comprobante: nº de transferencia, ID de Mercado Pago, etc.
+	Note      string           `json:"note"`
+	PaidAt    time.Time        `json:"paid_at"` // Fecha del cobro (puede ser anterior a la carga)
+	UserID    *uint            `json:"user_id"`
+	CreatedBy string           `json:"created_by"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Normalize limpia los campos de texto del pago
+func (p *OrderPayment) Normalize() {
+	p.Method = PaymentMethod(strings.ToLower(strings.TrimSpace(string(p.Method))))
+	p.Reference = strings.TrimSpace(p.Reference)
+	p.Note = strings.TrimSpace(p.Note)
+}
+
+// Validate valida el importe y el medio de pago
+func (p *OrderPayment) Validate() error {
+	if p.Kind != OrderPaymentKindPayment && p.Kind != OrderPaymentKindRefund {
+		return fmt.Errorf("tipo de movimiento inválido: %s", p.Kind)
+	}
+	if p.Amount <= 0 || math.IsNaN(p.Amount) || math.IsInf(p.Amount, 0) {
+		return fmt.Errorf("el importe debe ser mayor a 0")
+	}
+	if p.Method == "" {
+		return fmt.Errorf("el medio de pago es requerido")
+	}
+	if !p.Method.IsValid() {
+		return fmt.Errorf("medio de pago inválido: %s", p.Method)
+	}
+	if len(p.Reference) > 100 {
+		return fmt.Errorf("la referencia no puede superar los 100 caracteres")
+	}
+	if len(p.Note) > 500 {
+		return fmt.Errorf("la nota no puede superar los 500 caracteres")
+	}
+	return nil
+}
+
+// SignedAmount devuelve el importe con signo: positivo para cobros, negativo para devoluciones
+func (p OrderPayment) SignedAmount() float64 {
+	if p.Kind == OrderPaymentKindRefund {
+		return -p.Amount
+	}
+	return p.Amount
+}
+
+// PaymentSummary resume el estado de cuenta de un pedido
+type PaymentSummary struct {
+	AmountPaid     float64 `json:"amount_paid"`     // Cobros - devoluciones
+	AmountRefunded float64 `json:"amount_refunded"` // Total devuelto
+	BalanceDue     float64 `json:"balance_due"`     // Lo que falta cobrar (0 si está saldado)
+}
+
+// SummarizePayments calcula el estado de cuenta del pedido a partir de sus pagos
+func SummarizePayments(total float64, payments []OrderPayment) PaymentSummary {
+	var summary PaymentSummary
+	for _, p := range payments {
+		summary.AmountPaid += p.SignedAmount()
+		if p.Kind == OrderPaymentKindRefund {
+			summary.AmountRefunded += p.Amount
+		}
+	}
+
+	summary.AmountPaid = math.Round(summary.AmountPaid*100) / 100
+	summary.AmountRefunded = math.Round(summary.AmountRefunded*100) / 100
+	summary.BalanceDue = math.Max(0, math.Round((total-summary.AmountPaid)*100)/100)
+	return summary
+}
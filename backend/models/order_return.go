@@ -0,0 +1,181 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ReturnCondition es el estado en que vuelve un producto devuelto
+type ReturnCondition string
+
+const (
+	ReturnConditionResellable ReturnCondition = "resellable" // Vuelve al stock
+	ReturnConditionDamaged    ReturnCondition = "damaged"    // Se da de baja (no vuelve al stock)
+)
+
+// ReturnResolution define qué recibe el cliente a cambio de lo que devuelve
+type ReturnResolution string
+
+const (
+	ReturnResolutionExchange    ReturnResolution = "exchange"     // Cambio por otra talla u otro producto
+	ReturnResolutionStoreCredit ReturnResolution = "store_credit" // Crédito para una próxima compra (cupón de un uso)
+	ReturnResolutionRefund      ReturnResolution = "refund"       // Devolución del dinero (ledger de pagos)
+)
+
+// ReturnItem es un producto devuelto por el cliente
+type ReturnItem struct {
+	OrderItemID uint            `json:"order_item_id"`
+	ProductID   uint            `json:"product_id"`
+	VariantID   *uint           `json:"variant_id"`
+	ProductName string          `json:"product_name"` // Snapshot de la línea del pedido
+	Talla       string          `json:"talla"`
+	Color       string          `json:"color"`
+	Quantity    int             `json:"quantity"`
+	UnitPrice   float64         `json:"unit_price"` // Precio pagado (con el descuento del cupón prorrateado)
+	Condition   ReturnCondition `json:"condition"`
+	Restocked   bool            `json:"restocked"`
+}
+
+// ReturnReplacement es un producto que se entrega a cambio
+type ReturnReplacement struct {
+	ProductID   uint    `json:"product_id"`
+	VariantID   *uint   `json:"variant_id"`
+	ProductName string  `json:"product_name"`
+	Talla       string  `json:"talla"`
+	Color       string  `json:"color"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"` // Precio vigente al momento del cambio
+}
+
+// OrderReturn registra una devolución o un cambio asociado a un pedido.
+// ReturnedAmount es el valor de lo devuelto y ReplacementAmount el de lo entregado a cambio;
+// Difference (ReplacementAmount - ReturnedAmount) positiva es lo que debe abonar el cliente.
+type OrderReturn struct {
+	ID                uint                `json:"id"`
+	OrderID           uint                `json:"order_id"`
+	Resolution        ReturnResolution    `json:"resolution"`
+	Items             []ReturnItem        `json:"items"`
+	Replacements      []ReturnReplacement `json:"replacements"`
+	ReturnedAmount    float64             `json:"returned_amount"`
+	ReplacementAmount float64             `json:"replacement_amount"`
+	Difference        float64             `json:"difference"`
+	StoreCreditCode   string              `json:"store_credit_code,omitempty"` // Cupón emitido como crédito
+	RefundPaymentID   *uint               `json:"refund_payment_id,omitempty"` // Devolución registrada en el ledger
+	Note              string              `json:"note"`
+	UserID            *uint               `json:"user_id"`
+	CreatedBy         string              `json:"created_by"`
+	CreatedAt         time.Time           `json:"created_at"`
+}
+
+// OrderReturnRequest es el cuerpo de POST /api/orders/:id/returns
+type OrderReturnRequest struct {
+	Resolution   ReturnResolution    `json:"resolution"`
+	Items        []ReturnItemRequest `json:"items"`
+	Replacements []CheckoutItem      `json:"replacements"`  // Solo para cambios
+	RefundMethod PaymentMethod       `json:"refund_method"` // Solo para devoluciones de dinero
+	Note         string              `json:"note"`
+}
+
+// ReturnItemRequest indica qué línea del pedido se devuelve, cuántas unidades y en qué estado
+type ReturnItemRequest struct {
+	OrderItemID uint            `json:"order_item_id"`
+	Quantity    int             `json:"quantity"`
+	Condition   ReturnCondition `json:"condition"`
+}
+
+// Normalize limpia los campos de texto del pedido de devolución
+func (r *OrderReturnRequest) Normalize() {
+	r.Resolution = ReturnResolution(strings.ToLower(strings.TrimSpace(string(r.Resolution))))
+	r.RefundMethod = PaymentMethod(strings.ToLower(strings.TrimSpace(string(r.RefundMethod))))
+	r.Note = strings.TrimSpace(r.Note)
+	for i := range r.Items {
+		r.Items[i].Condition = ReturnCondition(strings.ToLower(strings.TrimSpace(string(r.Items[i].Condition))))
+		if r.Items[i].Condition == "" {
+			r.Items[i].Condition = ReturnConditionResellable
+		}
+	}
+	for i := range r.Replacements {
+		r.Replacements[i].Talla = strings.TrimSpace(r.Replacements[i].Talla)
+		r.Replacements[i].Color = strings.TrimSpace(r.Replacements[i].Color)
+	}
+}
+
+// Validate valida la resolución, los productos devueltos y los de reemplazo
+func (r *OrderReturnRequest) Validate() error {
+	switch r.Resolution {
+	case ReturnResolutionExchange:
+		if len(r.Replacements) == 0 {
+			return errors.New("un cambio debe indicar los productos que se entregan")
+		}
+	case ReturnResolutionStoreCredit, ReturnResolutionRefund:
+		if len(r.Replacements) > 0 {
+			return errors.New("solo los cambios entregan productos")
+		}
+	default:
+		return fmt.Errorf("resolución inválida: %q (usar exchange, store_credit o refund)", r.Resolution)
+	}
+
+	if r.Resolution == ReturnResolutionRefund {
+		if r.RefundMethod == "" {
+			return errors.New("el medio de devolución del dinero es requerido")
+		}
+		if !r.RefundMethod.IsValid() {
+			return fmt.Errorf("medio de pago inválido: %s", r.RefundMethod)
+		}
+	}
+
+	if len(r.Items) == 0 {
+		return errors.New("debe indicar al menos un producto devuelto")
+	}
+	for i, item := range r.Items {
+		if item.OrderItemID == 0 {
+			return fmt.Errorf("item %d: línea del pedido requerida", i+1)
+		}
+		if item.Quantity < 1 {
+			return fmt.Errorf("item %d: la cantidad debe ser mayor a 0", i+1)
+		}
+		if item.Condition != ReturnConditionResellable && item.Condition != ReturnConditionDamaged {
+			return fmt.Errorf("item %d: estado inválido %q (usar resellable o damaged)", i+1, item.Condition)
+		}
+	}
+	for i, item := range r.Replacements {
+		if item.ProductID == 0 {
+			return fmt.Errorf("reemplazo %d: producto requerido", i+1)
+		}
+		if item.Quantity < 1 {
+			return fmt.Errorf("reemplazo %d: la cantidad debe ser mayor a 0", i+1)
+		}
+	}
+
+	if utf8.RuneCountInString(r.Note) > 500 {
+		return errors.New("la nota no puede superar los 500 caracteres")
+	}
+	return nil
+}
+
+// PaidUnitPrice devuelve lo que el cliente pagó por unidad de la línea, prorrateando el
+// descuento del cupón sobre el subtotal del pedido (sin recargos ni envío)
+func PaidUnitPrice(order *Order, item OrderItem) float64 {
+	if order.Discount <= 0 || order.Subtotal <= 0 {
+		return item.UnitPrice
+	}
+	ratio := 1 - order.Discount/order.Subtotal
+	return math.Round(item.UnitPrice*ratio*100) / 100
+}
+
+// ReturnedQuantities suma las unidades ya devueltas de un pedido por variante (o producto),
+// indexadas por OrderItem.StockKey
+func ReturnedQuantities(returns []OrderReturn) map[string]int {
+	returned := map[string]int{}
+	for _, r := range returns {
+		for _, item := range r.Items {
+			key := OrderItem{ProductID: item.ProductID, VariantID: item.VariantID}.StockKey()
+			returned[key] += item.Quantity
+		}
+	}
+	return returned
+}
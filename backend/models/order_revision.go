@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// OrderItemsUpdate es el cuerpo de PUT /api/orders/:id/items: la lista completa de
+// productos que debe quedar en el pedido. Precios y totales se recalculan en el servidor.
+type OrderItemsUpdate struct {
+	Items []CheckoutItem `json:"items"`
+	Note  string         `json:"note"` // Motivo del cambio (queda en la auditoría)
+}
+
+// Normalize recorta espacios de los campos de texto
+func (u *OrderItemsUpdate) Normalize() {
+	u.Note = strings.TrimSpace(u.Note)
+	for i := range u.Items {
+		u.Items[i].Talla = strings.TrimSpace(u.Items[i].Talla)
+		u.Items[i].Color = strings.TrimSpace(u.Items[i].Color)
+	}
+}
+
+// Validate valida que el pedido siga teniendo productos y que las cantidades sean positivas
+func (u *OrderItemsUpdate) Validate() error {
+	if len(u.Items) == 0 {
+		return errors.New("el pedido debe tener al menos un producto")
+	}
+	for i, item := range u.Items {
+		if item.ProductID == 0 {
+			return fmt.Errorf("item %d: producto requerido", i+1)
+		}
+		if item.Quantity < 1 {
+			return fmt.Errorf("item %d: la cantidad debe ser mayor a 0", i+1)
+		}
+	}
+	if utf8.RuneCountInString(u.Note) > 500 {
+		return errors.New("la nota no puede superar los 500 caracteres")
+	}
+	return nil
+}
+
+// OrderItems convierte las líneas recibidas en items de pedido (sin precios)
+func (u *OrderItemsUpdate) OrderItems() []OrderItem {
+	items := make([]OrderItem, len(u.Items))
+	for i, line := range u.Items {
+		items[i] = OrderItem{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Talla:     line.Talla,
+			Color:     line.Color,
+			Quantity:  line.Quantity,
+		}
+	}
+	return items
+}
+
+// OrderItemChange describe cómo cambió la cantidad de un producto (o variante) del pedido.
+// QuantityBefore 0 es un producto agregado y QuantityAfter 0 uno quitado.
+type OrderItemChange struct {
+	ProductID      uint   `json:"product_id"`
+	VariantID      *uint  `json:"variant_id"`
+	ProductName    string `json:"product_name"`
+	Talla          string `json:"talla"`
+	Color          string `json:"color"`
+	QuantityBefore int    `json:"quantity_before"`
+	QuantityAfter  int    `json:"quantity_after"`
+}
+
+// Delta devuelve la diferencia de unidades (positiva si se agregaron)
+func (c OrderItemChange) Delta() int {
+	return c.QuantityAfter - c.QuantityBefore
+}
+
+// OrderRevision es una entrada de la auditoría de cambios de productos de un pedido
+type OrderRevision struct {
+	ID          uint              `json:"id"`
+	OrderID     uint              `json:"order_id"`
+	Changes     []OrderItemChange `json:"changes"`
+	TotalBefore float64           `json:"total_before"`
+	TotalAfter  float64           `json:"total_after"`
+	UserID      *uint             `json:"user_id"`
+	ChangedBy   string            `json:"changed_by"`
+	Note        string            `json:"note"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// DiffOrderItems compara los items de un pedido antes y después de editarlo, agrupando
+// las cantidades por variante (o producto). Solo devuelve lo que cambió, en el orden en
+// que aparece cada producto.
+func DiffOrderItems(before, after []OrderItem) []OrderItemChange {
+	var changes []OrderItemChange
+	index := map[string]int{}
+
+	add := func(item OrderItem, isAfter bool) {
+		key := item.StockKey()
+		idx, ok := index[key]
+		if !ok {
+			idx = len(changes)
+			index[key] = idx
+			changes = append(changes, OrderItemChange{
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Talla:     item.Talla,
+				Color:     item.Color,
+			})
+		}
+		change := &changes[idx]
+		if item.ProductName != "" {
+			change.ProductName = item.ProductName
+		}
+		if isAfter {
+			change.QuantityAfter += item.Quantity
+		} else {
+			change.QuantityBefore += item.Quantity
+		}
+	}
+
+	for _, item := range before {
+		add(item, false)
+	}
+	for _, item := range after {
+		add(item, true)
+	}
+
+	diff := []OrderItemChange{}
+	for _, change := range changes {
+		if change.Delta() != 0 {
+			diff = append(diff, change)
+		}
+	}
+	return diff
+}
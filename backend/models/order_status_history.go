@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// OrderStatusHistory registra un cambio de estado de un pedido
+type OrderStatusHistory struct {
+	ID         uint        `json:"id"`
+	OrderID    uint        `json:"order_id"`
+	FromStatus OrderStatus `json:"from_status"` // Vacío en la creación del pedido
+	ToStatus   OrderStatus `json:"to_status"`
+	UserID     *uint       `json:"user_id"`
+	ChangedBy  string      `json:"changed_by"`
+	Note       string      `json:"note"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// Actor identifica quién realiza un cambio: un admin autenticado o un proceso del sistema
+type Actor struct {
+	UserID   *uint
+	Username string
+}
+
+// SystemActor crea un actor para procesos automáticos (checkout, webhooks, jobs)
+func SystemActor(name string) Actor {
+	return Actor{Username: name}
+}
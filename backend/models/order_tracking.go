@@ -0,0 +1,77 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+// NewTrackingToken genera el token del seguimiento público de un pedido: 144 bits
+// aleatorios (24 caracteres URL-safe), imposible de adivinar a partir de otro pedido
+func NewTrackingToken() string {
+	buf := make([]byte, 18)
+	rand.Read(buf) // crypto/rand no devuelve error desde Go 1.24
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// OrderTracking es lo que ve el cliente en la página de seguimiento: estado, historial de
+// estados, productos y envío. No incluye datos personales, importes ni notas internas.
+type OrderTracking struct {
+	OrderNumber    string               `json:"order_number"`
+	Status         OrderStatus          `json:"status"`
+	Timeline       []OrderTrackingEvent `json:"timeline"`
+	Items          []OrderTrackingItem  `json:"items"`
+	ShippingMethod ShippingMethodCode   `json:"shipping_method"`
+	ShippingLabel  string               `json:"shipping_label"`  // Nombre del método de envío
+	TrackingNumber string               `json:"tracking_number"` // Número de seguimiento del envío, si ya se despachó
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// OrderTrackingEvent es un cambio de estado del pedido
+type OrderTrackingEvent struct {
+	Status OrderStatus `json:"status"`
+	At     time.Time   `json:"at"`
+}
+
+// OrderTrackingItem es un producto del pedido, sin precios
+type OrderTrackingItem struct {
+	ProductName string `json:"product_name"`
+	Talla       string `json:"talla"`
+	Color       string `json:"color"`
+	Quantity    int    `json:"quantity"`
+}
+
+// NewOrderTracking arma el seguimiento público del pedido. Del historial solo se toman
+// los cambios de estado (sin quién ni notas); shippingLabel es el nombre del método de envío.
+func NewOrderTracking(order *Order, history []OrderStatusHistory, shippingLabel string) OrderTracking {
+	tracking := OrderTracking{
+		OrderNumber:    order.DisplayNumber(),
+		Status:         order.Status,
+		Timeline:       []OrderTrackingEvent{},
+		Items:          make([]OrderTrackingItem, len(order.Items)),
+		ShippingMethod: order.ShippingMethod,
+		ShippingLabel:  shippingLabel,
+		TrackingNumber: order.TrackingNumber,
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+	}
+
+	for _, entry := range history {
+		if entry.FromStatus == entry.ToStatus {
+			continue // Registros sin cambio de estado (ej. devoluciones)
+		}
+		tracking.Timeline = append(tracking.Timeline, OrderTrackingEvent{Status: entry.ToStatus, At: entry.CreatedAt})
+	}
+
+	for i, item := range order.Items {
+		tracking.Items[i] = OrderTrackingItem{
+			ProductName: item.ProductName,
+			Talla:       item.Talla,
+			Color:       item.Color,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	return tracking
+}
@@ -0,0 +1,55 @@
+package models
+
+import "errors"
+
+// ErrInvalidPaymentID indica que el ID de pago recibido no tiene el formato de la pasarela.
+// Reintentar el aviso no lo corrige: el webhook lo ignora.
+var ErrInvalidPaymentID = errors.New("ID de pago inválido")
+
+// Estados de pago informados por la pasarela (mismos valores que Mercado Pago)
+const (
+	GatewayPaymentApproved  = "approved"
+	GatewayPaymentPending   = "pending"
+	GatewayPaymentRejected  = "rejected"
+	GatewayPaymentCancelled = "cancelled"
+	GatewayPaymentRefunded  = "refunded"
+)
+
+// PaymentPreferenceRequest son los datos para iniciar un pago online de un pedido
+type PaymentPreferenceRequest struct {
+	ExternalReference string  // Referencia pública del pedido
+	Title             string  // Descripción que ve el cliente
+	Amount            float64 // Total a cobrar
+	PayerName         string
+	PayerEmail        string
+	NotificationURL   string // Webhook al que la pasarela avisa los pagos
+	SuccessURL        string
+	FailureURL        string
+	PendingURL        string
+}
+
+// PaymentPreference es el pago iniciado en la pasarela
+type PaymentPreference struct {
+	ID        string `json:"id"`
+	InitPoint string `json:"init_point"` // URL a la que se redirige al cliente
+}
+
+// GatewayPayment es un pago tal como lo informa la pasarela
+type GatewayPayment struct {
+	ID                string  `json:"id"`
+	Status            string  `json:"status"`
+	StatusDetail      string  `json:"status_detail"`
+	ExternalReference string  `json:"external_reference"`
+	Amount            float64 `json:"amount"`
+	PaymentMethodID   string  `json:"payment_method_id"`
+	PaymentTypeID     string  `json:"payment_type_id"`
+	Installments      int     `json:"installments"`
+}
+
+// PaymentNotification es un aviso recibido en el webhook de la pasarela
+type PaymentNotification struct {
+	Type      string // "payment"; otros tipos se ignoran
+	DataID    string // ID del pago
+	RequestID string // Header x-request-id
+	Signature string // Header x-signature
+}
@@ -0,0 +1,99 @@
+package models
+
+import "fmt"
+
+// PaymentMethod define el medio de pago del pedido
+type PaymentMethod string
+
+const (
+	PaymentMethodCash       PaymentMethod = "efectivo"
+	PaymentMethodTransfer   PaymentMethod = "transferencia"
+	PaymentMethodDebitCard  PaymentMethod = "debito"
+	PaymentMethodCreditCard PaymentMethod = "credito" // Admite cuotas
+)
+
+// PaymentMethods lista los medios de pago en el orden en que se muestran
+var PaymentMethods = []PaymentMethod{PaymentMethodCash, PaymentMethodTransfer, PaymentMethodDebitCard, PaymentMethodCreditCard}
+
+// IsValid indica si el medio de pago es conocido (vacío = sin especificar)
+func (m PaymentMethod) IsValid() bool {
+	if m == "" {
+		return true
+	}
+	for _, known := range PaymentMethods {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallmentPlan es una opción de pago en cuotas con su recargo
+type InstallmentPlan struct {
+	Cuotas           int     `json:"cuotas"`
+	SurchargePercent float64 `json:"surcharge_percent"`
+}
+
+// PaymentMethodRule define el ajuste de precio de un medio de pago.
+// AdjustmentPercent positivo es recargo y negativo es descuento. Para crédito en un pago
+// se usa SiteConfig.CreditCardSurcharge; Installments lista los planes de 2 o más cuotas.
+type PaymentMethodRule struct {
+	Method            PaymentMethod     `json:"method"`
+	Label             string            `json:"label"`
+	Enabled           bool              `json:"enabled"`
+	AdjustmentPercent float64           `json:"adjustment_percent"`
+	Installments      []InstallmentPlan `json:"installments,omitempty"`
+}
+
+// Validate valida la regla de un medio de pago
+func (r *PaymentMethodRule) Validate() error {
+	if r.Method == "" || !r.Method.IsValid() {
+		return fmt.Errorf("medio de pago inválido: %q", r.Method)
+	}
+	if r.AdjustmentPercent <= -100 {
+		return fmt.Errorf("%s: el descuento debe ser menor al 100%%", r.Method)
+	}
+	if len(r.Installments) > 0 && r.Method != PaymentMethodCreditCard {
+		return fmt.Errorf("%s: solo el crédito admite cuotas", r.Method)
+	}
+
+	seen := map[int]bool{}
+	for _, plan := range r.Installments {
+		if plan.Cuotas < 2 {
+			return fmt.Errorf("%s: los planes en cuotas deben ser de 2 o más cuotas", r.Method)
+		}
+		if seen[plan.Cuotas] {
+			return fmt.Errorf("%s: el plan de %d cuotas está repetido", r.Method, plan.Cuotas)
+		}
+		if plan.SurchargePercent <= -100 {
+			return fmt.Errorf("%s: recargo inválido para %d cuotas", r.Method, plan.Cuotas)
+		}
+		seen[plan.Cuotas] = true
+	}
+
+	return nil
+}
+
+// AdjustmentFor devuelve el porcentaje a aplicar para la cantidad de cuotas (0 o 1 = un pago)
+func (r *PaymentMethodRule) AdjustmentFor(installments int) (float64, error) {
+	if installments <= 1 {
+		return r.AdjustmentPercent, nil
+	}
+	for _, plan := range r.Installments {
+		if plan.Cuotas == installments {
+			return plan.SurchargePercent, nil
+		}
+	}
+	return 0, fmt.Errorf("no se ofrecen %d cuotas con %s", installments, r.Label)
+}
+
+// DefaultPaymentMethods arma las reglas iniciales: todos los medios sin ajuste salvo
+// crédito, que toma el recargo configurado
+func DefaultPaymentMethods(creditCardSurcharge float64) []PaymentMethodRule {
+	return []PaymentMethodRule{
+		{Method: PaymentMethodCash, Label: "Efectivo", Enabled: true},
+		{Method: PaymentMethodTransfer, Label: "Transferencia", Enabled: true},
+		{Method: PaymentMethodDebitCard, Label: "Débito", Enabled: true},
+		{Method: PaymentMethodCreditCard, Label: "Crédito", Enabled: true, AdjustmentPercent: creditCardSurcharge},
+	}
+}
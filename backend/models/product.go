@@ -17,12 +17,13 @@ type Product struct {
 	Precio      float64   `json:"precio"`
 	PrecioLista float64   `json:"precio_lista"`
 	Stock       int            `json:"stock"`
-	StockBySize map[string]int `json:"stock_by_size"` // Se guardará como JSON string en SQLite
+	StockBySize map[string]int `json:"stock_by_size"` // Derivado de las variantes (compatibilidad con el frontend)
 	Tallas      []string       `json:"tallas"`        // Se guardará como JSON string en SQLite
 	Colores     []string       `json:"colores"`       // Se guardará como JSON string en SQLite
 	Imagenes    []string       `json:"imagenes"`      // Se guardará como JSON string en SQLite
 	Activo      bool           `json:"activo"`
 	Destacado   bool           `json:"destacado"`
+	Variantes   []ProductVariant `json:"variantes"` // Cargadas desde product_variants
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProductVariant representa una combinación talla × color de un producto (SKU)
+type ProductVariant struct {
+	ID        uint      `json:"id"`
+	ProductID uint      `json:"product_id"`
+	Talla     string    `json:"talla"`
+	Color     string    `json:"color"`
+	SKU       string    `json:"sku"`
+	Stock     int       `json:"stock"`
+	Precio    *float64  `json:"precio"` // Opcional: si es nil se usa el precio del producto
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate valida los campos de la variante antes de crear/actualizar
+func (v *ProductVariant) Validate() error {
+	if strings.TrimSpace(v.Talla) == "" {
+		return errors.New("la talla es requerida")
+	}
+
+	if v.Stock < 0 {
+		return errors.New("el stock no puede ser negativo")
+	}
+
+	if v.Precio != nil && *v.Precio <= 0 {
+		return errors.New("el precio de la variante debe ser mayor a 0")
+	}
+
+	return nil
+}
+
+// Normalize limpia talla, color y SKU y genera el SKU si no fue informado
+func (v *ProductVariant) Normalize() {
+	v.Talla = strings.TrimSpace(v.Talla)
+	v.Color = strings.ToLower(strings.TrimSpace(v.Color))
+	v.SKU = strings.ToUpper(strings.TrimSpace(v.SKU))
+
+	if v.SKU == "" {
+		v.SKU = BuildVariantSKU(v.ProductID, v.Talla, v.Color)
+	}
+}
+
+// EffectivePrice devuelve el precio de la variante o el precio base del producto
+func (v *ProductVariant) EffectivePrice(basePrice float64) float64 {
+	if v.Precio != nil {
+		return *v.Precio
+	}
+	return basePrice
+}
+
+// BuildVariantSKU genera un SKU legible a partir del producto, la talla y el color.
+// Ej: producto 12, talla 42, color "negro" => "CS-12-42-NEGRO"
+func BuildVariantSKU(productID uint, talla, color string) string {
+	sku := fmt.Sprintf("CS-%d-%s", productID, skuSegment(talla))
+	if seg := skuSegment(color); seg != "" {
+		sku += "-" + seg
+	}
+	return sku
+}
+
+// skuSegment normaliza un fragmento del SKU (mayúsculas, sin espacios)
+func skuSegment(s string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(s)), "_")
+}
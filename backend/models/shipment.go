@@ -0,0 +1,126 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ShipmentStatus es el estado de un envío según el transportista
+type ShipmentStatus string
+
+const (
+	ShipmentStatusInTransit ShipmentStatus = "in_transit" // Despachado, en camino
+	ShipmentStatusDelivered ShipmentStatus = "delivered"  // Entregado al cliente
+	ShipmentStatusException ShipmentStatus = "exception"  // Incidencia (dirección incorrecta, ausente, devuelto al remitente)
+)
+
+// IsValid indica si el estado es uno de los conocidos
+func (s ShipmentStatus) IsValid() bool {
+	switch s {
+	case ShipmentStatusInTransit, ShipmentStatusDelivered, ShipmentStatusException:
+		return true
+	}
+	return false
+}
+
+// Shipment es un despacho de un pedido: transportista, número de seguimiento, fechas y
+// etiqueta. Un pedido puede tener más de uno (ej. un reenvío después de una incidencia).
+type Shipment struct {
+	ID                uint           `json:"id"`
+	OrderID           uint           `json:"order_id"`
+	Carrier           string         `json:"carrier"`         // Código del transportista (ej. oca, andreani, correo_argentino)
+	TrackingNumber    string         `json:"tracking_number"` // Vacío si el transportista no da seguimiento (ej. moto propia)
+	ShippedAt         time.Time      `json:"shipped_at"`
+	EstimatedDelivery *time.Time     `json:"estimated_delivery"`
+	LabelFile         string         `json:"-"`         // Archivo de la etiqueta en la carpeta privada de etiquetas
+	HasLabel          bool           `json:"has_label"` // Se descarga con GET /api/orders/{id}/shipments/{shipmentId}/label
+	Status            ShipmentStatus `json:"status"`
+	StatusDetail      string         `json:"status_detail"` // Última descripción informada por el transportista
+	DeliveredAt       *time.Time     `json:"delivered_at"`
+	LastCheckedAt     *time.Time     `json:"last_checked_at"` // Última consulta al transportista
+	CreatedBy         string         `json:"created_by"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+// ShipmentRequest son los datos para registrar o corregir un envío
+type ShipmentRequest struct {
+	Carrier           string     `json:"carrier"`
+	TrackingNumber    string     `json:"tracking_number"`
+	ShippedAt         *time.Time `json:"shipped_at"` // Por defecto, ahora
+	EstimatedDelivery *time.Time `json:"estimated_delivery"`
+}
+
+// Normalize pasa el transportista a minúsculas y limpia el número de seguimiento
+func (r *ShipmentRequest) Normalize() {
+	r.Carrier = strings.ToLower(strings.TrimSpace(r.Carrier))
+	r.TrackingNumber = strings.TrimSpace(r.TrackingNumber)
+}
+
+// Validate valida el transportista, el número de seguimiento y las fechas
+func (r *ShipmentRequest) Validate() error {
+	if r.Carrier == "" {
+		return errors.New("el transportista es requerido")
+	}
+	if utf8.RuneCountInString(r.Carrier) > 50 {
+		return errors.New("el código del transportista es demasiado largo")
+	}
+	if utf8.RuneCountInString(r.TrackingNumber) > 100 {
+		return errors.New("el número de seguimiento es demasiado largo")
+	}
+	if r.ShippedAt != nil && r.EstimatedDelivery != nil && r.EstimatedDelivery.Before(*r.ShippedAt) {
+		return errors.New("la entrega estimada no puede ser anterior al despacho")
+	}
+	return nil
+}
+
+// Apply copia los datos de la solicitud al envío
+func (r *ShipmentRequest) Apply(s *Shipment, now time.Time) {
+	s.Carrier = r.Carrier
+	s.TrackingNumber = r.TrackingNumber
+	s.ShippedAt = now
+	if r.ShippedAt != nil {
+		s.ShippedAt = *r.ShippedAt
+	}
+	s.EstimatedDelivery = r.EstimatedDelivery
+}
+
+// CarrierStatus es el estado de un envío tal como lo informa el transportista
+type CarrierStatus struct {
+	Status            ShipmentStatus
+	Detail            string     // Descripción del último evento (ej. "En sucursal de destino")
+	DeliveredAt       *time.Time // Momento de la entrega, si la informa
+	EstimatedDelivery *time.Time // Nueva fecha estimada, si la informa
+}
+
+// ApplyCarrierStatus actualiza el envío con lo informado por el transportista. Devuelve
+// true si el envío pasó a entregado con esta actualización.
+func (s *Shipment) ApplyCarrierStatus(status CarrierStatus, now time.Time) (bool, error) {
+	if !status.Status.IsValid() {
+		return false, fmt.Errorf("estado de envío desconocido: %q", status.Status)
+	}
+
+	s.LastCheckedAt = &now
+	if status.Detail != "" {
+		s.StatusDetail = status.Detail
+	}
+	if status.EstimatedDelivery != nil {
+		s.EstimatedDelivery = status.EstimatedDelivery
+	}
+
+	wasDelivered := s.Status == ShipmentStatusDelivered
+	s.Status = status.Status
+	if s.Status != ShipmentStatusDelivered || wasDelivered {
+		return false, nil
+	}
+
+	deliveredAt := now
+	if status.DeliveredAt != nil {
+		deliveredAt = *status.DeliveredAt
+	}
+	s.DeliveredAt = &deliveredAt
+	return true, nil
+}
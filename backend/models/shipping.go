@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShippingMethodCode identifica un método de envío
+type ShippingMethodCode string
+
+const (
+	ShippingPickup ShippingMethodCode = "retiro" // Retiro en el local (sin costo)
+	ShippingMoto   ShippingMethodCode = "moto"   // Moto en CABA
+	ShippingCorreo ShippingMethodCode = "correo" // Correo a todo el país
+)
+
+// ShippingZone es una zona de un método de envío con su costo.
+// Una zona alcanza un código postal si cae en el rango [PostalCodeFrom, PostalCodeTo]
+// o si la provincia está en Provinces. Una zona sin rango ni provincias alcanza a todo el país.
+type ShippingZone struct {
+	Name           string   `json:"name"`
+	PostalCodeFrom int      `json:"postal_code_from"` // CP numérico de 4 dígitos (ej: 1000)
+	PostalCodeTo   int      `json:"postal_code_to"`
+	Provinces      []string `json:"provinces"`
+	Cost           float64  `json:"cost"`
+	EstimatedDays  string   `json:"estimated_days"` // Texto para la tienda (ej: "24 a 48 hs")
+}
+
+// IsCatchAll indica si la zona alcanza a cualquier destino
+func (z *ShippingZone) IsCatchAll() bool {
+	return z.PostalCodeFrom == 0 && z.PostalCodeTo == 0 && len(z.Provinces) == 0
+}
+
+// Matches indica si la zona alcanza al código postal o a la provincia
+func (z *ShippingZone) Matches(postalCode int, province string) bool {
+	if z.IsCatchAll() {
+		return true
+	}
+	if postalCode > 0 && z.PostalCodeFrom > 0 && postalCode >= z.PostalCodeFrom && postalCode <= z.PostalCodeTo {
+		return true
+	}
+	if province = normalizeProvince(province); province != "" {
+		for _, p := range z.Provinces {
+			if normalizeProvince(p) == province {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ShippingMethod es un método de envío configurable con sus zonas.
+// Los métodos sin zonas (retiro en el local) no tienen costo ni requieren destino.
+type ShippingMethod struct {
+	Code    ShippingMethodCode `json:"code"`
+	Label   string             `json:"label"`
+	Enabled bool               `json:"enabled"`
+	Zones   []ShippingZone     `json:"zones"`
+}
+
+// Validate valida el método de envío y sus zonas
+func (m *ShippingMethod) Validate() error {
+	if strings.TrimSpace(string(m.Code)) == "" {
+		return fmt.Errorf("el código del método de envío es requerido")
+	}
+	for i, zone := range m.Zones {
+		if zone.Cost < 0 {
+			return fmt.Errorf("%s: el costo de la zona %d no puede ser negativo", m.Code, i+1)
+		}
+		// Sin rango (0 y 0) la zona se define por provincias; un rango debe tener ambos extremos
+		hasRange := zone.PostalCodeFrom != 0 || zone.PostalCodeTo != 0
+		if hasRange && (zone.PostalCodeFrom <= 0 || zone.PostalCodeFrom > zone.PostalCodeTo || zone.PostalCodeTo > 9999) {
+			return fmt.Errorf("%s: rango de códigos postales inválido en la zona %d", m.Code, i+1)
+		}
+	}
+	return nil
+}
+
+// FindZone devuelve la primera zona que alcanza al destino, o nil si el método no llega.
+// Las zonas se evalúan en orden: conviene dejar la zona general al final.
+func (m *ShippingMethod) FindZone(postalCode int, province string) *ShippingZone {
+	for i := range m.Zones {
+		if m.Zones[i].Matches(postalCode, province) {
+			return &m.Zones[i]
+		}
+	}
+	return nil
+}
+
+// DefaultShippingMethods arma los métodos iniciales: retiro, moto en CABA y correo
+func DefaultShippingMethods() []ShippingMethod {
+	return []ShippingMethod{
+		{Code: ShippingPickup, Label: "Retiro en el local", Enabled: true},
+		{Code: ShippingMoto, Label: "Moto (CABA)", Enabled: true, Zones: []ShippingZone{
+			{Name: "CABA", PostalCodeFrom: 1000, PostalCodeTo: 1499, Provinces: []string{"CABA", "Ciudad Autónoma de Buenos Aires", "Capital Federal"}, Cost: 3500, EstimatedDays: "24 a 48 hs"},
+		}},
+		{Code: ShippingCorreo, Label: "Correo", Enabled: true, Zones: []ShippingZone{
+			{Name: "AMBA", PostalCodeFrom: 1000, PostalCodeTo: 1999, Cost: 5500, EstimatedDays: "2 a 4 días hábiles"},
+			{Name: "Resto del país", Cost: 8500, EstimatedDays: "3 a 7 días hábiles"},
+		}},
+	}
+}
+
+var postalCodeDigits = regexp.MustCompile(`\d{4}`)
+
+// ParsePostalCode obtiene el CP numérico de 4 dígitos de un código postal argentino,
+// tanto en formato viejo ("1425") como CPA ("C1425ABC"). Devuelve 0 si no es válido.
+func ParsePostalCode(postalCode string) int {
+	match := postalCodeDigits.FindString(strings.ToUpper(strings.TrimSpace(postalCode)))
+	if match == "" {
+		return 0
+	}
+	n, _ := strconv.Atoi(match)
+	return n
+}
+
+// provinceAccents quita los acentos de los nombres de provincia
+var provinceAccents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u")
+
+// normalizeProvince compara provincias sin mayúsculas ni acentos
+func normalizeProvince(province string) string {
+	return provinceAccents.Replace(strings.ToLower(strings.TrimSpace(province)))
+}
+
+// ShippingQuote es el costo de un método de envío para un destino
+type ShippingQuote struct {
+	Method        ShippingMethodCode `json:"method"`
+	Label         string             `json:"label"`
+	Zone          string             `json:"zone"`
+	Cost          float64            `json:"cost"`
+	Free          bool               `json:"free"` // Envío gratis por superar el mínimo
+	EstimatedDays string             `json:"estimated_days"`
+}
+
+// QuoteShipping calcula el costo del método para el destino y el importe de la compra.
+// Devuelve error si el método no llega al destino.
+func QuoteShipping(method *ShippingMethod, postalCode int, province string, amount, freeShippingThreshold float64) (ShippingQuote, error) {
+	quote := ShippingQuote{Method: method.Code, Label: method.Label}
+	if len(method.Zones) == 0 {
+		return quote, nil
+	}
+
+	if postalCode == 0 && strings.TrimSpace(province) == "" {
+		return quote, fmt.Errorf("indicá el código postal o la provincia para calcular el envío")
+	}
+
+	zone := method.FindZone(postalCode, province)
+	if zone == nil {
+		return quote, fmt.Errorf("%s no llega a ese destino", method.Label)
+	}
+
+	quote.Zone = zone.Name
+	quote.EstimatedDays = zone.EstimatedDays
+	quote.Cost = math.Round(zone.Cost*100) / 100
+	if freeShippingThreshold > 0 && amount >= freeShippingThreshold {
+		quote.Cost = 0
+		quote.Free = true
+	}
+	return quote, nil
+}
@@ -1,19 +1,144 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // SiteConfig represents the global configuration for the store
 type SiteConfig struct {
-	ID                  uint      `json:"id"`
-	StoreName           string    `json:"store_name"`
-	Description         string    `json:"description"`
-	LogoURL             string    `json:"logo_url"`
-	WhatsAppNumber      string    `json:"whatsapp_number"`
-	WhatsAppMessage     string    `json:"whatsapp_message"`
-	CreditCardSurcharge float64   `json:"credit_card_surcharge"`
-	LowStockThreshold   int       `json:"low_stock_threshold"`
-	EnableStockAlerts   bool      `json:"enable_stock_alerts"`
-	EnableOrderAlerts   bool      `json:"enable_order_alerts"`
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
+	ID                     uint                `json:"id"`
+	StoreName              string              `json:"store_name"`
+	Description            string              `json:"description"`
+	LogoURL                string              `json:"logo_url"`
+	WhatsAppNumber         string              `json:"whatsapp_number"`
+	WhatsAppMessage        string              `json:"whatsapp_message"`
+	WhatsAppProductMessage string              `json:"whatsapp_product_message"` // Plantilla de consulta por un producto (ver RenderWhatsAppMessage)
+	WhatsAppOrderMessage   string              `json:"whatsapp_order_message"`   // Plantilla del mensaje al cliente por su pedido
+	CreditCardSurcharge    float64             `json:"credit_card_surcharge"`    // Recargo de crédito en un pago
+	LowStockThreshold      int                 `json:"low_stock_threshold"`
+	EnableStockAlerts      bool                `json:"enable_stock_alerts"`
+	EnableOrderAlerts      bool                `json:"enable_order_alerts"`
+	PaymentMethods         []PaymentMethodRule `json:"payment_methods"`
+	ShippingMethods        []ShippingMethod    `json:"shipping_methods"`
+	FreeShippingOver       float64             `json:"free_shipping_threshold"` // Envío gratis desde este importe (0 = nunca)
+	OrderNumberPrefix      string              `json:"order_number_prefix"`     // Prefijo del número de pedido (ej. CS)
+	OrderNumberDigits      int                 `json:"order_number_digits"`     // Dígitos del secuencial anual (ej. 6 → 000123)
+	CreatedAt              time.Time           `json:"created_at"`
+	UpdatedAt              time.Time           `json:"updated_at"`
+}
+
+// NormalizePaymentMethods completa los medios de pago faltantes con sus valores por
+// defecto y sincroniza el recargo de crédito en un pago con CreditCardSurcharge
+func (c *SiteConfig) NormalizePaymentMethods() {
+	defaults := DefaultPaymentMethods(c.CreditCardSurcharge)
+	configured := map[PaymentMethod]PaymentMethodRule{}
+	for _, rule := range c.PaymentMethods {
+		configured[rule.Method] = rule
+	}
+
+	rules := make([]PaymentMethodRule, 0, len(defaults))
+	for _, def := range defaults {
+		rule, ok := configured[def.Method]
+		if !ok {
+			rule = def
+		}
+		if rule.Label == "" {
+			rule.Label = def.Label
+		}
+		if rule.Method == PaymentMethodCreditCard {
+			rule.AdjustmentPercent = c.CreditCardSurcharge
+		}
+		rules = append(rules, rule)
+	}
+	c.PaymentMethods = rules
+}
+
+// FindPaymentMethod devuelve la regla del medio de pago, o nil si no está configurado
+func (c *SiteConfig) FindPaymentMethod(method PaymentMethod) *PaymentMethodRule {
+	for i := range c.PaymentMethods {
+		if c.PaymentMethods[i].Method == method {
+			return &c.PaymentMethods[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeShippingMethods carga los métodos de envío por defecto si no hay ninguno configurado
+func (c *SiteConfig) NormalizeShippingMethods() {
+	if len(c.ShippingMethods) == 0 {
+		c.ShippingMethods = DefaultShippingMethods()
+	}
+	for i := range c.ShippingMethods {
+		method := &c.ShippingMethods[i]
+		method.Code = ShippingMethodCode(strings.ToLower(strings.TrimSpace(string(method.Code))))
+		if method.Label == "" {
+			method.Label = string(method.Code)
+		}
+		if method.Zones == nil {
+			method.Zones = []ShippingZone{}
+		}
+	}
+}
+
+// FindShippingMethod devuelve el método de envío, o nil si no está configurado
+func (c *SiteConfig) FindShippingMethod(code ShippingMethodCode) *ShippingMethod {
+	for i := range c.ShippingMethods {
+		if c.ShippingMethods[i].Code == code {
+			return &c.ShippingMethods[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeOrderNumbering completa prefijo y dígitos del número de pedido con sus valores
+// por defecto y pasa el prefijo a mayúsculas
+func (c *SiteConfig) NormalizeOrderNumbering() {
+	c.OrderNumberPrefix = strings.ToUpper(strings.TrimSpace(c.OrderNumberPrefix))
+	if c.OrderNumberPrefix == "" {
+		c.OrderNumberPrefix = DefaultOrderNumberPrefix
+	}
+	if c.OrderNumberDigits == 0 {
+		c.OrderNumberDigits = DefaultOrderNumberDigits
+	}
+}
+
+// ValidateOrderNumbering verifica que el prefijo sea alfanumérico y la cantidad de dígitos razonable
+func (c *SiteConfig) ValidateOrderNumbering() error {
+	if len(c.OrderNumberPrefix) > 10 {
+		return fmt.Errorf("el prefijo del número de pedido no puede superar los 10 caracteres")
+	}
+	for _, r := range c.OrderNumberPrefix {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("el prefijo del número de pedido solo puede tener letras y números")
+		}
+	}
+	if c.OrderNumberDigits < 3 || c.OrderNumberDigits > 10 {
+		return fmt.Errorf("el número de pedido debe tener entre 3 y 10 dígitos")
+	}
+	return nil
+}
+
+// OrderNumbering devuelve el formato configurado para los números de pedido
+func (c *SiteConfig) OrderNumbering() OrderNumberFormat {
+	return OrderNumberFormat{Prefix: c.OrderNumberPrefix, Digits: c.OrderNumberDigits}
+}
+
+// NormalizeWhatsAppMessages completa las plantillas de WhatsApp vacías con las de por defecto
+func (c *SiteConfig) NormalizeWhatsAppMessages() {
+	if strings.TrimSpace(c.WhatsAppProductMessage) == "" {
+		c.WhatsAppProductMessage = DefaultWhatsAppProductMessage
+	}
+	if strings.TrimSpace(c.WhatsAppOrderMessage) == "" {
+		c.WhatsAppOrderMessage = DefaultWhatsAppOrderMessage
+	}
+}
+
+// ValidateWhatsAppMessages verifica que las plantillas solo usen los placeholders disponibles
+func (c *SiteConfig) ValidateWhatsAppMessages() error {
+	if err := ValidateWhatsAppMessage(c.WhatsAppProductMessage, WhatsAppProductPlaceholders); err != nil {
+		return err
+	}
+	return ValidateWhatsAppMessage(c.WhatsAppOrderMessage, WhatsAppOrderPlaceholders)
 }
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// StockAlertKind es el tipo de alerta de stock
+type StockAlertKind string
+
+const (
+	StockAlertLow        StockAlertKind = "low_stock"    // Quedó en el umbral o por debajo (LowStockThreshold)
+	StockAlertOutOfStock StockAlertKind = "out_of_stock" // Se agotó
+)
+
+// DefaultLowStockThreshold es el umbral de stock bajo de la configuración por defecto
+const DefaultLowStockThreshold = 5
+
+// StockAlertFor indica si un cambio de stock de before a after cruza el umbral y qué
+// alerta corresponde. Solo se alerta al cruzar, no mientras el stock siga bajo; un
+// umbral de 0 o menos solo alerta cuando se agota.
+func StockAlertFor(before, after, threshold int) (StockAlertKind, bool) {
+	if after <= 0 && before > 0 {
+		return StockAlertOutOfStock, true
+	}
+	if after > 0 && after <= threshold && before > threshold {
+		return StockAlertLow, true
+	}
+	return "", false
+}
+
+// StockAlertOnCreate indica qué alerta corresponde a un producto o una variante que se
+// crea con stock: la creación cuenta como un cruce (no había stock previo), así lo que se
+// carga agotado o con poco stock también alerta.
+func StockAlertOnCreate(stock, threshold int) (StockAlertKind, bool) {
+	return StockAlertFor(math.MaxInt, stock, threshold)
+}
+
+// StockAlert es un aviso para la tienda de que un producto (o una talla/color) quedó con
+// poco stock o se agotó. Se registra en la misma transacción que el movimiento de stock
+// y se envía por email a la tienda después, igual que los emails de los pedidos.
+type StockAlert struct {
+	ID          uint                    `json:"id"`
+	ProductID   uint                    `json:"product_id"`
+	VariantID   *uint                   `json:"variant_id"` // nil en productos sin variantes
+	ProductName string                  `json:"product_name"`
+	Talla       string                  `json:"talla"`
+	Color       string                  `json:"color"`
+	Kind        StockAlertKind          `json:"kind"`
+	Stock       int                     `json:"stock"`     // Stock luego del movimiento
+	Threshold   int                     `json:"threshold"` // Umbral vigente al generarse
+	EmailStatus OrderNotificationStatus `json:"email_status"`
+	Attempts    int                     `json:"attempts"`
+	LastError   string                  `json:"last_error"`
+	ReadAt      *time.Time              `json:"read_at"` // Marcada como vista en el panel
+	CreatedAt   time.Time               `json:"created_at"`
+	SentAt      *time.Time              `json:"sent_at"`
+}
+
+// LowStockItem es un producto (o una variante) con stock en el umbral o por debajo
+type LowStockItem struct {
+	ProductID   uint           `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	VariantID   *uint          `json:"variant_id"`
+	Talla       string         `json:"talla"`
+	Color       string         `json:"color"`
+	SKU         string         `json:"sku"`
+	Stock       int            `json:"stock"`
+	Status      StockAlertKind `json:"status"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// StockMovementReason define el motivo de un movimiento de stock
+type StockMovementReason string
+
+const (
+	StockMovementSale         StockMovementReason = "sale"          // Venta (pedido creado o reactivado)
+	StockMovementCancel       StockMovementReason = "cancel"        // Pedido cancelado o eliminado
+	StockMovementManualAdjust StockMovementReason = "manual_adjust" // Ajuste desde el panel de admin
+	StockMovementReturn       StockMovementReason = "return"        // Devolución de un cliente
+	StockMovementImport       StockMovementReason = "import"        // Carga inicial o migración
+	StockMovementOrderEdit    StockMovementReason = "order_edit"    // Cambio de productos o cantidades de un pedido
+	StockMovementExchange     StockMovementReason = "exchange"      // Producto entregado en un cambio (ej. otra talla)
+)
+
+// IsValid indica si el motivo es uno de los motivos conocidos
+func (r StockMovementReason) IsValid() bool {
+	switch r {
+	case StockMovementSale, StockMovementCancel, StockMovementManualAdjust, StockMovementReturn, StockMovementImport, StockMovementOrderEdit,
+		StockMovementExchange:
+		return true
+	}
+	return false
+}
+
+// StockMovement representa una entrada del ledger de inventario.
+// La suma de los Delta de un producto explica su stock actual.
+type StockMovement struct {
+	ID         uint                `json:"id"`
+	ProductID  uint                `json:"product_id"`
+	VariantID  *uint               `json:"variant_id"`
+	Talla      string              `json:"talla"` // Snapshot de la variante
+	Color      string              `json:"color"`
+	Delta      int                 `json:"delta"`
+	StockAfter int                 `json:"stock_after"` // Stock de la variante (o del producto) luego del movimiento
+	Reason     StockMovementReason `json:"reason"`
+	OrderID    *uint               `json:"order_id"`
+	UserID     *uint               `json:"user_id"`
+	CreatedBy  string              `json:"created_by"`
+	Note       string              `json:"note"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// NewStockMovement crea la plantilla de un movimiento con su motivo y quién lo realiza.
+// Los repositorios completan producto, variante, delta y stock resultante.
+func NewStockMovement(reason StockMovementReason, actor Actor, note string) StockMovement {
+	createdBy := actor.Username
+	if createdBy == "" {
+		createdBy = "sistema"
+	}
+
+	return StockMovement{
+		Reason:    reason,
+		UserID:    actor.UserID,
+		CreatedBy: createdBy,
+		Note:      note,
+	}
+}
+
+// ForOrder asocia el movimiento a un pedido
+func (m StockMovement) ForOrder(orderID uint) StockMovement {
+	m.OrderID = &orderID
+	return m
+}
+
+// StockMovementSummary agrupa los movimientos de un período por producto, variante y motivo
+type StockMovementSummary struct {
+	ProductID   uint                `json:"product_id"`
+	ProductName string              `json:"product_name"`
+	VariantID   *uint               `json:"variant_id"`
+	Talla       string              `json:"talla"`
+	Color       string              `json:"color"`
+	Reason      StockMovementReason `json:"reason"`
+	Movements   int                 `json:"movements"`
+	UnitsIn     int                 `json:"units_in"`
+	UnitsOut    int                 `json:"units_out"`
+	NetDelta    int                 `json:"net_delta"`
+}
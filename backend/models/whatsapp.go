@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Plantillas por defecto de los mensajes de WhatsApp (ver RenderWhatsAppMessage)
+const (
+	DefaultWhatsAppProductMessage = "Hola! Me interesa este producto:\n\n*{producto}*\nTalla: {talla}\nColor: {color}\nPrecio: {precio}\n{enlace}\n\n¿Está disponible?"
+	DefaultWhatsAppOrderMessage   = "Hola {cliente}! Te escribimos de {tienda} por tu pedido {pedido}.\nTotal: {total}\nEstado: {estado}\nSeguilo en: {seguimiento}"
+)
+
+// maxWhatsAppMessageLength limita el largo de las plantillas configurables
+const maxWhatsAppMessageLength = 1000
+
+// Placeholders disponibles en cada plantilla
+var (
+	WhatsAppProductPlaceholders = []string{"tienda", "producto", "talla", "color", "precio", "enlace"}
+	WhatsAppOrderPlaceholders   = []string{"tienda", "cliente", "pedido", "total", "saldo", "estado", "seguimiento"}
+)
+
+var whatsAppPlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// WhatsAppLink es un mensaje listo para abrir en WhatsApp
+type WhatsAppLink struct {
+	Phone   string `json:"phone"`   // Número internacional sin signos (ej. 5491155551234)
+	Message string `json:"message"` // Mensaje con los placeholders reemplazados
+	URL     string `json:"url"`     // https://wa.me/{phone}?text={message}
+}
+
+// NewWhatsAppLink arma el enlace wa.me con el mensaje codificado
+func NewWhatsAppLink(phone, message string) WhatsAppLink {
+	// QueryEscape codifica los espacios como "+", que WhatsApp muestra tal cual
+	text := strings.ReplaceAll(url.QueryEscape(message), "+", "%20")
+	return WhatsAppLink{
+		Phone:   phone,
+		Message: message,
+		URL:     "https://wa.me/" + phone + "?text=" + text,
+	}
+}
+
+// WhatsAppPhone convierte un teléfono argentino al formato internacional de celular que
+// usa wa.me (54 + 9 + característica + número). Devuelve vacío si el teléfono no es válido.
+func WhatsAppPhone(phone string) string {
+	n := NormalizePhone(phone)
+	if n == "" {
+		return ""
+	}
+	return "549" + n
+}
+
+// RenderWhatsAppMessage reemplaza los placeholders {nombre} de la plantilla por sus valores.
+// Las líneas con un placeholder sin valor (ej. {talla} si no se eligió talla) se omiten
+// completas, para no dejar "Talla: " vacío en el mensaje.
+func RenderWhatsAppMessage(template string, values map[string]string) string {
+	lines := strings.Split(template, "\n")
+	rendered := make([]string, 0, len(lines))
+	for _, line := range lines {
+		missing := false
+		line = whatsAppPlaceholder.ReplaceAllStringFunc(line, func(match string) string {
+			value := strings.TrimSpace(values[match[1:len(match)-1]])
+			if value == "" {
+				missing = true
+			}
+			return value
+		})
+		if !missing {
+			rendered = append(rendered, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(rendered, "\n"))
+}
+
+// ValidateWhatsAppMessage verifica el largo de la plantilla y que solo use los placeholders permitidos
+func ValidateWhatsAppMessage(template string, placeholders []string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("el mensaje de WhatsApp no puede estar vacío")
+	}
+	if len(template) > maxWhatsAppMessageLength {
+		return fmt.Errorf("el mensaje de WhatsApp no puede superar los %d caracteres", maxWhatsAppMessageLength)
+	}
+	for _, match := range whatsAppPlaceholder.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, name := range placeholders {
+			if match[1] == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("placeholder desconocido en el mensaje de WhatsApp: %s (disponibles: {%s})",
+				match[0], strings.Join(placeholders, "}, {"))
+		}
+	}
+	return nil
+}
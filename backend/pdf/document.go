@@ -0,0 +1,263 @@
+// Package pdf genera documentos PDF simples (texto, líneas, rectángulos e imágenes) sin
+// dependencias externas. Usa las fuentes estándar Helvetica y Helvetica-Bold, que todos
+// los lectores de PDF incluyen, con codificación WinAnsi (acentos y eñe del español).
+//
+// Las coordenadas se expresan en puntos (1/72 de pulgada) con origen en la esquina
+// superior izquierda de la página; en Text, y es la línea base del texto.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Tamaño de página A4 en puntos
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font es una de las fuentes estándar disponibles
+type Font int
+
+const (
+	Regular Font = iota // Helvetica
+	Bold                // Helvetica-Bold
+)
+
+// resourceName es el nombre de la fuente en los recursos de la página
+func (f Font) resourceName() string {
+	if f == Bold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// Document es un PDF en construcción
+type Document struct {
+	pages  []*bytes.Buffer
+	images [][]byte // Objetos XObject ya serializados (diccionario + stream)
+}
+
+// New crea un documento vacío; las operaciones de dibujo agregan la primera página si falta
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage agrega una página A4 en blanco; las operaciones siguientes dibujan sobre ella
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount devuelve la cantidad de páginas del documento
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text escribe s en (x, y) con la fuente y el tamaño indicados
+func (d *Document) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font.resourceName(), num(size), num(x), num(PageHeight-y), escape(encode(s)))
+}
+
+// TextRight escribe s alineado a la derecha, terminando en x
+func (d *Document) TextRight(x, y float64, font Font, size float64, s string) {
+	d.Text(x-TextWidth(s, font, size), y, font, size, s)
+}
+
+// Line dibuja una línea de (x1, y1) a (x2, y2) con el grosor y el gris indicados (0 = negro, 1 = blanco)
+func (d *Document) Line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(d.page(), "q %s G %s w %s %s m %s %s l S Q\n",
+		num(gray), num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// FillRect pinta un rectángulo con esquina superior izquierda en (x, y)
+func (d *Document) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.page(), "q %s g %s %s %s %s re f Q\n",
+		num(gray), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// StrokeRect dibuja el borde de un rectángulo con esquina superior izquierda en (x, y)
+func (d *Document) StrokeRect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.page(), "q %s w %s %s %s %s re S Q\n",
+		num(width), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// maxImageSide es el lado máximo en píxeles con que se guarda una imagen: las más
+// grandes se reducen para no inflar el PDF (un logo no necesita más resolución)
+const maxImageSide = 600
+
+// ImageID identifica una imagen agregada al documento (ver AddImage)
+type ImageID int
+
+// AddImage incorpora img al documento para dibujarla con DrawImage, en una o varias
+// páginas sin repetir los datos. La transparencia se mezcla con fondo blanco.
+func (d *Document) AddImage(img image.Image) (ImageID, error) {
+	bounds := img.Bounds()
+	step := 1
+	if side := max(bounds.Dx(), bounds.Dy()); side > maxImageSide {
+		step = (side + maxImageSide - 1) / maxImageSide
+	}
+	width := (bounds.Dx() + step - 1) / step
+	height := (bounds.Dy() + step - 1) / step
+
+	pixels := make([]byte, 0, width*height*3)
+	for py := bounds.Min.Y; py < bounds.Max.Y; py += step {
+		for px := bounds.Min.X; px < bounds.Max.X; px += step {
+			r, g, b, a := img.At(px, py).RGBA()
+			// Componer sobre blanco: color + (1 - alfa) * blanco
+			white := 0xffff - a
+			pixels = append(pixels, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	data, err := deflate(pixels)
+	if err != nil {
+		return 0, fmt.Errorf("error al comprimir imagen: %w", err)
+	}
+
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+		width, height, len(data))
+	obj.Write(data)
+	obj.WriteString("\nendstream")
+	d.images = append(d.images, obj.Bytes())
+	return ImageID(len(d.images)), nil
+}
+
+// DrawImage dibuja la imagen escalada al rectángulo con esquina superior izquierda en (x, y)
+func (d *Document) DrawImage(id ImageID, x, y, w, h float64) {
+	fmt.Fprintf(d.page(), "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		num(w), num(h), num(x), num(PageHeight-y-h), id)
+}
+
+// Bytes serializa el documento
+func (d *Document) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// Objetos: 1 catálogo, 2 árbol de páginas, 3-4 fuentes, luego imágenes y por cada
+	// página su objeto y su contenido
+	const firstImage = 5
+	firstPage := firstImage + len(d.images)
+	objects := make([][]byte, 0, firstPage-1+2*len(d.pages))
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	objects = append(objects,
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		[]byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))),
+		[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"),
+		[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"),
+	)
+	objects = append(objects, d.images...)
+
+	var xobjects strings.Builder
+	for i := range d.images {
+		fmt.Fprintf(&xobjects, " /Im%d %d 0 R", i+1, firstImage+i)
+	}
+	resources := "<< /Font << /F1 3 0 R /F2 4 0 R >>"
+	if xobjects.Len() > 0 {
+		resources += " /XObject <<" + xobjects.String() + " >>"
+	}
+	resources += " >>"
+
+	for i, content := range d.pages {
+		data, err := deflate(content.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("error al comprimir página: %w", err)
+		}
+		objects = append(objects, []byte(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), resources, firstPage+2*i+1)))
+
+		var stream bytes.Buffer
+		fmt.Fprintf(&stream, "<< /Length %d /Filter /FlateDecode >>\nstream\n", len(data))
+		stream.Write(data)
+		stream.WriteString("\nendstream")
+		objects = append(objects, stream.Bytes())
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes(), nil
+}
+
+// encode convierte el texto a WinAnsi (Windows-1252); los caracteres sin equivalente se reemplazan por "?"
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if b, ok := charmap.Windows1252.EncodeRune(r); ok {
+			out = append(out, b)
+		} else {
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// escape protege los caracteres especiales de un string literal de PDF
+func escape(s []byte) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n', '\r', '\t':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// num formatea un número para el PDF (sin notación exponencial ni ceros de más)
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
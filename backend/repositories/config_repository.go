@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"tiendaedgar/backend/models"
 )
@@ -21,17 +22,20 @@ func (r *ConfigRepository) GetConfig() (*models.SiteConfig, error) {
 	query := `
 		SELECT id, store_name, description, logo_url, whatsapp_number, whatsapp_message, 
 		       credit_card_surcharge, low_stock_threshold, enable_stock_alerts, enable_order_alerts, 
-		       created_at, updated_at
+		       payment_methods, shipping_methods, free_shipping_threshold, order_number_prefix, order_number_digits,
+		       whatsapp_product_message, whatsapp_order_message, created_at, updated_at
 		FROM site_configs
 		LIMIT 1
 	`
 	
 	var config models.SiteConfig
+	var paymentMethodsJSON, shippingMethodsJSON sql.NullString
 	err := r.db.QueryRow(query).Scan(
 		&config.ID, &config.StoreName, &config.Description, &config.LogoURL, 
 		&config.WhatsAppNumber, &config.WhatsAppMessage, &config.CreditCardSurcharge, 
 		&config.LowStockThreshold, &config.EnableStockAlerts, &config.EnableOrderAlerts,
-		&config.CreatedAt, &config.UpdatedAt,
+		&paymentMethodsJSON, &shippingMethodsJSON, &config.FreeShippingOver, &config.OrderNumberPrefix, &config.OrderNumberDigits,
+		&config.WhatsAppProductMessage, &config.WhatsAppOrderMessage, &config.CreatedAt, &config.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -41,14 +45,31 @@ func (r *ConfigRepository) GetConfig() (*models.SiteConfig, error) {
 		return nil, fmt.Errorf("error al obtener configuración: %w", err)
 	}
 
+	if paymentMethodsJSON.Valid && paymentMethodsJSON.String != "" {
+		if err := json.Unmarshal([]byte(paymentMethodsJSON.String), &config.PaymentMethods); err != nil {
+			return nil, fmt.Errorf("error al leer medios de pago: %w", err)
+		}
+	}
+	config.NormalizePaymentMethods()
+
+	if shippingMethodsJSON.Valid && shippingMethodsJSON.String != "" {
+		if err := json.Unmarshal([]byte(shippingMethodsJSON.String), &config.ShippingMethods); err != nil {
+			return nil, fmt.Errorf("error al leer métodos de envío: %w", err)
+		}
+	}
+	config.NormalizeShippingMethods()
+	config.NormalizeOrderNumbering()
+	config.NormalizeWhatsAppMessages()
+
 	return &config, nil
 }
 
 func (r *ConfigRepository) createDefaultConfig() (*models.SiteConfig, error) {
 	query := `
 		INSERT INTO site_configs (store_name, description, logo_url, whatsapp_number, whatsapp_message, 
-			credit_card_surcharge, low_stock_threshold, enable_stock_alerts, enable_order_alerts)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			credit_card_surcharge, low_stock_threshold, enable_stock_alerts, enable_order_alerts,
+			order_number_prefix, order_number_digits, whatsapp_product_message, whatsapp_order_message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING id, created_at, updated_at
 	`
 	
@@ -59,15 +80,21 @@ func (r *ConfigRepository) createDefaultConfig() (*models.SiteConfig, error) {
 		WhatsAppNumber:      "5491134567890",
 		WhatsAppMessage:     "Hola! Me interesa este producto...",
 		CreditCardSurcharge: 15.0,
-		LowStockThreshold:   5,
+		LowStockThreshold:   models.DefaultLowStockThreshold,
 		EnableStockAlerts:   true,
 		EnableOrderAlerts:   true,
 	}
+	defaultConfig.NormalizePaymentMethods()
+	defaultConfig.NormalizeShippingMethods()
+	defaultConfig.NormalizeOrderNumbering()
+	defaultConfig.NormalizeWhatsAppMessages()
 
 	err := r.db.QueryRow(query, 
 		defaultConfig.StoreName, defaultConfig.Description, defaultConfig.LogoURL,
 		defaultConfig.WhatsAppNumber, defaultConfig.WhatsAppMessage, defaultConfig.CreditCardSurcharge,
 		defaultConfig.LowStockThreshold, defaultConfig.EnableStockAlerts, defaultConfig.EnableOrderAlerts,
+		defaultConfig.OrderNumberPrefix, defaultConfig.OrderNumberDigits,
+		defaultConfig.WhatsAppProductMessage, defaultConfig.WhatsAppOrderMessage,
 	).Scan(&defaultConfig.ID, &defaultConfig.CreatedAt, &defaultConfig.UpdatedAt)
 
 	if err != nil {
@@ -78,18 +105,31 @@ func (r *ConfigRepository) createDefaultConfig() (*models.SiteConfig, error) {
 }
 
 func (r *ConfigRepository) UpdateConfig(config *models.SiteConfig) error {
+	paymentMethodsJSON, err := json.Marshal(config.PaymentMethods)
+	if err != nil {
+		return fmt.Errorf("error al serializar medios de pago: %w", err)
+	}
+	shippingMethodsJSON, err := json.Marshal(config.ShippingMethods)
+	if err != nil {
+		return fmt.Errorf("error al serializar métodos de envío: %w", err)
+	}
+
 	query := `
 		UPDATE site_configs 
 		SET store_name = ?, description = ?, logo_url = ?, whatsapp_number = ?, whatsapp_message = ?, 
 			credit_card_surcharge = ?, low_stock_threshold = ?, enable_stock_alerts = ?, enable_order_alerts = ?,
-			updated_at = CURRENT_TIMESTAMP
+			payment_methods = ?, shipping_methods = ?, free_shipping_threshold = ?,
+			order_number_prefix = ?, order_number_digits = ?,
+			whatsapp_product_message = ?, whatsapp_order_message = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
 	
-	_, err := r.db.Exec(query, 
+	_, err = r.db.Exec(query, 
 		config.StoreName, config.Description, config.LogoURL, config.WhatsAppNumber, config.WhatsAppMessage,
 		config.CreditCardSurcharge, config.LowStockThreshold, config.EnableStockAlerts, config.EnableOrderAlerts,
-		config.ID,
+		string(paymentMethodsJSON), string(shippingMethodsJSON), config.FreeShippingOver,
+		config.OrderNumberPrefix, config.OrderNumberDigits,
+		config.WhatsAppProductMessage, config.WhatsAppOrderMessage, config.ID,
 	)
 	
 	if err != nil {
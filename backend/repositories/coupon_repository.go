@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// CouponRepository maneja las operaciones de base de datos para cupones de descuento
+type CouponRepository struct {
+	db DBTX
+}
+
+// NewCouponRepository crea una nueva instancia del repositorio
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+const couponColumns = `id, code, description, type, value, min_purchase, starts_at, ends_at, usage_limit, usage_count, categories, product_ids, active, created_at, updated_at`
+
+// Create inserta un nuevo cupón
+func (r *CouponRepository) Create(coupon *models.Coupon) error {
+	categoriesJSON, productIDsJSON, err := marshalCouponRestrictions(coupon)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO coupons (code, description, type, value, min_purchase, starts_at, ends_at, usage_limit, usage_count, categories, product_ids, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?)
+	`, coupon.Code, coupon.Description, coupon.Type, coupon.Value, coupon.MinPurchase, coupon.StartsAt, coupon.EndsAt,
+		coupon.UsageLimit, categoriesJSON, productIDsJSON, coupon.Active, now, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return fmt.Errorf("ya existe un cupón con el código %s", coupon.Code)
+		}
+		return fmt.Errorf("error al crear cupón: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	coupon.ID = uint(id)
+	coupon.UsageCount = 0
+	coupon.CreatedAt = now
+	coupon.UpdatedAt = now
+	return nil
+}
+
+// GetAll obtiene todos los cupones (los más recientes primero)
+func (r *CouponRepository) GetAll() ([]models.Coupon, error) {
+	rows, err := r.db.Query("SELECT " + couponColumns + " FROM coupons ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cupones: %w", err)
+	}
+	defer rows.Close()
+
+	coupons := []models.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, *coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+// GetByID obtiene un cupón por su ID
+func (r *CouponRepository) GetByID(id uint) (*models.Coupon, error) {
+	coupon, err := scanCoupon(r.db.QueryRow("SELECT "+couponColumns+" FROM coupons WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return coupon, err
+}
+
+// GetByCode obtiene un cupón por su código (ya normalizado)
+func (r *CouponRepository) GetByCode(code string) (*models.Coupon, error) {
+	coupon, err := scanCoupon(r.db.QueryRow("SELECT "+couponColumns+" FROM coupons WHERE code = ?", code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return coupon, err
+}
+
+// Update actualiza la definición de un cupón (no modifica su contador de usos)
+func (r *CouponRepository) Update(coupon *models.Coupon) error {
+	categoriesJSON, productIDsJSON, err := marshalCouponRestrictions(coupon)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		UPDATE coupons
+		SET code = ?, description = ?, type = ?, value = ?, min_purchase = ?, starts_at = ?, ends_at = ?,
+		    usage_limit = ?, categories = ?, product_ids = ?, active = ?, updated_at = ?
+		WHERE id = ?
+	`, coupon.Code, coupon.Description, coupon.Type, coupon.Value, coupon.MinPurchase, coupon.StartsAt, coupon.EndsAt,
+		coupon.UsageLimit, categoriesJSON, productIDsJSON, coupon.Active, now, coupon.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return fmt.Errorf("ya existe un cupón con el código %s", coupon.Code)
+		}
+		return fmt.Errorf("error al actualizar cupón: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("cupón no encontrado")
+	}
+
+	coupon.UpdatedAt = now
+	return nil
+}
+
+// Delete elimina un cupón. Los pedidos conservan el código y el descuento aplicado.
+func (r *CouponRepository) Delete(id uint) error {
+	result, err := r.db.Exec("DELETE FROM coupons WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar cupón: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("cupón no encontrado")
+	}
+
+	return nil
+}
+
+// Redeem suma un uso al cupón. El UPDATE es condicional: si otro pedido tomó el
+// último uso disponible no se modifica nada y se devuelve error.
+func (r *CouponRepository) Redeem(code string) error {
+	result, err := r.db.Exec(`
+		UPDATE coupons SET usage_count = usage_count + 1, updated_at = ?
+		WHERE code = ? AND (usage_limit = 0 OR usage_count < usage_limit)
+	`, time.Now(), code)
+	if err != nil {
+		return fmt.Errorf("error al registrar uso del cupón: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("el cupón %s alcanzó su límite de usos", code)
+	}
+	return nil
+}
+
+// Release devuelve un uso al cupón (pedido cancelado o eliminado). Si el cupón
+// ya no existe no hace nada.
+func (r *CouponRepository) Release(code string) error {
+	_, err := r.db.Exec(`
+		UPDATE coupons SET usage_count = usage_count - 1, updated_at = ?
+		WHERE code = ? AND usage_count > 0
+	`, time.Now(), code)
+	if err != nil {
+		return fmt.Errorf("error al liberar uso del cupón: %w", err)
+	}
+	return nil
+}
+
+// rowScanner es la interfaz común de *sql.Row y *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCoupon lee un cupón de una fila con couponColumns
+func scanCoupon(row rowScanner) (*models.Coupon, error) {
+	var c models.Coupon
+	var description, categoriesJSON, productIDsJSON sql.NullString
+	var startsAt, endsAt sql.NullTime
+	err := row.Scan(&c.ID, &c.Code, &description, &c.Type, &c.Value, &c.MinPurchase, &startsAt, &endsAt,
+		&c.UsageLimit, &c.UsageCount, &categoriesJSON, &productIDsJSON, &c.Active, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error al escanear cupón: %w", err)
+	}
+
+	c.Description = description.String
+	if startsAt.Valid {
+		c.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		c.EndsAt = &endsAt.Time
+	}
+
+	c.Categories = []string{}
+	c.ProductIDs = []uint{}
+	if categoriesJSON.Valid && categoriesJSON.String != "" {
+		json.Unmarshal([]byte(categoriesJSON.String), &c.Categories)
+	}
+	if productIDsJSON.Valid && productIDsJSON.String != "" {
+		json.Unmarshal([]byte(productIDsJSON.String), &c.ProductIDs)
+	}
+
+	return &c, nil
+}
+
+// marshalCouponRestrictions serializa las restricciones del cupón como JSON
+func marshalCouponRestrictions(coupon *models.Coupon) (string, string, error) {
+	categoriesJSON, err := json.Marshal(coupon.Categories)
+	if err != nil {
+		return "", "", err
+	}
+	productIDsJSON, err := json.Marshal(coupon.ProductIDs)
+	if err != nil {
+		return "", "", err
+	}
+	return string(categoriesJSON), string(productIDsJSON), nil
+}
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// CustomerRepository maneja las operaciones de base de datos para clientes
+type CustomerRepository struct {
+	db DBTX
+}
+
+// NewCustomerRepository crea una nueva instancia del repositorio
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// customerStatsQuery devuelve los clientes con sus estadísticas de compra. La última compra
+// se toma del pedido más reciente (por ID) para leer created_at con su tipo de columna.
+const customerStatsQuery = `
+	SELECT c.id, c.name, c.email, c.phone, c.address, c.notes, c.created_at, c.updated_at,
+	       COUNT(o.id), COALESCE(SUM(o.total_amount - o.return_adjustment), 0), lo.created_at
+	FROM customers c
+	LEFT JOIN orders o ON o.customer_id = c.id AND o.status <> 'Cancelado'
+	LEFT JOIN orders lo ON lo.id = (
+		SELECT MAX(id) FROM orders WHERE customer_id = c.id AND status <> 'Cancelado'
+	)
+`
+
+// Create inserta un nuevo cliente
+func (r *CustomerRepository) Create(c *models.Customer) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO customers (name, email, phone, phone_normalized, address, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.Name, c.Email, c.Phone, models.NormalizePhone(c.Phone), c.Address, c.Notes, now, now)
+	if err != nil {
+		return fmt.Errorf("error al crear cliente: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	c.ID = uint(id)
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+// Update actualiza los datos de un cliente
+func (r *CustomerRepository) Update(c *models.Customer) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		UPDATE customers
+		SET name = ?, email = ?, phone = ?, phone_normalized = ?, address = ?, notes = ?, updated_at = ?
+		WHERE id = ?
+	`, c.Name, c.Email, c.Phone, models.NormalizePhone(c.Phone), c.Address, c.Notes, now, c.ID)
+	if err != nil {
+		return fmt.Errorf("error al actualizar cliente: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("cliente no encontrado")
+	}
+
+	c.UpdatedAt = now
+	return nil
+}
+
+// GetAll obtiene clientes con sus estadísticas, filtrando por nombre, email o teléfono.
+// Los que compraron más recientemente aparecen primero.
+func (r *CustomerRepository) GetAll(limit, offset int, search string) ([]models.Customer, int, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	if search != "" {
+		where += " AND (c.name LIKE ? OR c.email LIKE ? OR c.phone LIKE ?"
+		like := "%" + search + "%"
+		args = append(args, like, like, like)
+		if phone := models.NormalizePhone(search); phone != "" {
+			where += " OR c.phone_normalized = ?"
+			args = append(args, phone)
+		}
+		where += ")"
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM customers c"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error al contar clientes: %w", err)
+	}
+
+	query := customerStatsQuery + where + `
+		GROUP BY c.id
+		ORDER BY lo.id IS NULL, lo.id DESC, c.id DESC
+		LIMIT ? OFFSET ?`
+	rows, err := r.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error al obtener clientes: %w", err)
+	}
+	defer rows.Close()
+
+	customers := []models.Customer{}
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		customers = append(customers, *c)
+	}
+
+	return customers, total, rows.Err()
+}
+
+// GetByID obtiene un cliente con sus estadísticas
+func (r *CustomerRepository) GetByID(id uint) (*models.Customer, error) {
+	c, err := scanCustomer(r.db.QueryRow(customerStatsQuery+" WHERE c.id = ? GROUP BY c.id", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// FindByPhone busca un cliente por teléfono normalizado (ver models.NormalizePhone)
+func (r *CustomerRepository) FindByPhone(phone string) (*models.Customer, error) {
+	normalized := models.NormalizePhone(phone)
+	if normalized == "" {
+		return nil, nil
+	}
+	return r.findOne("SELECT id FROM customers WHERE phone_normalized = ? ORDER BY id LIMIT 1", normalized)
+}
+
+// FindByEmail busca un cliente por email normalizado
+func (r *CustomerRepository) FindByEmail(email string) (*models.Customer, error) {
+	normalized := models.NormalizeEmail(email)
+	if normalized == "" {
+		return nil, nil
+	}
+	return r.findOne("SELECT id FROM customers WHERE email = ? ORDER BY id LIMIT 1", normalized)
+}
+
+func (r *CustomerRepository) findOne(query string, arg interface{}) (*models.Customer, error) {
+	var id uint
+	err := r.db.QueryRow(query, arg).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar cliente: %w", err)
+	}
+	return r.GetByID(id)
+}
+
+// Merge pasa los pedidos del cliente source al cliente target y elimina source
+func (r *CustomerRepository) Merge(targetID, sourceID uint) error {
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("UPDATE orders SET customer_id = ? WHERE customer_id = ?", targetID, sourceID); err != nil {
+			return fmt.Errorf("error al reasignar pedidos: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM customers WHERE id = ?", sourceID); err != nil {
+			return fmt.Errorf("error al eliminar cliente duplicado: %w", err)
+		}
+		return nil
+	})
+}
+
+// scanCustomer lee un cliente con sus estadísticas (ver customerStatsQuery)
+func scanCustomer(row rowScanner) (*models.Customer, error) {
+	var c models.Customer
+	var stats models.CustomerStats
+	var email, phone, address, notes sql.NullString
+	var lastPurchase sql.NullTime
+	err := row.Scan(&c.ID, &c.Name, &email, &phone, &address, &notes, &c.CreatedAt, &c.UpdatedAt,
+		&stats.OrdersCount, &stats.LifetimeValue, &lastPurchase)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Email = email.String
+	c.Phone = phone.String
+	c.Address = address.String
+	c.Notes = notes.String
+	if lastPurchase.Valid {
+		stats.LastPurchaseAt = &lastPurchase.Time
+	}
+	stats.LifetimeValue = math.Round(stats.LifetimeValue*100) / 100
+	c.CustomerStats = &stats
+	return &c, nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// IdempotencyRepository guarda las respuestas de las peticiones con Idempotency-Key
+type IdempotencyRepository struct {
+	db DBTX
+}
+
+// NewIdempotencyRepository crea una nueva instancia del repositorio
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve toma la clave para una petición nueva. Si la clave ya existe (y no venció)
+// devuelve el registro existente y reserved = false; en ese caso no debe ejecutarse la
+// petición. Las claves anteriores a expiredBefore y las reservas sin terminar anteriores
+// a staleBefore (la petición original se interrumpió) se descartan.
+func (r *IdempotencyRepository) Reserve(key, scope, requestHash string, expiredBefore, staleBefore time.Time) (record *models.IdempotencyRecord, reserved bool, err error) {
+	err = runInTx(r.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`
+			DELETE FROM idempotency_keys
+			WHERE created_at < ? OR (status_code = 0 AND created_at < ?)
+		`, expiredBefore, staleBefore); err != nil {
+			return fmt.Errorf("error al limpiar claves de idempotencia: %w", err)
+		}
+
+		now := time.Now()
+		result, err := tx.Exec(`
+			INSERT INTO idempotency_keys (idempotency_key, scope, request_hash, created_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(scope, idempotency_key) DO NOTHING
+		`, key, scope, requestHash, now)
+		if err != nil {
+			return fmt.Errorf("error al reservar clave de idempotencia: %w", err)
+		}
+
+		if affected, _ := result.RowsAffected(); affected == 1 {
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			record = &models.IdempotencyRecord{ID: uint(id), Key: key, Scope: scope, RequestHash: requestHash, CreatedAt: now}
+			reserved = true
+			return nil
+		}
+
+		record = &models.IdempotencyRecord{}
+		var body []byte
+		err = tx.QueryRow(`
+			SELECT id, idempotency_key, scope, request_hash, status_code, content_type, response_body, created_at
+			FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?
+		`, scope, key).Scan(&record.ID, &record.Key, &record.Scope, &record.RequestHash, &record.StatusCode,
+			&record.ContentType, &body, &record.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("error al obtener clave de idempotencia: %w", err)
+		}
+		record.ResponseBody = body
+		return nil
+	})
+	return record, reserved, err
+}
+
+// Complete guarda la respuesta de la petición original
+func (r *IdempotencyRepository) Complete(id uint, statusCode int, contentType string, body []byte) error {
+	_, err := r.db.Exec(`
+		UPDATE idempotency_keys SET status_code = ?, content_type = ?, response_body = ? WHERE id = ?
+	`, statusCode, contentType, body, id)
+	if err != nil {
+		return fmt.Errorf("error al guardar respuesta idempotente: %w", err)
+	}
+	return nil
+}
+
+// Release libera la clave (la petición falló y puede reintentarse con la misma clave)
+func (r *IdempotencyRepository) Release(id uint) error {
+	_, err := r.db.Exec("DELETE FROM idempotency_keys WHERE id = ?", id)
+	return err
+}
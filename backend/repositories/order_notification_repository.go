@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// OrderNotificationRepository maneja la cola de emails de los pedidos
+type OrderNotificationRepository struct {
+	db DBTX
+}
+
+// NewOrderNotificationRepository crea una nueva instancia del repositorio
+func NewOrderNotificationRepository(db *sql.DB) *OrderNotificationRepository {
+	return &OrderNotificationRepository{db: db}
+}
+
+const orderNotificationColumns = `id, order_id, kind, status, attempts, last_error, created_at, sent_at`
+
+// Enqueue encola la notificación si el pedido todavía no tiene una de ese tipo
+func (r *OrderNotificationRepository) Enqueue(orderID uint, kind models.OrderNotificationKind) error {
+	_, err := r.db.Exec(`
+		INSERT INTO order_notifications (order_id, kind, status, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(order_id, kind) DO NOTHING
+	`, orderID, kind, models.OrderNotificationPending, time.Now())
+	if err != nil {
+		return fmt.Errorf("error al encolar notificación: %w", err)
+	}
+	return nil
+}
+
+// GetPending obtiene las notificaciones en cola, las más antiguas primero
+func (r *OrderNotificationRepository) GetPending(limit int) ([]models.OrderNotification, error) {
+	return r.query("SELECT "+orderNotificationColumns+" FROM order_notifications WHERE status = ? ORDER BY created_at ASC, id ASC LIMIT ?",
+		models.OrderNotificationPending, limit)
+}
+
+// GetByOrderID obtiene las notificaciones de un pedido
+func (r *OrderNotificationRepository) GetByOrderID(orderID uint) ([]models.OrderNotification, error) {
+	return r.query("SELECT "+orderNotificationColumns+" FROM order_notifications WHERE order_id = ? ORDER BY created_at ASC, id ASC", orderID)
+}
+
+// MarkSent registra el envío de la notificación
+func (r *OrderNotificationRepository) MarkSent(id uint) error {
+	_, err := r.db.Exec("UPDATE order_notifications SET status = ?, attempts = attempts + 1, last_error = '', sent_at = ? WHERE id = ?",
+		models.OrderNotificationSent, time.Now(), id)
+	return err
+}
+
+// MarkFailed registra un intento fallido; al llegar a MaxNotificationAttempts deja de reintentarse
+func (r *OrderNotificationRepository) MarkFailed(id uint, cause error) error {
+	_, err := r.db.Exec(`
+		UPDATE order_notifications
+		SET attempts = attempts + 1, last_error = ?,
+			status = CASE WHEN attempts + 1 >= ? THEN ? ELSE status END
+		WHERE id = ?
+	`, cause.Error(), models.MaxNotificationAttempts, models.OrderNotificationFailed, id)
+	return err
+}
+
+// MarkSkipped descarta la notificación sin enviarla, con el motivo
+func (r *OrderNotificationRepository) MarkSkipped(id uint, reason string) error {
+	_, err := r.db.Exec("UPDATE order_notifications SET status = ?, last_error = ? WHERE id = ?",
+		models.OrderNotificationSkipped, reason, id)
+	return err
+}
+
+func (r *OrderNotificationRepository) query(query string, args ...interface{}) ([]models.OrderNotification, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener notificaciones: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.OrderNotification{}
+	for rows.Next() {
+		var n models.OrderNotification
+		var sentAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.OrderID, &n.Kind, &n.Status, &n.Attempts, &n.LastError, &n.CreatedAt, &sentAt); err != nil {
+			return nil, fmt.Errorf("error al escanear notificación: %w", err)
+		}
+		if sentAt.Valid {
+			n.SentAt = &sentAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
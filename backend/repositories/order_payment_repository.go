@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// OrderPaymentRepository maneja el ledger de cobros y devoluciones de pedidos
+type OrderPaymentRepository struct {
+	db DBTX
+}
+
+// NewOrderPaymentRepository crea una nueva instancia del repositorio
+func NewOrderPaymentRepository(db *sql.DB) *OrderPaymentRepository {
+	return &OrderPaymentRepository{db: db}
+}
+
+// Create registra un cobro o una devolución
+func (r *OrderPaymentRepository) Create(p *models.OrderPayment) error {
+	now := time.Now()
+	if p.PaidAt.IsZero() {
+		p.PaidAt = now
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO order_payments (order_id, kind, amount, method, reference, note, paid_at, user_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.OrderID, p.Kind, p.Amount, p.Method, p.Reference, p.Note, p.PaidAt, p.UserID, p.CreatedBy, now)
+	if err != nil {
+		return fmt.Errorf("error al registrar pago: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	p.ID = uint(id)
+	p.CreatedAt = now
+	return nil
+}
+
+// GetByOrderID obtiene los pagos de un pedido ordenados cronológicamente
+func (r *OrderPaymentRepository) GetByOrderID(orderID uint) ([]models.OrderPayment, error) {
+	rows, err := r.db.Query(`
+		SELECT id, order_id, kind, amount, method, reference, note, paid_at, user_id, created_by, created_at
+		FROM order_payments
+		WHERE order_id = ?
+		ORDER BY paid_at ASC, id ASC
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pagos: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []models.OrderPayment{}
+	for rows.Next() {
+		var p models.OrderPayment
+		var userID sql.NullInt64
+		var createdBy, note sql.NullString
+		err := rows.Scan(&p.ID, &p.OrderID, &p.Kind, &p.Amount, &p.Method, &p.Reference, &note, &p.PaidAt, &userID, &createdBy, &p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear pago: %w", err)
+		}
+		p.UserID = nullableUint(userID)
+		p.CreatedBy = createdBy.String
+		p.Note = note.String
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+// ExistsByReference indica si ya se registró un pago con ese comprobante
+// (evita duplicar pagos cuando la pasarela reenvía la notificación)
+func (r *OrderPaymentRepository) ExistsByReference(orderID uint, kind models.OrderPaymentKind, reference string) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM order_payments WHERE order_id = ? AND kind = ? AND reference = ?
+	`, orderID, kind, reference).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("error al verificar pago: %w", err)
+	}
+	return count > 0, nil
+}
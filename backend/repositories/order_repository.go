@@ -9,7 +9,7 @@ import (
 
 // OrderRepository maneja las operaciones de base de datos para pedidos
 type OrderRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewOrderRepository crea una nueva instancia del repositorio
@@ -19,75 +19,121 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 
 // Create inserta un nuevo pedido y sus items en la base de datos dentro de una transacción
 func (r *OrderRepository) Create(order *models.Order) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-
-	// 1. Insertar orden
-	query := `
-		INSERT INTO orders (customer_name, customer_email, customer_phone, customer_address, total_amount, status, notes, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	res, err := tx.Exec(query,
-		order.CustomerName, order.CustomerEmail, order.CustomerPhone, order.CustomerAddress,
-		order.TotalAmount, order.Status, order.Notes, time.Now(), time.Now(),
-	)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error al insertar orden: %w", err)
-	}
-
-	orderID, err := res.LastInsertId()
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	order.ID = uint(orderID)
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		// 1. Insertar orden
+		query := `
+			INSERT INTO orders (order_number, reference, tracking_token, tracking_number, customer_id, customer_name, customer_email, customer_phone, customer_address, shipping_method, shipping_postal_code, shipping_province, shipping_cost, payment_method, installments, subtotal, coupon_code, discount, surcharge, total_amount, status, notes, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`
+		now := time.Now()
+		res, err := tx.Exec(query,
+			order.OrderNumber, order.Reference, order.TrackingToken, order.TrackingNumber, order.CustomerID, order.CustomerName, order.CustomerEmail, order.CustomerPhone, order.CustomerAddress,
+			order.ShippingMethod, order.ShippingPostalCode, order.ShippingProvince, order.ShippingCost,
+			order.PaymentMethod, order.Installments, order.Subtotal, order.CouponCode, order.Discount, order.Surcharge, order.TotalAmount, order.Status, order.Notes, now, now,
+		)
+		if err != nil {
+			return fmt.Errorf("error al insertar orden: %w", err)
+		}
 
-	// 2. Insertar items
-	itemQuery := `
-		INSERT INTO order_items (order_id, product_id, product_name, quantity, unit_price, subtotal)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`
-	stmt, err := tx.Prepare(itemQuery)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	defer stmt.Close()
+		orderID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		order.ID = uint(orderID)
+		order.CreatedAt = now
+		order.UpdatedAt = now
 
-	for _, item := range order.Items {
-		_, err := stmt.Exec(orderID, item.ProductID, item.ProductName, item.Quantity, item.UnitPrice, item.Subtotal)
+		// 2. Insertar items
+		itemQuery := `
+			INSERT INTO order_items (order_id, product_id, variant_id, talla, color, product_name, quantity, unit_price, subtotal)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`
+		stmt, err := tx.Prepare(itemQuery)
 		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("error al insertar item del pedido: %w", err)
+			return err
 		}
-	}
+		defer stmt.Close()
 
-	// Commit transacción
-	if err := tx.Commit(); err != nil {
-		return err
-	}
+		for i := range order.Items {
+			item := &order.Items[i]
+			res, err := stmt.Exec(orderID, item.ProductID, item.VariantID, item.Talla, item.Color, item.ProductName, item.Quantity, item.UnitPrice, item.Subtotal)
+			if err != nil {
+				return fmt.Errorf("error al insertar item del pedido: %w", err)
+			}
+			itemID, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			item.ID = uint(itemID)
+			item.OrderID = order.ID
+		}
 
-	return nil
+		return nil
+	})
 }
 
-// GetAll obtiene todos los pedidos con filtros y paginación
-func (r *OrderRepository) GetAll(limit, offset int, status string, search string) ([]models.Order, int, error) {
+// GetAll obtiene los pedidos que cumplen el filtro, ordenados y paginados
+func (r *OrderRepository) GetAll(filter models.OrderFilter, limit, offset int) ([]models.Order, int, error) {
 	// Construir query base
 	baseQuery := "FROM orders WHERE 1=1"
 	args := []interface{}{}
 
-	if status != "" {
-		baseQuery += " AND status = ?"
-		args = append(args, status)
+	if len(filter.Statuses) > 0 {
+		baseQuery += " AND status IN ("
+		for i, status := range filter.Statuses {
+			if i > 0 {
+				baseQuery += ", "
+			}
+			baseQuery += "?"
+			args = append(args, status)
+		}
+		baseQuery += ")"
+	}
+
+	if len(filter.PaymentMethods) > 0 {
+		baseQuery += " AND payment_method IN ("
+		for i, method := range filter.PaymentMethods {
+			if i > 0 {
+				baseQuery += ", "
+			}
+			baseQuery += "?"
+			args = append(args, method)
+		}
+		baseQuery += ")"
+	}
+
+	if filter.From != nil {
+		baseQuery += " AND created_at >= ?"
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		baseQuery += " AND created_at < ?"
+		args = append(args, *filter.To)
+	}
+
+	if filter.MinTotal != nil {
+		baseQuery += " AND total_amount >= ?"
+		args = append(args, *filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		baseQuery += " AND total_amount <= ?"
+		args = append(args, *filter.MaxTotal)
+	}
+
+	if filter.ProductID != 0 {
+		baseQuery += " AND EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = orders.id AND i.product_id = ?)"
+		args = append(args, filter.ProductID)
+	}
+
+	if filter.CustomerID != 0 {
+		baseQuery += " AND customer_id = ?"
+		args = append(args, filter.CustomerID)
 	}
 
-	if search != "" {
-		baseQuery += " AND (customer_name LIKE ? OR customer_email LIKE ? OR id LIKE ?)"
-		likeSearch := "%" + search + "%"
-		args = append(args, likeSearch, likeSearch, likeSearch)
+	if filter.Search != "" {
+		baseQuery += " AND (customer_name LIKE ? OR customer_email LIKE ? OR order_number LIKE ? OR reference LIKE ?)"
+		likeSearch := "%" + filter.Search + "%"
+		args = append(args, likeSearch, likeSearch, likeSearch, likeSearch)
 	}
 
 	// Contar total
@@ -99,7 +145,18 @@ func (r *OrderRepository) GetAll(limit, offset int, status string, search string
 	}
 
 	// Obtener resultados paginados
-	query := "SELECT id, customer_name, customer_email, customer_phone, total_amount, status, created_at " + baseQuery + " ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	// Determinar ordenamiento (el id desempata pedidos creados en el mismo instante)
+	orderBy := "created_at DESC, id DESC"
+	switch filter.Sort {
+	case models.OrderSortOldest:
+		orderBy = "created_at ASC, id ASC"
+	case models.OrderSortTotalDesc:
+		orderBy = "total_amount DESC, id DESC"
+	case models.OrderSortTotalAsc:
+		orderBy = "total_amount ASC, id ASC"
+	}
+
+	query := "SELECT id, order_number, reference, customer_name, customer_email, customer_phone, payment_method, installments, total_amount, status, created_at " + baseQuery + " ORDER BY " + orderBy + " LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(query, args...)
@@ -112,7 +169,7 @@ func (r *OrderRepository) GetAll(limit, offset int, status string, search string
 	for rows.Next() {
 		var o models.Order
 		// Nota: Escaneamos solo los campos necesarios para la lista
-		if err := rows.Scan(&o.ID, &o.CustomerName, &o.CustomerEmail, &o.CustomerPhone, &o.TotalAmount, &o.Status, &o.CreatedAt); err != nil {
+		if err := rows.Scan(&o.ID, &o.OrderNumber, &o.Reference, &o.CustomerName, &o.CustomerEmail, &o.CustomerPhone, &o.PaymentMethod, &o.Installments, &o.TotalAmount, &o.Status, &o.CreatedAt); err != nil {
 			return nil, 0, err
 		}
 		orders = append(orders, o)
@@ -121,41 +178,219 @@ func (r *OrderRepository) GetAll(limit, offset int, status string, search string
 	return orders, total, nil
 }
 
+// GetByCustomerID obtiene los pedidos de un cliente, los más recientes primero (sin items)
+func (r *OrderRepository) GetByCustomerID(customerID uint) ([]models.Order, error) {
+	rows, err := r.db.Query(`
+		SELECT id, order_number, reference, customer_name, customer_email, customer_phone, payment_method, installments, total_amount, return_adjustment, status, created_at
+		FROM orders WHERE customer_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		o := models.Order{CustomerID: &customerID}
+		if err := rows.Scan(&o.ID, &o.OrderNumber, &o.Reference, &o.CustomerName, &o.CustomerEmail, &o.CustomerPhone, &o.PaymentMethod, &o.Installments, &o.TotalAmount, &o.ReturnAdjustment, &o.Status, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	return orders, rows.Err()
+}
+
 // GetByID obtiene un pedido por su ID incluyendo sus items
 func (r *OrderRepository) GetByID(id uint) (*models.Order, error) {
 	var o models.Order
 	query := `
-		SELECT id, customer_name, customer_email, customer_phone, customer_address, total_amount, status, notes, created_at, updated_at
+		SELECT id, order_number, reference, tracking_token, tracking_number, customer_id, customer_name, customer_email, customer_phone, customer_address, shipping_method, shipping_postal_code, shipping_province, shipping_cost,
+		       payment_method, installments, subtotal, coupon_code, discount, surcharge, total_amount, return_adjustment, status, notes, created_at, updated_at
 		FROM orders WHERE id = ?
 	`
+	var customerID sql.NullInt64
 	err := r.db.QueryRow(query, id).Scan(
-		&o.ID, &o.CustomerName, &o.CustomerEmail, &o.CustomerPhone, &o.CustomerAddress,
-		&o.TotalAmount, &o.Status, &o.Notes, &o.CreatedAt, &o.UpdatedAt,
+		&o.ID, &o.OrderNumber, &o.Reference, &o.TrackingToken, &o.TrackingNumber, &customerID, &o.CustomerName, &o.CustomerEmail, &o.CustomerPhone, &o.CustomerAddress,
+		&o.ShippingMethod, &o.ShippingPostalCode, &o.ShippingProvince, &o.ShippingCost,
+		&o.PaymentMethod, &o.Installments, &o.Subtotal, &o.CouponCode, &o.Discount, &o.Surcharge, &o.TotalAmount, &o.ReturnAdjustment, &o.Status, &o.Notes, &o.CreatedAt, &o.UpdatedAt,
 	)
+	if err == sql.ErrNoRows {
+		return nil, nil // Pedido no encontrado
+	}
 	if err != nil {
 		return nil, err
 	}
+	if customerID.Valid {
+		cid := uint(customerID.Int64)
+		o.CustomerID = &cid
+	}
 
 	// Obtener items
-	itemsQuery := `
-		SELECT id, order_id, product_id, product_name, quantity, unit_price, subtotal
-		FROM order_items WHERE order_id = ?
-	`
-	rows, err := r.db.Query(itemsQuery, id)
+	items, err := r.itemsByOrder([]uint{id})
+	if err != nil {
+		return nil, err
+	}
+	o.Items = items[id]
+
+	return &o, nil
+}
+
+// AttachItems carga los items de todos los pedidos recibidos con una única consulta
+// (para listados, en lugar de una consulta por pedido)
+func (r *OrderRepository) AttachItems(orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].ID
+	}
+
+	items, err := r.itemsByOrder(ids)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		orders[i].Items = items[orders[i].ID]
+		if orders[i].Items == nil {
+			orders[i].Items = []models.OrderItem{}
+		}
+	}
+	return nil
+}
+
+// itemsByOrder obtiene los items de los pedidos indicados, agrupados por pedido
+func (r *OrderRepository) itemsByOrder(orderIDs []uint) (map[uint][]models.OrderItem, error) {
+	query := `
+		SELECT id, order_id, product_id, variant_id, talla, color, product_name, quantity, unit_price, subtotal
+		FROM order_items WHERE order_id IN (`
+	args := make([]interface{}, len(orderIDs))
+	for i, id := range orderIDs {
+		if i > 0 {
+			query += ", "
+		}
+		query += "?"
+		args[i] = id
+	}
+	query += ") ORDER BY order_id, id"
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	items := map[uint][]models.OrderItem{}
 	for rows.Next() {
 		var item models.OrderItem
-		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitPrice, &item.Subtotal); err != nil {
+		var variantID sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &variantID, &item.Talla, &item.Color, &item.ProductName, &item.Quantity, &item.UnitPrice, &item.Subtotal); err != nil {
 			return nil, err
 		}
-		o.Items = append(o.Items, item)
+		if variantID.Valid {
+			id := uint(variantID.Int64)
+			item.VariantID = &id
+		}
+		items[item.OrderID] = append(items[item.OrderID], item)
 	}
 
-	return &o, nil
+	return items, rows.Err()
+}
+
+// GetByReference obtiene un pedido por su referencia pública (ej. "7KQ2-M9XD")
+func (r *OrderRepository) GetByReference(reference string) (*models.Order, error) {
+	var id uint
+	err := r.db.QueryRow("SELECT id FROM orders WHERE reference = ?", reference).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// GetByOrderNumber obtiene un pedido por su número (ej. "CS-2026-000123")
+func (r *OrderRepository) GetByOrderNumber(number string) (*models.Order, error) {
+	var id uint
+	err := r.db.QueryRow("SELECT id FROM orders WHERE order_number = ?", number).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// GetByTrackingToken obtiene un pedido por el token de su seguimiento público
+func (r *OrderRepository) GetByTrackingToken(token string) (*models.Order, error) {
+	var id uint
+	err := r.db.QueryRow("SELECT id FROM orders WHERE tracking_token = ?", token).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// UpdateTrackingToken reemplaza el token del seguimiento público (el anterior deja de funcionar)
+func (r *OrderRepository) UpdateTrackingToken(id uint, token string) error {
+	_, err := r.db.Exec("UPDATE orders SET tracking_token = ?, updated_at = ? WHERE id = ?", token, time.Now(), id)
+	return err
+}
+
+// UpdateTrackingNumber guarda el número de seguimiento del envío que ve el cliente
+func (r *OrderRepository) UpdateTrackingNumber(id uint, trackingNumber string) error {
+	_, err := r.db.Exec("UPDATE orders SET tracking_number = ?, updated_at = ? WHERE id = ?", trackingNumber, time.Now(), id)
+	return err
+}
+
+// NextOrderNumber reserva el siguiente secuencial del año. El incremento es atómico
+// (un único INSERT ... ON CONFLICT): debe usarse dentro de la transacción que crea el
+// pedido para que un rollback no deje huecos en la numeración.
+func (r *OrderRepository) NextOrderNumber(year int) (int, error) {
+	var seq int
+	err := r.db.QueryRow(`
+		INSERT INTO order_sequences (year, last_number) VALUES (?, 1)
+		ON CONFLICT(year) DO UPDATE SET last_number = last_number + 1
+		RETURNING last_number
+	`, year).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("error al generar número de pedido: %w", err)
+	}
+	return seq, nil
+}
+
+// GetStalePendingIDs devuelve los pedidos Pendiente creados por createdBy antes de before que
+// siguen como se crearon: sin otros cambios de estado ni pagos registrados
+func (r *OrderRepository) GetStalePendingIDs(createdBy string, before time.Time) ([]uint, error) {
+	rows, err := r.db.Query(`
+		SELECT o.id FROM orders o
+		JOIN order_status_history h ON h.order_id = o.id AND h.from_status = ''
+		WHERE o.status = ? AND h.changed_by = ? AND h.created_at < ?
+		  AND NOT EXISTS (SELECT 1 FROM order_status_history c WHERE c.order_id = o.id AND c.id <> h.id)
+		  AND NOT EXISTS (SELECT 1 FROM order_payments p WHERE p.order_id = o.id)
+		ORDER BY o.id
+	`, models.OrderStatusPending, createdBy, before)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar pedidos pendientes vencidos: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
 
 // UpdateStatus actualiza el estado de un pedido
@@ -169,15 +404,106 @@ func (r *OrderRepository) UpdateStatus(id uint, status models.OrderStatus) error
 func (r *OrderRepository) Update(order *models.Order) error {
 	query := `
 		UPDATE orders 
-		SET customer_name = ?, customer_email = ?, customer_phone = ?, customer_address = ?, notes = ?, updated_at = ?
+		SET customer_id = ?, customer_name = ?, customer_email = ?, customer_phone = ?, customer_address = ?, tracking_number = ?, notes = ?, updated_at = ?
 		WHERE id = ?
 	`
 	_, err := r.db.Exec(query,
-		order.CustomerName, order.CustomerEmail, order.CustomerPhone, order.CustomerAddress, order.Notes, time.Now(), order.ID,
+		order.CustomerID, order.CustomerName, order.CustomerEmail, order.CustomerPhone, order.CustomerAddress, order.TrackingNumber, order.Notes, time.Now(), order.ID,
 	)
 	return err
 }
 
+// ReplaceItems reemplaza los items del pedido por order.Items y guarda los importes
+// recalculados (subtotal, descuento, recargo, envío y total). Las líneas que siguen en el
+// pedido (misma variante, o mismo producto sin variantes) se actualizan en el lugar y
+// conservan su ID, que referencian las devoluciones; solo se insertan las líneas nuevas
+// y se eliminan las quitadas.
+func (r *OrderRepository) ReplaceItems(order *models.Order) error {
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query("SELECT id, product_id, variant_id FROM order_items WHERE order_id = ? ORDER BY id", order.ID)
+		if err != nil {
+			return fmt.Errorf("error al obtener items del pedido: %w", err)
+		}
+		existing := map[string][]uint{}
+		for rows.Next() {
+			var current models.OrderItem
+			var variantID sql.NullInt64
+			if err := rows.Scan(&current.ID, &current.ProductID, &variantID); err != nil {
+				rows.Close()
+				return err
+			}
+			if variantID.Valid {
+				id := uint(variantID.Int64)
+				current.VariantID = &id
+			}
+			existing[current.StockKey()] = append(existing[current.StockKey()], current.ID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i := range order.Items {
+			item := &order.Items[i]
+			item.OrderID = order.ID
+
+			if ids := existing[item.StockKey()]; len(ids) > 0 {
+				item.ID = ids[0]
+				existing[item.StockKey()] = ids[1:]
+				_, err := tx.Exec(`
+					UPDATE order_items
+					SET product_id = ?, variant_id = ?, talla = ?, color = ?, product_name = ?, quantity = ?, unit_price = ?, subtotal = ?
+					WHERE id = ?
+				`, item.ProductID, item.VariantID, item.Talla, item.Color, item.ProductName, item.Quantity, item.UnitPrice, item.Subtotal, item.ID)
+				if err != nil {
+					return fmt.Errorf("error al actualizar item del pedido: %w", err)
+				}
+				continue
+			}
+
+			res, err := tx.Exec(`
+				INSERT INTO order_items (order_id, product_id, variant_id, talla, color, product_name, quantity, unit_price, subtotal)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, order.ID, item.ProductID, item.VariantID, item.Talla, item.Color, item.ProductName, item.Quantity, item.UnitPrice, item.Subtotal)
+			if err != nil {
+				return fmt.Errorf("error al insertar item del pedido: %w", err)
+			}
+			itemID, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			item.ID = uint(itemID)
+		}
+
+		// Las líneas que quedaron sin usar fueron quitadas del pedido
+		for _, ids := range existing {
+			for _, id := range ids {
+				if _, err := tx.Exec("DELETE FROM order_items WHERE id = ?", id); err != nil {
+					return fmt.Errorf("error al eliminar item del pedido: %w", err)
+				}
+			}
+		}
+
+		now := time.Now()
+		_, err = tx.Exec(`
+			UPDATE orders
+			SET subtotal = ?, discount = ?, surcharge = ?, shipping_cost = ?, total_amount = ?, updated_at = ?
+			WHERE id = ?
+		`, order.Subtotal, order.Discount, order.Surcharge, order.ShippingCost, order.TotalAmount, now, order.ID)
+		if err != nil {
+			return fmt.Errorf("error al actualizar importes del pedido: %w", err)
+		}
+		order.UpdatedAt = now
+		return nil
+	})
+}
+
+// AddReturnAdjustment suma al ajuste por devoluciones del pedido (ver Order.AmountDue)
+func (r *OrderRepository) AddReturnAdjustment(id uint, amount float64) error {
+	_, err := r.db.Exec("UPDATE orders SET return_adjustment = return_adjustment + ?, updated_at = ? WHERE id = ?", amount, time.Now(), id)
+	return err
+}
+
 // Delete elimina un pedido y sus items (cascade automático con foreign_keys=ON)
 func (r *OrderRepository) Delete(id uint) error {
 	result, err := r.db.Exec("DELETE FROM orders WHERE id = ?", id)
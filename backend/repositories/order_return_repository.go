@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// OrderReturnRepository maneja las devoluciones y cambios de pedidos
+type OrderReturnRepository struct {
+	db DBTX
+}
+
+// NewOrderReturnRepository crea una nueva instancia del repositorio
+func NewOrderReturnRepository(db *sql.DB) *OrderReturnRepository {
+	return &OrderReturnRepository{db: db}
+}
+
+// Create registra una devolución o un cambio
+func (r *OrderReturnRepository) Create(ret *models.OrderReturn) error {
+	itemsJSON, err := json.Marshal(ret.Items)
+	if err != nil {
+		return fmt.Errorf("error al serializar productos devueltos: %w", err)
+	}
+	if ret.Replacements == nil {
+		ret.Replacements = []models.ReturnReplacement{}
+	}
+	replacementsJSON, err := json.Marshal(ret.Replacements)
+	if err != nil {
+		return fmt.Errorf("error al serializar productos de reemplazo: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO order_returns (order_id, resolution, items, replacements, returned_amount, replacement_amount, difference,
+			store_credit_code, refund_payment_id, note, user_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, ret.OrderID, ret.Resolution, string(itemsJSON), string(replacementsJSON), ret.ReturnedAmount, ret.ReplacementAmount, ret.Difference,
+		ret.StoreCreditCode, ret.RefundPaymentID, ret.Note, ret.UserID, ret.CreatedBy, now)
+	if err != nil {
+		return fmt.Errorf("error al registrar devolución: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	ret.ID = uint(id)
+	ret.CreatedAt = now
+	return nil
+}
+
+// GetByOrderID obtiene las devoluciones y cambios de un pedido ordenados cronológicamente
+func (r *OrderReturnRepository) GetByOrderID(orderID uint) ([]models.OrderReturn, error) {
+	rows, err := r.db.Query(`
+		SELECT id, order_id, resolution, items, replacements, returned_amount, replacement_amount, difference,
+		       store_credit_code, refund_payment_id, note, user_id, created_by, created_at
+		FROM order_returns
+		WHERE order_id = ?
+		ORDER BY created_at ASC, id ASC
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener devoluciones: %w", err)
+	}
+	defer rows.Close()
+
+	returns := []models.OrderReturn{}
+	for rows.Next() {
+		var ret models.OrderReturn
+		var itemsJSON, replacementsJSON string
+		var refundPaymentID, userID sql.NullInt64
+		var note, createdBy sql.NullString
+		if err := rows.Scan(&ret.ID, &ret.OrderID, &ret.Resolution, &itemsJSON, &replacementsJSON, &ret.ReturnedAmount,
+			&ret.ReplacementAmount, &ret.Difference, &ret.StoreCreditCode, &refundPaymentID, &note, &userID, &createdBy, &ret.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error al escanear devolución: %w", err)
+		}
+		if err := json.Unmarshal([]byte(itemsJSON), &ret.Items); err != nil {
+			return nil, fmt.Errorf("error al leer productos devueltos de la devolución %d: %w", ret.ID, err)
+		}
+		if err := json.Unmarshal([]byte(replacementsJSON), &ret.Replacements); err != nil {
+			return nil, fmt.Errorf("error al leer productos de reemplazo de la devolución %d: %w", ret.ID, err)
+		}
+		if refundPaymentID.Valid {
+			id := uint(refundPaymentID.Int64)
+			ret.RefundPaymentID = &id
+		}
+		if userID.Valid {
+			id := uint(userID.Int64)
+			ret.UserID = &id
+		}
+		ret.Note = note.String
+		ret.CreatedBy = createdBy.String
+		returns = append(returns, ret)
+	}
+
+	return returns, rows.Err()
+}
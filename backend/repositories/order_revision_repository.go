@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// OrderRevisionRepository maneja la auditoría de cambios de productos de pedidos
+type OrderRevisionRepository struct {
+	db DBTX
+}
+
+// NewOrderRevisionRepository crea una nueva instancia del repositorio
+func NewOrderRevisionRepository(db *sql.DB) *OrderRevisionRepository {
+	return &OrderRevisionRepository{db: db}
+}
+
+// Create registra una modificación de los items de un pedido
+func (r *OrderRevisionRepository) Create(rev *models.OrderRevision) error {
+	changesJSON, err := json.Marshal(rev.Changes)
+	if err != nil {
+		return fmt.Errorf("error al serializar cambios del pedido: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO order_revisions (order_id, changes, total_before, total_after, user_id, changed_by, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, rev.OrderID, string(changesJSON), rev.TotalBefore, rev.TotalAfter, rev.UserID, rev.ChangedBy, rev.Note, now)
+	if err != nil {
+		return fmt.Errorf("error al registrar cambios del pedido: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	rev.ID = uint(id)
+	rev.CreatedAt = now
+	return nil
+}
+
+// GetByOrderID obtiene las modificaciones de un pedido ordenadas cronológicamente
+func (r *OrderRevisionRepository) GetByOrderID(orderID uint) ([]models.OrderRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT id, order_id, changes, total_before, total_after, user_id, changed_by, note, created_at
+		FROM order_revisions
+		WHERE order_id = ?
+		ORDER BY created_at ASC, id ASC
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cambios del pedido: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.OrderRevision{}
+	for rows.Next() {
+		var rev models.OrderRevision
+		var changesJSON string
+		var userID sql.NullInt64
+		var changedBy, note sql.NullString
+		if err := rows.Scan(&rev.ID, &rev.OrderID, &changesJSON, &rev.TotalBefore, &rev.TotalAfter, &userID, &changedBy, &note, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error al escanear cambios del pedido: %w", err)
+		}
+		if err := json.Unmarshal([]byte(changesJSON), &rev.Changes); err != nil {
+			return nil, fmt.Errorf("error al leer cambios del pedido %d: %w", orderID, err)
+		}
+		if userID.Valid {
+			id := uint(userID.Int64)
+			rev.UserID = &id
+		}
+		rev.ChangedBy = changedBy.String
+		rev.Note = note.String
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
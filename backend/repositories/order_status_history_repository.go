@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// OrderStatusHistoryRepository maneja el historial de estados de pedidos
+type OrderStatusHistoryRepository struct {
+	db DBTX
+}
+
+// NewOrderStatusHistoryRepository crea una nueva instancia del repositorio
+func NewOrderStatusHistoryRepository(db *sql.DB) *OrderStatusHistoryRepository {
+	return &OrderStatusHistoryRepository{db: db}
+}
+
+// Create registra un cambio de estado
+func (r *OrderStatusHistoryRepository) Create(entry *models.OrderStatusHistory) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO order_status_history (order_id, from_status, to_status, user_id, changed_by, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, entry.OrderID, entry.FromStatus, entry.ToStatus, entry.UserID, entry.ChangedBy, entry.Note, now)
+	if err != nil {
+		return fmt.Errorf("error al registrar historial de estado: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = uint(id)
+	entry.CreatedAt = now
+	return nil
+}
+
+// GetByOrderID obtiene el historial de un pedido ordenado cronológicamente
+func (r *OrderStatusHistoryRepository) GetByOrderID(orderID uint) ([]models.OrderStatusHistory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, order_id, from_status, to_status, user_id, changed_by, note, created_at
+		FROM order_status_history
+		WHERE order_id = ?
+		ORDER BY created_at ASC, id ASC
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener historial: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.OrderStatusHistory{}
+	for rows.Next() {
+		var h models.OrderStatusHistory
+		var userID sql.NullInt64
+		var changedBy, note sql.NullString
+		if err := rows.Scan(&h.ID, &h.OrderID, &h.FromStatus, &h.ToStatus, &userID, &changedBy, &note, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error al escanear historial: %w", err)
+		}
+		if userID.Valid {
+			id := uint(userID.Int64)
+			h.UserID = &id
+		}
+		h.ChangedBy = changedBy.String
+		h.Note = note.String
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,9 +11,13 @@ import (
 	"tiendaedgar/backend/models"
 )
 
+// ErrStockManagedByVariants indica un cambio directo del stock de un producto con
+// variantes, cuyo stock total es la suma de las variantes
+var ErrStockManagedByVariants = errors.New("el stock de un producto con variantes se modifica desde sus variantes")
+
 // ProductRepository maneja el acceso a datos de productos
 type ProductRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewProductRepository crea una nueva instancia del repositorio
@@ -22,8 +27,8 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	}
 }
 
-// Create inserta un nuevo producto en la base de datos
-func (r *ProductRepository) Create(product *models.Product) error {
+// Create inserta un nuevo producto en la base de datos y registra su stock inicial en el ledger
+func (r *ProductRepository) Create(product *models.Product, movement models.StockMovement) error {
 	// Convertir arrays a JSON strings para SQLite
 	tallasJSON, err := json.Marshal(product.Tallas)
 	if err != nil {
@@ -56,41 +61,50 @@ func (r *ProductRepository) Create(product *models.Product) error {
 	`
 
 	now := time.Now()
-	result, err := r.db.Exec(
-		query,
-		product.Nombre,
-		product.Descripcion,
-		product.Categoria,
-		product.Genero,
-		product.Temporada,
-		product.Precio,
-		product.PrecioLista,
-		product.Stock,
-		string(stockBySizeJSON),
-		string(tallasJSON),
-		string(coloresJSON),
-		string(imagenesJSON),
-		product.Activo,
-		product.Destacado,
-		now,
-		now,
-	)
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			query,
+			product.Nombre,
+			product.Descripcion,
+			product.Categoria,
+			product.Genero,
+			product.Temporada,
+			product.Precio,
+			product.PrecioLista,
+			product.Stock,
+			string(stockBySizeJSON),
+			string(tallasJSON),
+			string(coloresJSON),
+			string(imagenesJSON),
+			product.Activo,
+			product.Destacado,
+			now,
+			now,
+		)
 
-	if err != nil {
-		return fmt.Errorf("error al crear producto: %w", err)
-	}
+		if err != nil {
+			return fmt.Errorf("error al crear producto: %w", err)
+		}
 
-	// Obtener el ID generado
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("error al obtener ID: %w", err)
-	}
+		// Obtener el ID generado
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error al obtener ID: %w", err)
+		}
 
-	product.ID = uint(id)
-	product.CreatedAt = now
-	product.UpdatedAt = now
+		product.ID = uint(id)
+		product.CreatedAt = now
+		product.UpdatedAt = now
 
-	return nil
+		movement.ProductID = product.ID
+		movement.Delta = product.Stock
+		movement.StockAfter = product.Stock
+		// Si el producto se crea con variantes, las alertas son de cada variante
+		if len(product.Variantes) > 0 || len(product.StockBySize) > 0 {
+			return recordStockMovement(tx, &movement)
+		}
+		return recordInitialStock(tx, &movement)
+	})
 }
 
 // GetAll obtiene todos los productos con paginación y filtros opcionales
@@ -128,7 +142,17 @@ func (r *ProductRepository) GetAll(limit, offset int, categories, genders, sizes
 	}
 
 	if len(sizes) > 0 {
-		baseQuery += " AND ("
+		// Productos con variantes: debe existir una variante con stock en alguna de las tallas.
+		// Productos sin variantes (legacy): se busca en la lista de tallas.
+		baseQuery += " AND (EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.stock > 0 AND v.talla IN ("
+		for i, size := range sizes {
+			if i > 0 {
+				baseQuery += ", "
+			}
+			baseQuery += "?"
+			args = append(args, strings.TrimSpace(size))
+		}
+		baseQuery += ")) OR (NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id) AND ("
 		for i, size := range sizes {
 			if i > 0 {
 				baseQuery += " OR "
@@ -136,7 +160,7 @@ func (r *ProductRepository) GetAll(limit, offset int, categories, genders, sizes
 			baseQuery += "tallas LIKE ?"
 			args = append(args, `%"`+size+`"%`)
 		}
-		baseQuery += ")"
+		baseQuery += ")))"
 	}
 
 	if len(temporadas) > 0 {
@@ -245,10 +269,39 @@ func (r *ProductRepository) GetAll(limit, offset int, categories, genders, sizes
 	if err = rows.Err(); err != nil {
 		return nil, 0, fmt.Errorf("error al iterar productos: %w", err)
 	}
+	rows.Close()
+
+	// Cargar variantes de toda la página en una sola consulta
+	if err := r.loadVariants(products); err != nil {
+		return nil, 0, err
+	}
 
 	return products, totalCount, nil
 }
 
+// loadVariants carga las variantes de los productos recibidos
+func (r *ProductRepository) loadVariants(products []models.Product) error {
+	ids := make([]uint, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+
+	variantRepo := &ProductVariantRepository{db: r.db}
+	variantsByProduct, err := variantRepo.GetByProductIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		products[i].Variantes = variantsByProduct[products[i].ID]
+		if products[i].Variantes == nil {
+			products[i].Variantes = []models.ProductVariant{}
+		}
+	}
+
+	return nil
+}
+
 // GetByID obtiene un producto por su ID
 func (r *ProductRepository) GetByID(id uint) (*models.Product, error) {
 	query := `
@@ -314,11 +367,20 @@ func (r *ProductRepository) GetByID(id uint) (*models.Product, error) {
 		json.Unmarshal([]byte(stockBySizeJSON.String), &product.StockBySize)
 	}
 
+	variantRepo := &ProductVariantRepository{db: r.db}
+	variants, err := variantRepo.GetByProductID(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	product.Variantes = variants
+
 	return &product, nil
 }
 
-// Update actualiza un producto completo
-func (r *ProductRepository) Update(product *models.Product) error {
+// Update actualiza un producto completo. Si el producto tiene variantes, stock y
+// stock_by_size no se tocan (se derivan de ellas); si no, la diferencia de stock
+// se registra en el ledger.
+func (r *ProductRepository) Update(product *models.Product, movement models.StockMovement) error {
 	// Convertir arrays a JSON strings
 	tallasJSON, _ := json.Marshal(product.Tallas)
 	coloresJSON, _ := json.Marshal(product.Colores)
@@ -330,52 +392,89 @@ func (r *ProductRepository) Update(product *models.Product) error {
 	product.Genero = strings.ToLower(product.Genero)
 	product.Temporada = strings.ToLower(product.Temporada)
 
-	query := `
-		UPDATE products
-		SET nombre = ?, descripcion = ?, categoria = ?, genero = ?, temporada = ?, precio = ?, precio_lista = ?, stock = ?, stock_by_size = ?,
-		    tallas = ?, colores = ?, imagenes = ?, activo = ?, destacado = ?,
-		    updated_at = ?
-		WHERE id = ?
-	`
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		previousStock, hasVariants, err := currentStock(tx, product.ID)
+		if err != nil {
+			return err
+		}
 
-	result, err := r.db.Exec(
-		query,
-		product.Nombre,
-		product.Descripcion,
-		product.Categoria,
-		product.Genero,
-		product.Temporada,
-		product.Precio,
-		product.PrecioLista,
-		product.Stock,
-		string(stockBySizeJSON),
-		string(tallasJSON),
-		string(coloresJSON),
-		string(imagenesJSON),
-		product.Activo,
-		product.Destacado,
-		time.Now(),
-		product.ID,
-	)
+		query := `
+			UPDATE products
+			SET nombre = ?, descripcion = ?, categoria = ?, genero = ?, temporada = ?, precio = ?, precio_lista = ?,
+			    tallas = ?, colores = ?, imagenes = ?, activo = ?, destacado = ?,
+			    updated_at = ?
+			WHERE id = ?
+		`
+
+		_, err = tx.Exec(
+			query,
+			product.Nombre,
+			product.Descripcion,
+			product.Categoria,
+			product.Genero,
+			product.Temporada,
+			product.Precio,
+			product.PrecioLista,
+			string(tallasJSON),
+			string(coloresJSON),
+			string(imagenesJSON),
+			product.Activo,
+			product.Destacado,
+			time.Now(),
+			product.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("error al actualizar producto: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("error al actualizar producto: %w", err)
-	}
+		if hasVariants {
+			return nil
+		}
 
-	rowsAffected, err := result.RowsAffected()
+		if _, err := tx.Exec("UPDATE products SET stock = ?, stock_by_size = ? WHERE id = ?", product.Stock, string(stockBySizeJSON), product.ID); err != nil {
+			return fmt.Errorf("error al actualizar stock: %w", err)
+		}
+
+		movement.ProductID = product.ID
+		movement.Delta = product.Stock - previousStock
+		movement.StockAfter = product.Stock
+		return recordStockMovement(tx, &movement)
+	})
+}
+
+// currentStock devuelve el stock total del producto e indica si tiene variantes
+func currentStock(db DBTX, productID uint) (int, bool, error) {
+	var stock, variantCount int
+	err := db.QueryRow(`
+		SELECT stock, (SELECT COUNT(*) FROM product_variants WHERE product_id = products.id)
+		FROM products WHERE id = ?
+	`, productID).Scan(&stock, &variantCount)
+	if err == sql.ErrNoRows {
+		return 0, false, fmt.Errorf("producto no encontrado")
+	}
 	if err != nil {
-		return fmt.Errorf("error al verificar actualización: %w", err)
+		return 0, false, fmt.Errorf("error al obtener stock: %w", err)
 	}
+	return stock, variantCount > 0, nil
+}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("producto no encontrado")
+// ensureNoVariants falla con ErrStockManagedByVariants si el producto tiene variantes:
+// su stock es la suma de las variantes y el próximo recálculo pisaría una escritura directa
+func ensureNoVariants(db DBTX, productID uint) error {
+	var variantCount int
+	if err := db.QueryRow("SELECT COUNT(*) FROM product_variants WHERE product_id = ?", productID).Scan(&variantCount); err != nil {
+		return fmt.Errorf("error al obtener variantes: %w", err)
+	}
+	if variantCount > 0 {
+		return ErrStockManagedByVariants
 	}
-
 	return nil
 }
 
-// PartialUpdate actualiza campos específicos de un producto
-func (r *ProductRepository) PartialUpdate(id uint, updates map[string]interface{}) error {
+// PartialUpdate actualiza campos específicos de un producto; si incluye stock,
+// la diferencia se registra en el ledger. Los productos con variantes no admiten
+// cambios de stock ni de stock_by_size (ErrStockManagedByVariants).
+func (r *ProductRepository) PartialUpdate(id uint, updates map[string]interface{}, movement models.StockMovement) error {
 	if len(updates) == 0 {
 		return fmt.Errorf("no hay campos para actualizar")
 	}
@@ -401,21 +500,35 @@ func (r *ProductRepository) PartialUpdate(id uint, updates map[string]interface{
 	query += " WHERE id = ?"
 	args = append(args, id)
 
-	result, err := r.db.Exec(query, args...)
-	if err != nil {
-		return fmt.Errorf("error al actualizar producto: %w", err)
-	}
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		previousStock, hasVariants, err := currentStock(tx, id)
+		if err != nil {
+			return err
+		}
+		_, hasStock := updates["stock"]
+		_, hasStockBySize := updates["stock_by_size"]
+		if hasVariants && (hasStock || hasStockBySize) {
+			return ErrStockManagedByVariants
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error al verificar actualización: %w", err)
-	}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("error al actualizar producto: %w", err)
+		}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("producto no encontrado")
-	}
+		if !hasStock {
+			return nil
+		}
 
-	return nil
+		newStock, _, err := currentStock(tx, id)
+		if err != nil {
+			return err
+		}
+
+		movement.ProductID = id
+		movement.Delta = newStock - previousStock
+		movement.StockAfter = newStock
+		return recordStockMovement(tx, &movement)
+	})
 }
 
 // Delete elimina un producto por su ID
@@ -474,57 +587,86 @@ func (r *ProductRepository) BulkDelete(ids []uint) error {
 	return nil
 }
 
-// UpdateStock actualiza el stock de un producto
-func (r *ProductRepository) UpdateStock(id uint, newStock int) error {
-	query := "UPDATE products SET stock = ?, updated_at = ? WHERE id = ?"
-	_, err := r.db.Exec(query, newStock, time.Now(), id)
-	return err
+// UpdateStock actualiza el stock de un producto sin variantes y registra la diferencia
+// en el ledger
+func (r *ProductRepository) UpdateStock(id uint, newStock int, movement models.StockMovement) error {
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		previousStock, hasVariants, err := currentStock(tx, id)
+		if err != nil {
+			return err
+		}
+		if hasVariants {
+			return ErrStockManagedByVariants
+		}
+
+		query := "UPDATE products SET stock = ?, updated_at = ? WHERE id = ?"
+		if _, err := tx.Exec(query, newStock, time.Now(), id); err != nil {
+			return err
+		}
+
+		movement.ProductID = id
+		movement.Delta = newStock - previousStock
+		movement.StockAfter = newStock
+		return recordStockMovement(tx, &movement)
+	})
 }
 
-// ReduceStock reduce el stock de un producto de manera atómica
-func (r *ProductRepository) ReduceStock(id uint, quantity int) error {
+// ReduceStock reduce el stock de un producto de manera atómica. Los productos con
+// variantes descuentan de cada variante (ErrStockManagedByVariants).
+func (r *ProductRepository) ReduceStock(id uint, quantity int, movement models.StockMovement) error {
 	query := `
 		UPDATE products 
 		SET stock = stock - ?, updated_at = ? 
 		WHERE id = ? AND stock >= ?
+		RETURNING stock
 	`
-	result, err := r.db.Exec(query, quantity, time.Now(), id, quantity)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		if err := ensureNoVariants(tx, id); err != nil {
+			return err
+		}
 
-	if rows == 0 {
-		return fmt.Errorf("stock insuficiente o producto no encontrado para ID %d", id)
-	}
+		var stock int
+		err := tx.QueryRow(query, quantity, time.Now(), id, quantity).Scan(&stock)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("stock insuficiente o producto no encontrado para ID %d", id)
+		}
+		if err != nil {
+			return err
+		}
 
-	return nil
+		movement.ProductID = id
+		movement.Delta = -quantity
+		movement.StockAfter = stock
+		return recordStockMovement(tx, &movement)
+	})
 }
 
-// IncreaseStock incrementa el stock de un producto (atomicamente)
-func (r *ProductRepository) IncreaseStock(id uint, quantity int) error {
+// IncreaseStock incrementa el stock de un producto (atomicamente). Los productos con
+// variantes reciben el stock en cada variante (ErrStockManagedByVariants).
+func (r *ProductRepository) IncreaseStock(id uint, quantity int, movement models.StockMovement) error {
 	query := `
 		UPDATE products 
 		SET stock = stock + ?, updated_at = ? 
 		WHERE id = ?
+		RETURNING stock
 	`
-	result, err := r.db.Exec(query, quantity, time.Now(), id)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		if err := ensureNoVariants(tx, id); err != nil {
+			return err
+		}
 
-	if rows == 0 {
-		return fmt.Errorf("producto no encontrado para ID %d", id)
-	}
+		var stock int
+		err := tx.QueryRow(query, quantity, time.Now(), id).Scan(&stock)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("producto no encontrado para ID %d", id)
+		}
+		if err != nil {
+			return err
+		}
 
-	return nil
+		movement.ProductID = id
+		movement.Delta = quantity
+		movement.StockAfter = stock
+		return recordStockMovement(tx, &movement)
+	})
 }
@@ -0,0 +1,465 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// ProductVariantRepository maneja el acceso a datos de variantes de producto
+type ProductVariantRepository struct {
+	db DBTX
+}
+
+// NewProductVariantRepository crea una nueva instancia del repositorio
+func NewProductVariantRepository(db *sql.DB) *ProductVariantRepository {
+	return &ProductVariantRepository{db: db}
+}
+
+const variantColumns = "id, product_id, talla, color, sku, stock, precio, created_at, updated_at"
+
+// scanVariant escanea una fila de product_variants manejando los campos nullable
+func scanVariant(scanner interface{ Scan(...interface{}) error }) (*models.ProductVariant, error) {
+	var v models.ProductVariant
+	var sku sql.NullString
+	var precio sql.NullFloat64
+
+	err := scanner.Scan(&v.ID, &v.ProductID, &v.Talla, &v.Color, &sku, &v.Stock, &precio, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if sku.Valid {
+		v.SKU = sku.String
+	}
+	if precio.Valid {
+		p := precio.Float64
+		v.Precio = &p
+	}
+
+	return &v, nil
+}
+
+// GetByProductID obtiene todas las variantes de un producto
+func (r *ProductVariantRepository) GetByProductID(productID uint) ([]models.ProductVariant, error) {
+	query := "SELECT " + variantColumns + " FROM product_variants WHERE product_id = ? ORDER BY talla ASC, color ASC"
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener variantes: %w", err)
+	}
+	defer rows.Close()
+
+	variants := []models.ProductVariant{}
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear variante: %w", err)
+		}
+		variants = append(variants, *v)
+	}
+
+	return variants, rows.Err()
+}
+
+// GetByProductIDs obtiene las variantes de varios productos en una sola consulta
+func (r *ProductVariantRepository) GetByProductIDs(productIDs []uint) (map[uint][]models.ProductVariant, error) {
+	result := map[uint][]models.ProductVariant{}
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(productIDs))
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := "SELECT " + variantColumns + " FROM product_variants WHERE product_id IN (" + strings.Join(placeholders, ", ") + ") ORDER BY talla ASC, color ASC"
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener variantes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear variante: %w", err)
+		}
+		result[v.ProductID] = append(result[v.ProductID], *v)
+	}
+
+	return result, rows.Err()
+}
+
+// GetByID obtiene una variante por su ID
+func (r *ProductVariantRepository) GetByID(id uint) (*models.ProductVariant, error) {
+	query := "SELECT " + variantColumns + " FROM product_variants WHERE id = ?"
+
+	v, err := scanVariant(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil // Variante no encontrada
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener variante: %w", err)
+	}
+
+	return v, nil
+}
+
+// FindBySizeAndColor busca la variante de un producto por talla y color
+func (r *ProductVariantRepository) FindBySizeAndColor(productID uint, talla, color string) (*models.ProductVariant, error) {
+	query := "SELECT " + variantColumns + " FROM product_variants WHERE product_id = ? AND talla = ? AND color = ?"
+
+	v, err := scanVariant(r.db.QueryRow(query, productID, strings.TrimSpace(talla), strings.ToLower(strings.TrimSpace(color))))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener variante: %w", err)
+	}
+
+	return v, nil
+}
+
+// Create inserta una nueva variante, registra su stock inicial en el ledger
+// y sincroniza el stock total del producto
+func (r *ProductVariantRepository) Create(variant *models.ProductVariant, movement models.StockMovement) error {
+	now := time.Now()
+
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		if err := releaseLegacyStock(tx, variant.ProductID, movement); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO product_variants (product_id, talla, color, sku, stock, precio, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, variant.ProductID, variant.Talla, variant.Color, variant.SKU, variant.Stock, variant.Precio, now, now)
+		if err != nil {
+			return fmt.Errorf("error al crear variante: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error al obtener ID: %w", err)
+		}
+
+		variant.ID = uint(id)
+		variant.CreatedAt = now
+		variant.UpdatedAt = now
+
+		if err := recordVariantCreation(tx, variant, movement); err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, variant.ProductID)
+	})
+}
+
+// Update actualiza una variante, registra la diferencia de stock en el ledger
+// y sincroniza el stock total del producto
+func (r *ProductVariantRepository) Update(variant *models.ProductVariant, movement models.StockMovement) error {
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		var previousStock int
+		err := tx.QueryRow("SELECT stock FROM product_variants WHERE id = ? AND product_id = ?", variant.ID, variant.ProductID).Scan(&previousStock)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("variante no encontrada")
+		}
+		if err != nil {
+			return fmt.Errorf("error al obtener variante: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			UPDATE product_variants
+			SET talla = ?, color = ?, sku = ?, stock = ?, precio = ?, updated_at = ?
+			WHERE id = ? AND product_id = ?
+		`, variant.Talla, variant.Color, variant.SKU, variant.Stock, variant.Precio, time.Now(), variant.ID, variant.ProductID)
+		if err != nil {
+			return fmt.Errorf("error al actualizar variante: %w", err)
+		}
+
+		if err := recordVariantMovement(tx, variant, variant.Stock-previousStock, movement); err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, variant.ProductID)
+	})
+}
+
+// Delete elimina una variante, registra la baja de su stock en el ledger
+// y sincroniza el stock total del producto
+func (r *ProductVariantRepository) Delete(productID, id uint, movement models.StockMovement) error {
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		variantRepo := &ProductVariantRepository{db: tx}
+		variant, err := variantRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if variant == nil || variant.ProductID != productID {
+			return fmt.Errorf("variante no encontrada")
+		}
+
+		// El movimiento se registra antes de borrar para que la FK quede válida;
+		// al eliminar la variante el ledger conserva talla y color como snapshot.
+		if err := recordVariantRemoval(tx, *variant, movement); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM product_variants WHERE id = ? AND product_id = ?", id, productID); err != nil {
+			return fmt.Errorf("error al eliminar variante: %w", err)
+		}
+
+		return syncProductStock(tx, productID)
+	})
+}
+
+// ReplaceSizeStock aplica un mapa talla → stock (formato legacy stock_by_size) sobre las
+// variantes sin color del producto. Si el producto ya tiene variantes con color el mapa
+// se ignora, porque no alcanza para describir su stock.
+func (r *ProductVariantRepository) ReplaceSizeStock(productID uint, stockBySize map[string]int, movement models.StockMovement) error {
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		variantRepo := &ProductVariantRepository{db: tx}
+		existing, err := variantRepo.GetByProductID(productID)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range existing {
+			if v.Color != "" {
+				return syncProductStock(tx, productID)
+			}
+		}
+
+		if len(existing) == 0 {
+			if err := releaseLegacyStock(tx, productID, movement); err != nil {
+				return err
+			}
+		}
+
+		bySize := map[string]models.ProductVariant{}
+		for _, v := range existing {
+			bySize[v.Talla] = v
+		}
+
+		now := time.Now()
+		seen := map[string]bool{}
+		for talla, stock := range stockBySize {
+			talla = strings.TrimSpace(talla)
+			if talla == "" {
+				continue
+			}
+			if stock < 0 {
+				stock = 0
+			}
+			seen[talla] = true
+
+			variant, ok := bySize[talla]
+			if !ok {
+				variant = models.ProductVariant{ProductID: productID, Talla: talla, SKU: models.BuildVariantSKU(productID, talla, "")}
+				result, err := tx.Exec(`
+					INSERT INTO product_variants (product_id, talla, color, sku, stock, created_at, updated_at)
+					VALUES (?, ?, '', ?, ?, ?, ?)
+				`, productID, talla, variant.SKU, stock, now, now)
+				if err != nil {
+					return fmt.Errorf("error al crear variante %s: %w", talla, err)
+				}
+				id, err := result.LastInsertId()
+				if err != nil {
+					return err
+				}
+				variant.ID = uint(id)
+				variant.Stock = stock
+				if err := recordVariantCreation(tx, &variant, movement); err != nil {
+					return err
+				}
+				continue
+			}
+			if variant.Stock != stock {
+				if _, err := tx.Exec("UPDATE product_variants SET stock = ?, updated_at = ? WHERE id = ?", stock, now, variant.ID); err != nil {
+					return fmt.Errorf("error al actualizar variante %s: %w", talla, err)
+				}
+			}
+
+			delta := stock - variant.Stock
+			variant.Stock = stock
+			if err := recordVariantMovement(tx, &variant, delta, movement); err != nil {
+				return err
+			}
+		}
+
+		// Eliminar las tallas que ya no figuran en el mapa
+		for talla, variant := range bySize {
+			if seen[talla] {
+				continue
+			}
+			if err := recordVariantRemoval(tx, variant, movement); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM product_variants WHERE id = ?", variant.ID); err != nil {
+				return fmt.Errorf("error al eliminar variante %s: %w", talla, err)
+			}
+		}
+
+		return syncProductStock(tx, productID)
+	})
+}
+
+// ReduceStock descuenta stock de una variante de manera atómica y sincroniza el producto
+func (r *ProductVariantRepository) ReduceStock(id uint, quantity int, movement models.StockMovement) error {
+	return r.shiftStock(id, -quantity, movement)
+}
+
+// IncreaseStock incrementa el stock de una variante y sincroniza el producto
+func (r *ProductVariantRepository) IncreaseStock(id uint, quantity int, movement models.StockMovement) error {
+	return r.shiftStock(id, quantity, movement)
+}
+
+// shiftStock aplica un delta sobre el stock de la variante sin permitir valores negativos
+func (r *ProductVariantRepository) shiftStock(id uint, delta int, movement models.StockMovement) error {
+	return runInTx(r.db, func(tx *sql.Tx) error {
+		var variant models.ProductVariant
+		err := tx.QueryRow(`
+			UPDATE product_variants
+			SET stock = stock + ?, updated_at = ?
+			WHERE id = ? AND stock + ? >= 0
+			RETURNING id, product_id, talla, color, stock
+		`, delta, time.Now(), id, delta).Scan(&variant.ID, &variant.ProductID, &variant.Talla, &variant.Color, &variant.Stock)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("stock insuficiente o variante no encontrada para ID %d", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := recordVariantMovement(tx, &variant, delta, movement); err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, variant.ProductID)
+	})
+}
+
+// recordVariantMovement registra en el ledger un cambio de stock de la variante
+// (variant.Stock debe contener el stock resultante)
+func recordVariantMovement(db DBTX, variant *models.ProductVariant, delta int, movement models.StockMovement) error {
+	movement = variantMovement(variant, delta, movement)
+	return recordStockMovement(db, &movement)
+}
+
+// recordVariantCreation registra en el ledger el stock inicial de una variante nueva
+// (variant.Stock debe contener ese stock)
+func recordVariantCreation(db DBTX, variant *models.ProductVariant, movement models.StockMovement) error {
+	movement = variantMovement(variant, variant.Stock, movement)
+	return recordInitialStock(db, &movement)
+}
+
+// recordVariantRemoval registra en el ledger la baja del stock de una variante que se
+// elimina (no genera alerta de agotado)
+func recordVariantRemoval(db DBTX, variant models.ProductVariant, movement models.StockMovement) error {
+	removed := variant
+	removed.Stock = 0
+	movement = variantMovement(&removed, -variant.Stock, movement)
+	return insertStockMovement(db, &movement)
+}
+
+// variantMovement completa el movimiento con los datos de la variante
+func variantMovement(variant *models.ProductVariant, delta int, movement models.StockMovement) models.StockMovement {
+	movement.ProductID = variant.ProductID
+	movement.VariantID = &variant.ID
+	movement.Talla = variant.Talla
+	movement.Color = variant.Color
+	movement.Delta = delta
+	movement.StockAfter = variant.Stock
+	return movement
+}
+
+// releaseLegacyStock registra la salida del stock total de un producto que todavía
+// no tiene variantes, ya que a partir de la primera variante el total se calcula
+// desde ellas. Así la suma del ledger sigue coincidiendo con el stock del producto.
+func releaseLegacyStock(db DBTX, productID uint, movement models.StockMovement) error {
+	var variantCount, stock int
+	err := db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM product_variants WHERE product_id = ?), stock
+		FROM products WHERE id = ?
+	`, productID, productID).Scan(&variantCount, &stock)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("producto no encontrado")
+	}
+	if err != nil {
+		return err
+	}
+	if variantCount > 0 || stock == 0 {
+		return nil
+	}
+
+	movement.ProductID = productID
+	movement.Delta = -stock
+	movement.StockAfter = 0
+	movement.Note = "Stock total trasladado a variantes"
+	return insertStockMovement(db, &movement)
+}
+
+// SyncProductStock recalcula el stock total del producto si tiene variantes cargadas
+func (r *ProductVariantRepository) SyncProductStock(productID uint) error {
+	count, err := r.CountByProductID(productID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	return syncProductStock(r.db, productID)
+}
+
+// CountByProductID devuelve cuántas variantes tiene un producto
+func (r *ProductVariantRepository) CountByProductID(productID uint) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM product_variants WHERE product_id = ?", productID).Scan(&count)
+	return count, err
+}
+
+// syncProductStock recalcula products.stock y products.stock_by_size a partir de las variantes.
+// Los productos sin variantes conservan su stock manual.
+func syncProductStock(db DBTX, productID uint) error {
+	rows, err := db.Query("SELECT talla, SUM(stock) FROM product_variants WHERE product_id = ? GROUP BY talla", productID)
+	if err != nil {
+		return fmt.Errorf("error al calcular stock por talla: %w", err)
+	}
+
+	stockBySize := map[string]int{}
+	total := 0
+	for rows.Next() {
+		var talla string
+		var stock int
+		if err := rows.Scan(&talla, &stock); err != nil {
+			rows.Close()
+			return err
+		}
+		stockBySize[talla] = stock
+		total += stock
+	}
+	rows.Close()
+
+	stockBySizeJSON, err := json.Marshal(stockBySize)
+	if err != nil {
+		return fmt.Errorf("error al serializar stock_by_size: %w", err)
+	}
+
+	_, err = db.Exec(
+		"UPDATE products SET stock = ?, stock_by_size = ?, updated_at = ? WHERE id = ?",
+		total, string(stockBySizeJSON), time.Now(), productID,
+	)
+	if err != nil {
+		return fmt.Errorf("error al sincronizar stock del producto: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// ShipmentRepository maneja los envíos de los pedidos
+type ShipmentRepository struct {
+	db DBTX
+}
+
+// NewShipmentRepository crea una nueva instancia del repositorio
+func NewShipmentRepository(db *sql.DB) *ShipmentRepository {
+	return &ShipmentRepository{db: db}
+}
+
+const shipmentColumns = `id, order_id, carrier, tracking_number, shipped_at, estimated_delivery, label_file, status,
+	status_detail, delivered_at, last_checked_at, created_by, created_at, updated_at`
+
+// Create registra un envío
+func (r *ShipmentRepository) Create(s *models.Shipment) error {
+	now := time.Now()
+	if s.Status == "" {
+		s.Status = models.ShipmentStatusInTransit
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO order_shipments (order_id, carrier, tracking_number, shipped_at, estimated_delivery, label_file,
+			status, status_detail, delivered_at, last_checked_at, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, s.OrderID, s.Carrier, s.TrackingNumber, s.ShippedAt, s.EstimatedDelivery, s.LabelFile,
+		s.Status, s.StatusDetail, s.DeliveredAt, s.LastCheckedAt, s.CreatedBy, now, now)
+	if err != nil {
+		return fmt.Errorf("error al registrar envío: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	s.ID = uint(id)
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// Update guarda los datos del envío (datos cargados, estado informado y etiqueta)
+func (r *ShipmentRepository) Update(s *models.Shipment) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		UPDATE order_shipments
+		SET carrier = ?, tracking_number = ?, shipped_at = ?, estimated_delivery = ?, label_file = ?,
+			status = ?, status_detail = ?, delivered_at = ?, last_checked_at = ?, updated_at = ?
+		WHERE id = ?
+	`, s.Carrier, s.TrackingNumber, s.ShippedAt, s.EstimatedDelivery, s.LabelFile,
+		s.Status, s.StatusDetail, s.DeliveredAt, s.LastCheckedAt, now, s.ID)
+	if err != nil {
+		return fmt.Errorf("error al actualizar envío: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("envío no encontrado")
+	}
+
+	s.UpdatedAt = now
+	s.HasLabel = s.LabelFile != ""
+	return nil
+}
+
+// GetByID obtiene un envío del pedido; devuelve nil si no existe o es de otro pedido
+func (r *ShipmentRepository) GetByID(orderID, id uint) (*models.Shipment, error) {
+	shipment, err := scanShipment(r.db.QueryRow("SELECT "+shipmentColumns+" FROM order_shipments WHERE id = ? AND order_id = ?", id, orderID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return shipment, err
+}
+
+// GetByOrderID obtiene los envíos de un pedido en orden de despacho
+func (r *ShipmentRepository) GetByOrderID(orderID uint) ([]models.Shipment, error) {
+	rows, err := r.db.Query("SELECT "+shipmentColumns+" FROM order_shipments WHERE order_id = ? ORDER BY shipped_at ASC, id ASC", orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener envíos: %w", err)
+	}
+	defer rows.Close()
+
+	shipments := []models.Shipment{}
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, *shipment)
+	}
+
+	return shipments, rows.Err()
+}
+
+// GetPendingTracking obtiene los envíos a consultar: con número de seguimiento, de alguno
+// de los transportistas indicados, todavía sin entregar y de pedidos en estado Enviado
+func (r *ShipmentRepository) GetPendingTracking(carriers []string) ([]models.Shipment, error) {
+	if len(carriers) == 0 {
+		return []models.Shipment{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(carriers)), ",")
+	args := []interface{}{models.ShipmentStatusDelivered, models.OrderStatusShipped}
+	for _, carrier := range carriers {
+		args = append(args, carrier)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+shipmentColumns+`
+		FROM order_shipments
+		WHERE tracking_number <> '' AND status <> ?
+		  AND order_id IN (SELECT id FROM orders WHERE status = ?)
+		  AND carrier IN (`+placeholders+`)
+		ORDER BY last_checked_at ASC, id ASC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener envíos pendientes: %w", err)
+	}
+	defer rows.Close()
+
+	shipments := []models.Shipment{}
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, *shipment)
+	}
+
+	return shipments, rows.Err()
+}
+
+// scanShipment lee un envío de una fila con shipmentColumns
+func scanShipment(row rowScanner) (*models.Shipment, error) {
+	var s models.Shipment
+	var estimatedDelivery, deliveredAt, lastCheckedAt sql.NullTime
+	err := row.Scan(&s.ID, &s.OrderID, &s.Carrier, &s.TrackingNumber, &s.ShippedAt, &estimatedDelivery, &s.LabelFile,
+		&s.Status, &s.StatusDetail, &deliveredAt, &lastCheckedAt, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error al escanear envío: %w", err)
+	}
+
+	if estimatedDelivery.Valid {
+		s.EstimatedDelivery = &estimatedDelivery.Time
+	}
+	if deliveredAt.Valid {
+		s.DeliveredAt = &deliveredAt.Time
+	}
+	if lastCheckedAt.Valid {
+		s.LastCheckedAt = &lastCheckedAt.Time
+	}
+	s.HasLabel = s.LabelFile != ""
+	return &s, nil
+}
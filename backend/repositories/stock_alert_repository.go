@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// StockAlertRepository maneja las alertas de stock bajo y agotado
+type StockAlertRepository struct {
+	db DBTX
+}
+
+// NewStockAlertRepository crea una nueva instancia del repositorio
+func NewStockAlertRepository(db *sql.DB) *StockAlertRepository {
+	return &StockAlertRepository{db: db}
+}
+
+const stockAlertColumns = `id, product_id, variant_id, product_name, talla, color, kind, stock, threshold,
+	email_status, attempts, last_error, read_at, created_at, sent_at`
+
+// raiseStockAlert registra la alerta si el movimiento cruzó el umbral de stock bajo de la
+// configuración (ver models.StockAlertFor); created indica que el movimiento es el alta
+// del producto o la variante. Se llama desde recordStockMovement y recordInitialStock,
+// dentro de la transacción del movimiento. Los productos con variantes alertan por variante.
+func raiseStockAlert(db DBTX, m models.StockMovement, created bool) error {
+	threshold, enabled := models.DefaultLowStockThreshold, true
+	err := db.QueryRow("SELECT low_stock_threshold, enable_stock_alerts FROM site_configs LIMIT 1").Scan(&threshold, &enabled)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error al obtener el umbral de stock: %w", err)
+	}
+	if !enabled {
+		return nil
+	}
+
+	kind, ok := models.StockAlertFor(m.StockAfter-m.Delta, m.StockAfter, threshold)
+	if created {
+		kind, ok = models.StockAlertOnCreate(m.StockAfter, threshold)
+	}
+	if !ok {
+		return nil
+	}
+
+	var name string
+	var variantCount int
+	err = db.QueryRow(`
+		SELECT nombre, (SELECT COUNT(*) FROM product_variants WHERE product_id = ?)
+		FROM products WHERE id = ?
+	`, m.ProductID, m.ProductID).Scan(&name, &variantCount)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error al obtener producto: %w", err)
+	}
+	if m.VariantID == nil && variantCount > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO stock_alerts (product_id, variant_id, product_name, talla, color, kind, stock, threshold, email_status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.ProductID, m.VariantID, name, m.Talla, m.Color, kind, m.StockAfter, threshold, models.OrderNotificationPending, time.Now())
+	if err != nil {
+		return fmt.Errorf("error al registrar alerta de stock: %w", err)
+	}
+	return nil
+}
+
+// GetAll obtiene las alertas más recientes primero; unreadOnly filtra las no vistas
+func (r *StockAlertRepository) GetAll(unreadOnly bool, limit int) ([]models.StockAlert, error) {
+	query := "SELECT " + stockAlertColumns + " FROM stock_alerts"
+	if unreadOnly {
+		query += " WHERE read_at IS NULL"
+	}
+	return r.query(query+" ORDER BY created_at DESC, id DESC LIMIT ?", limit)
+}
+
+// GetPendingEmail obtiene las alertas que falta enviar por email, las más antiguas primero
+func (r *StockAlertRepository) GetPendingEmail(limit int) ([]models.StockAlert, error) {
+	return r.query("SELECT "+stockAlertColumns+" FROM stock_alerts WHERE email_status = ? ORDER BY created_at ASC, id ASC LIMIT ?",
+		models.OrderNotificationPending, limit)
+}
+
+// MarkRead marca la alerta como vista. Devuelve false si no existe.
+func (r *StockAlertRepository) MarkRead(id uint) (bool, error) {
+	result, err := r.db.Exec("UPDATE stock_alerts SET read_at = COALESCE(read_at, ?) WHERE id = ?", time.Now(), id)
+	if err != nil {
+		return false, fmt.Errorf("error al marcar alerta: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// MarkSent registra el envío por email de las alertas
+func (r *StockAlertRepository) MarkSent(ids []uint) error {
+	return r.updateEmail(ids, "email_status = ?, attempts = attempts + 1, last_error = '', sent_at = ?",
+		models.OrderNotificationSent, time.Now())
+}
+
+// MarkFailed registra un intento fallido; al llegar a MaxNotificationAttempts deja de reintentarse
+func (r *StockAlertRepository) MarkFailed(ids []uint, cause error) error {
+	return r.updateEmail(ids, "attempts = attempts + 1, last_error = ?, email_status = CASE WHEN attempts + 1 >= ? THEN ? ELSE email_status END",
+		cause.Error(), models.MaxNotificationAttempts, models.OrderNotificationFailed)
+}
+
+// MarkSkipped descarta el envío por email de las alertas, con el motivo (siguen visibles en el panel)
+func (r *StockAlertRepository) MarkSkipped(ids []uint, reason string) error {
+	return r.updateEmail(ids, "email_status = ?, last_error = ?", models.OrderNotificationSkipped, reason)
+}
+
+func (r *StockAlertRepository) updateEmail(ids []uint, set string, args ...interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	_, err := r.db.Exec("UPDATE stock_alerts SET "+set+" WHERE id IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return fmt.Errorf("error al actualizar alertas de stock: %w", err)
+	}
+	return nil
+}
+
+// GetLowStock obtiene los productos activos con stock en el umbral o por debajo: las
+// variantes de los productos que las tienen y el stock total de los que no, agotados primero
+func (r *StockAlertRepository) GetLowStock(threshold int) ([]models.LowStockItem, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.nombre, v.id, v.talla, v.color, COALESCE(v.sku, ''), v.stock
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE p.activo = 1 AND v.stock <= ?
+		UNION ALL
+		SELECT p.id, p.nombre, NULL, '', '', '', p.stock
+		FROM products p
+		WHERE p.activo = 1 AND p.stock <= ?
+			AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+		ORDER BY 7 ASC, 2 ASC, 4 ASC, 5 ASC
+	`, threshold, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos con stock bajo: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.LowStockItem{}
+	for rows.Next() {
+		var item models.LowStockItem
+		var variantID sql.NullInt64
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &variantID, &item.Talla, &item.Color, &item.SKU, &item.Stock); err != nil {
+			return nil, fmt.Errorf("error al escanear producto: %w", err)
+		}
+		item.VariantID = nullableUint(variantID)
+		item.Status = models.StockAlertLow
+		if item.Stock <= 0 {
+			item.Status = models.StockAlertOutOfStock
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *StockAlertRepository) query(query string, args ...interface{}) ([]models.StockAlert, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener alertas de stock: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []models.StockAlert{}
+	for rows.Next() {
+		var a models.StockAlert
+		var variantID sql.NullInt64
+		var readAt, sentAt sql.NullTime
+		if err := rows.Scan(&a.ID, &a.ProductID, &variantID, &a.ProductName, &a.Talla, &a.Color, &a.Kind, &a.Stock, &a.Threshold,
+			&a.EmailStatus, &a.Attempts, &a.LastError, &readAt, &a.CreatedAt, &sentAt); err != nil {
+			return nil, fmt.Errorf("error al escanear alerta de stock: %w", err)
+		}
+		a.VariantID = nullableUint(variantID)
+		if readAt.Valid {
+			a.ReadAt = &readAt.Time
+		}
+		if sentAt.Valid {
+			a.SentAt = &sentAt.Time
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, rows.Err()
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+)
+
+// StockMovementRepository maneja el ledger de movimientos de stock
+type StockMovementRepository struct {
+	db DBTX
+}
+
+// NewStockMovementRepository crea una nueva instancia del repositorio
+func NewStockMovementRepository(db *sql.DB) *StockMovementRepository {
+	return &StockMovementRepository{db: db}
+}
+
+// recordStockMovement inserta un movimiento en el ledger y genera la alerta de stock
+// bajo o agotado si corresponde. Se llama desde cada operación que modifica stock,
+// dentro de su misma transacción.
+func recordStockMovement(db DBTX, m *models.StockMovement) error {
+	if m.Delta == 0 {
+		return nil
+	}
+	if err := insertStockMovement(db, m); err != nil {
+		return err
+	}
+	return raiseStockAlert(db, *m, false)
+}
+
+// recordInitialStock registra el stock con el que se crea un producto o una variante y
+// genera la alerta si se crea agotado o con poco stock (ver models.StockAlertOnCreate)
+func recordInitialStock(db DBTX, m *models.StockMovement) error {
+	if err := insertStockMovement(db, m); err != nil {
+		return err
+	}
+	return raiseStockAlert(db, *m, true)
+}
+
+// insertStockMovement inserta el movimiento sin evaluar alertas. Lo usan las bajas de
+// variantes y el traslado del stock de un producto a sus variantes, que dejan el stock
+// en 0 sin que el producto se haya agotado.
+func insertStockMovement(db DBTX, m *models.StockMovement) error {
+	if m.Delta == 0 {
+		return nil
+	}
+
+	if m.Reason == "" {
+		m.Reason = models.StockMovementManualAdjust
+	}
+	if m.CreatedBy == "" {
+		m.CreatedBy = "sistema"
+	}
+
+	now := time.Now()
+	result, err := db.Exec(`
+		INSERT INTO stock_movements (product_id, variant_id, talla, color, delta, stock_after, reason, order_id, user_id, created_by, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.ProductID, m.VariantID, m.Talla, m.Color, m.Delta, m.StockAfter, m.Reason, m.OrderID, m.UserID, m.CreatedBy, m.Note, now)
+	if err != nil {
+		return fmt.Errorf("error al registrar movimiento de stock: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	m.ID = uint(id)
+	m.CreatedAt = now
+	return nil
+}
+
+// GetByProductID obtiene los movimientos de un producto (más recientes primero)
+func (r *StockMovementRepository) GetByProductID(productID uint, limit, offset int) ([]models.StockMovement, int, error) {
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM stock_movements WHERE product_id = ?", productID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error al contar movimientos: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, product_id, variant_id, talla, color, delta, stock_after, reason, order_id, user_id, created_by, note, created_at
+		FROM stock_movements
+		WHERE product_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, productID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error al obtener movimientos: %w", err)
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		var variantID, orderID, userID sql.NullInt64
+		var createdBy, note sql.NullString
+		err := rows.Scan(&m.ID, &m.ProductID, &variantID, &m.Talla, &m.Color, &m.Delta, &m.StockAfter, &m.Reason,
+			&orderID, &userID, &createdBy, &note, &m.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error al escanear movimiento: %w", err)
+		}
+		m.VariantID = nullableUint(variantID)
+		m.OrderID = nullableUint(orderID)
+		m.UserID = nullableUint(userID)
+		m.CreatedBy = createdBy.String
+		m.Note = note.String
+		movements = append(movements, m)
+	}
+
+	return movements, total, rows.Err()
+}
+
+// GetReport agrupa los movimientos del período [from, to) por producto, variante y motivo
+func (r *StockMovementRepository) GetReport(from, to time.Time) ([]models.StockMovementSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT m.product_id, COALESCE(p.nombre, ''), m.variant_id, m.talla, m.color, m.reason,
+		       COUNT(*),
+		       COALESCE(SUM(CASE WHEN m.delta > 0 THEN m.delta ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN m.delta < 0 THEN -m.delta ELSE 0 END), 0),
+		       COALESCE(SUM(m.delta), 0)
+		FROM stock_movements m
+		LEFT JOIN products p ON p.id = m.product_id
+		WHERE m.created_at >= ? AND m.created_at < ?
+		GROUP BY m.product_id, m.variant_id, m.talla, m.color, m.reason
+		ORDER BY p.nombre ASC, m.talla ASC, m.color ASC, m.reason ASC
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de movimientos: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []models.StockMovementSummary{}
+	for rows.Next() {
+		var s models.StockMovementSummary
+		var variantID sql.NullInt64
+		err := rows.Scan(&s.ProductID, &s.ProductName, &variantID, &s.Talla, &s.Color, &s.Reason,
+			&s.Movements, &s.UnitsIn, &s.UnitsOut, &s.NetDelta)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear reporte: %w", err)
+		}
+		s.VariantID = nullableUint(variantID)
+		summaries = append(summaries, s)
+	}
+
+	return summaries, rows.Err()
+}
+
+// nullableUint convierte un NullInt64 en *uint
+func nullableUint(v sql.NullInt64) *uint {
+	if !v.Valid {
+		return nil
+	}
+	id := uint(v.Int64)
+	return &id
+}
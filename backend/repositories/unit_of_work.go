@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DBTX es la interfaz común de *sql.DB y *sql.Tx que usan los repositorios,
+// de modo que un mismo repositorio puede trabajar dentro o fuera de una transacción
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// TxRepositories agrupa los repositorios ligados a una misma transacción
+type TxRepositories struct {
+	Orders         *OrderRepository
+	Products       *ProductRepository
+	Variants       *ProductVariantRepository
+	StatusHistory  *OrderStatusHistoryRepository
+	Revisions      *OrderRevisionRepository
+	StockMovements *StockMovementRepository
+	Payments       *OrderPaymentRepository
+	Returns        *OrderReturnRepository
+	Coupons        *CouponRepository
+	Customers      *CustomerRepository
+	Shipments      *ShipmentRepository
+	Notifications  *OrderNotificationRepository
+}
+
+// UnitOfWork ejecuta operaciones de varios repositorios en una única transacción
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork crea una nueva unidad de trabajo
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do ejecuta fn dentro de una transacción: si fn devuelve error se hace rollback de todo.
+// Importante: con SQLite hay una sola conexión abierta, por lo que dentro de fn solo
+// deben usarse los repositorios recibidos (usar otros bloquearía la conexión).
+func (u *UnitOfWork) Do(fn func(repos *TxRepositories) error) error {
+	return runInTx(u.db, func(tx *sql.Tx) error {
+		return fn(&TxRepositories{
+			Orders:         &OrderRepository{db: tx},
+			Products:       &ProductRepository{db: tx},
+			Variants:       &ProductVariantRepository{db: tx},
+			StatusHistory:  &OrderStatusHistoryRepository{db: tx},
+			Revisions:      &OrderRevisionRepository{db: tx},
+			StockMovements: &StockMovementRepository{db: tx},
+			Payments:       &OrderPaymentRepository{db: tx},
+			Returns:        &OrderReturnRepository{db: tx},
+			Coupons:        &CouponRepository{db: tx},
+			Customers:      &CustomerRepository{db: tx},
+			Shipments:      &ShipmentRepository{db: tx},
+			Notifications:  &OrderNotificationRepository{db: tx},
+		})
+	})
+}
+
+// runInTx ejecuta fn en una transacción. Si db ya es una transacción se reutiliza
+// (el commit queda a cargo de quien la abrió).
+func runInTx(db DBTX, fn func(tx *sql.Tx) error) error {
+	switch conn := db.(type) {
+	case *sql.Tx:
+		return fn(conn)
+	case *sql.DB:
+		tx, err := conn.Begin()
+		if err != nil {
+			return fmt.Errorf("error al iniciar transacción: %w", err)
+		}
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	default:
+		return fmt.Errorf("conexión de base de datos no soportada: %T", db)
+	}
+}
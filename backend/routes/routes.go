@@ -1,8 +1,14 @@
 ﻿package routes
 
 import (
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/config"
 	"tiendaedgar/backend/database"
 	"tiendaedgar/backend/handlers"
+	"tiendaedgar/backend/mailer"
+	"tiendaedgar/backend/mercadopago"
 	"tiendaedgar/backend/middleware"
 	"tiendaedgar/backend/repositories"
 	"tiendaedgar/backend/services"
@@ -30,18 +36,114 @@ func SetupRoutes(router *gin.Engine) {
 	
 	// Crear repositorio, servicio y handler de productos
 	productRepo := repositories.NewProductRepository(database.DB)
-	productService := services.NewProductService(productRepo)
+	variantRepo := repositories.NewProductVariantRepository(database.DB)
+	unitOfWork := repositories.NewUnitOfWork(database.DB)
+	productService := services.NewProductService(productRepo, variantRepo, unitOfWork)
 	productHandler := handlers.NewProductHandler(productService)
 
+	// Crear servicio y handler de variantes de producto
+	variantService := services.NewProductVariantService(variantRepo, productRepo)
+	variantHandler := handlers.NewProductVariantHandler(variantService)
+
+	// Crear repositorio, servicio y handler del ledger de inventario
+	stockMovementRepo := repositories.NewStockMovementRepository(database.DB)
+	stockMovementService := services.NewStockMovementService(stockMovementRepo, productRepo)
+	stockMovementHandler := handlers.NewStockMovementHandler(stockMovementService)
+
 	// Crear handler de carousel slides
 	carouselHandler := handlers.NewCarouselHandler()
 
 
 	// Crear repositorio, servicio y handler de pedidos
 	orderRepo := repositories.NewOrderRepository(database.DB)
-	orderService := services.NewOrderService(orderRepo, productRepo)
+	orderHistoryRepo := repositories.NewOrderStatusHistoryRepository(database.DB)
+	orderPaymentRepo := repositories.NewOrderPaymentRepository(database.DB)
+	orderRevisionRepo := repositories.NewOrderRevisionRepository(database.DB)
+	configRepo := repositories.NewConfigRepository(database.DB)
+	orderPricer := services.NewOrderPricer(configRepo)
+	orderService := services.NewOrderService(orderRepo, orderHistoryRepo, orderPaymentRepo, orderRevisionRepo, unitOfWork, orderPricer, configRepo)
 	orderHandler := handlers.NewOrderHandler(orderService)
 
+	// Crear servicio y handler de comprobantes PDF (comprobante de compra y remito)
+	orderDocumentService := services.NewOrderDocumentService(orderService, configRepo, "./uploads")
+	orderDocumentHandler := handlers.NewOrderDocumentHandler(orderDocumentService)
+
+	// Crear repositorio, servicio y handler de devoluciones y cambios
+	orderReturnRepo := repositories.NewOrderReturnRepository(database.DB)
+	returnService := services.NewReturnService(orderRepo, orderReturnRepo, unitOfWork)
+	returnHandler := handlers.NewReturnHandler(returnService)
+
+	// Crear repositorio, servicio y handler de envíos. Los transportistas con seguimiento
+	// automático se registran por código (ver services.CarrierTracker); el resto se actualiza a mano.
+	// Todavía no hay transportistas integrados: StartSync lo avisa en el log y no consulta nada.
+	shippingConfig := config.ShippingFromEnv()
+	shipmentRepo := repositories.NewShipmentRepository(database.DB)
+	carrierTrackers := map[string]services.CarrierTracker{}
+	shipmentService := services.NewShipmentService(orderRepo, shipmentRepo, unitOfWork, shippingConfig.LabelsDir, carrierTrackers)
+	shipmentService.StartSync(shippingConfig.SyncInterval)
+	shipmentHandler := handlers.NewShipmentHandler(shipmentService)
+
+	// Crear repositorio, servicio y handler de clientes
+	customerRepo := repositories.NewCustomerRepository(database.DB)
+	customerService := services.NewCustomerService(customerRepo, orderRepo, unitOfWork)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+
+	// Crear repositorio, servicio y handler de cupones de descuento
+	couponRepo := repositories.NewCouponRepository(database.DB)
+	couponService := services.NewCouponService(couponRepo)
+	couponHandler := handlers.NewCouponHandler(couponService)
+
+	// Crear handler de medios de pago
+	paymentMethodHandler := handlers.NewPaymentMethodHandler(orderPricer)
+
+	// Crear handler de envíos
+	shippingHandler := handlers.NewShippingHandler(orderPricer)
+
+	// Idempotency-Key en la creación de pedidos: los reintentos reciben la primera respuesta
+	idempotencyRepo := repositories.NewIdempotencyRepository(database.DB)
+	idempotency := middleware.Idempotency(idempotencyRepo, 24*time.Hour)
+
+	// Crear servicio y handler del checkout público
+	// Los pedidos sin pago liberan el stock al vencer la reserva (ver config.CheckoutFromEnv)
+	checkoutConfig := config.CheckoutFromEnv()
+	checkoutService := services.NewCheckoutService(productRepo, orderService)
+	checkoutService.StartExpiry(checkoutConfig.ReservationTTL, checkoutConfig.ExpiryInterval)
+	checkoutHandler := handlers.NewCheckoutHandler(checkoutService)
+
+	// Crear servicio y handler de pagos online (Mercado Pago); sin credenciales quedan deshabilitados
+	mpConfig := config.MercadoPagoFromEnv()
+	var paymentGateway services.PaymentGateway
+	if mpConfig.Enabled() {
+		paymentGateway = mercadopago.NewClient(mpConfig.AccessToken, mpConfig.WebhookSecret, mpConfig.BaseURL)
+	}
+	notificationURL := ""
+	if mpConfig.PublicURL != "" {
+		notificationURL = strings.TrimRight(mpConfig.PublicURL, "/") + "/api/webhooks/mercadopago"
+	}
+	paymentService := services.NewPaymentService(orderRepo, orderService, paymentGateway, notificationURL, mpConfig.StoreURL)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+
+	// Crear servicio y handler de enlaces de WhatsApp (mensajes armados con las plantillas de la configuración)
+	whatsAppService := services.NewWhatsAppService(productService, orderService, configRepo, mpConfig.StoreURL)
+	whatsAppHandler := handlers.NewWhatsAppHandler(whatsAppService)
+
+	// Crear repositorio, servicio y handler de emails de pedidos; sin servidor SMTP quedan en cola sin enviarse
+	smtpConfig := config.SMTPFromEnv()
+	var emailSender services.EmailSender
+	if smtpConfig.Enabled() {
+		emailSender = mailer.NewSMTPSender(smtpConfig.Host, smtpConfig.Port, smtpConfig.Username, smtpConfig.Password, smtpConfig.From)
+	}
+	notificationRepo := repositories.NewOrderNotificationRepository(database.DB)
+	notificationService := services.NewNotificationService(notificationRepo, orderService, shipmentRepo, configRepo, emailSender, smtpConfig.AdminEmail, mpConfig.StoreURL)
+	notificationService.StartDispatcher(smtpConfig.DispatchInterval)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+
+	// Crear repositorio, servicio y handler de alertas de stock (se envían a ORDER_ALERTS_EMAIL por el mismo SMTP)
+	stockAlertRepo := repositories.NewStockAlertRepository(database.DB)
+	stockAlertService := services.NewStockAlertService(stockAlertRepo, configRepo, emailSender, smtpConfig.AdminEmail)
+	stockAlertService.StartDispatcher(smtpConfig.DispatchInterval)
+	stockAlertHandler := handlers.NewStockAlertHandler(stockAlertService)
+
 	// Crear handler de configuración
 	configHandler := handlers.NewConfigHandler()
 	
@@ -75,6 +177,7 @@ func SetupRoutes(router *gin.Engine) {
 			// Endpoints públicos (no requieren autenticación)
 			products.GET("", productHandler.GetAllProducts)       // Listar productos (con paginación y filtros)
 			products.GET("/:id", productHandler.GetProductByID)   // Obtener producto por ID
+			products.GET("/:id/whatsapp-link", whatsAppHandler.GetProductLink) // Enlace de WhatsApp para consultar por el producto
 			
 			// Endpoints protegidos (requieren autenticación)
 			products.POST("", middleware.AuthRequired(), productHandler.CreateProduct)                // Crear producto
@@ -82,6 +185,25 @@ func SetupRoutes(router *gin.Engine) {
 			products.PATCH("/:id", middleware.AuthRequired(), productHandler.PartialUpdateProduct)    // Actualizar producto parcial
 			products.DELETE("/:id", middleware.AuthRequired(), productHandler.DeleteProduct)          // Eliminar producto
 			products.POST("/bulk-delete", middleware.AuthRequired(), productHandler.BulkDeleteProducts) // Eliminar productos en masa
+
+			// Variantes (talla × color)
+			products.GET("/:id/variants", variantHandler.GetVariants)                                                  // Listar variantes
+			products.POST("/:id/variants", middleware.AuthRequired(), variantHandler.CreateVariant)                    // Crear variante
+			products.PUT("/:id/variants/:variantId", middleware.AuthRequired(), variantHandler.UpdateVariant)          // Actualizar variante
+			products.DELETE("/:id/variants/:variantId", middleware.AuthRequired(), variantHandler.DeleteVariant)       // Eliminar variante
+
+			// Ledger de inventario
+			products.GET("/:id/stock-movements", middleware.AuthRequired(), stockMovementHandler.GetProductMovements) // Movimientos de stock
+		}
+
+		// Rutas de inventario
+		inventory := api.Group("/inventory")
+		inventory.Use(middleware.AuthRequired())
+		{
+			inventory.GET("/movements/report", stockMovementHandler.GetReport) // Reporte de movimientos por período
+			inventory.GET("/low-stock", stockAlertHandler.GetLowStock)          // Productos y variantes en el umbral de stock bajo o agotados
+			inventory.GET("/alerts", stockAlertHandler.GetAlerts)               // Alertas de stock bajo y agotado
+			inventory.PATCH("/alerts/:id/read", stockAlertHandler.MarkAlertRead) // Marcar alerta como vista
 		}
 
 		// Rutas de carousel slides
@@ -99,14 +221,74 @@ func SetupRoutes(router *gin.Engine) {
 		// Rutas de Orders
 		orders := api.Group("/orders")
 		{
-			orders.POST("", middleware.AuthRequired(), orderHandler.CreateOrder)
+			orders.POST("", middleware.AuthRequired(), idempotency, orderHandler.CreateOrder)
 			orders.GET("", middleware.AuthRequired(), orderHandler.GetOrders)
 			orders.GET("/:id", middleware.AuthRequired(), orderHandler.GetOrder)
+			orders.GET("/:id/history", middleware.AuthRequired(), orderHandler.GetOrderHistory)
+			orders.GET("/:id/invoice.pdf", middleware.AuthRequired(), orderDocumentHandler.GetInvoice)
+			orders.GET("/:id/packing-slip.pdf", middleware.AuthRequired(), orderDocumentHandler.GetPackingSlip)
+			orders.GET("/:id/payments", middleware.AuthRequired(), orderHandler.GetOrderPayments)
+			orders.POST("/:id/payments", middleware.AuthRequired(), orderHandler.AddPayment)
+			orders.POST("/:id/refunds", middleware.AuthRequired(), orderHandler.AddRefund)
+			orders.GET("/:id/revisions", middleware.AuthRequired(), orderHandler.GetOrderRevisions)
+			orders.GET("/:id/returns", middleware.AuthRequired(), returnHandler.GetOrderReturns)
+			orders.POST("/:id/returns", middleware.AuthRequired(), returnHandler.CreateReturn)
+			orders.GET("/:id/shipments", middleware.AuthRequired(), shipmentHandler.GetOrderShipments)
+			orders.POST("/:id/shipments", middleware.AuthRequired(), shipmentHandler.CreateShipment)
+			orders.PUT("/:id/shipments/:shipmentId", middleware.AuthRequired(), shipmentHandler.UpdateShipment)
+			orders.GET("/:id/shipments/:shipmentId/label", middleware.AuthRequired(), shipmentHandler.GetLabel)
+			orders.POST("/:id/shipments/:shipmentId/label", middleware.AuthRequired(), shipmentHandler.UploadLabel)
 			orders.PUT("/:id", middleware.AuthRequired(), orderHandler.UpdateOrder)
+			orders.PUT("/:id/items", middleware.AuthRequired(), orderHandler.UpdateOrderItems)
 			orders.PATCH("/:id/status", middleware.AuthRequired(), orderHandler.UpdateStatus)
+			orders.POST("/:id/tracking-token", middleware.AuthRequired(), orderHandler.RegenerateTrackingToken)
+			orders.GET("/:id/whatsapp-link", middleware.AuthRequired(), whatsAppHandler.GetOrderLink)
+			orders.GET("/:id/notifications", middleware.AuthRequired(), notificationHandler.GetOrderNotifications)
+			orders.POST("/:id/payment-preference", middleware.AuthRequired(), paymentHandler.CreateOrderPreference)
 			orders.DELETE("/:id", middleware.AuthRequired(), orderHandler.DeleteOrder)
 		}
 
+		// Rutas de cupones de descuento (admin)
+		coupons := api.Group("/coupons")
+		coupons.Use(middleware.AuthRequired())
+		{
+			coupons.GET("", couponHandler.GetCoupons)
+			coupons.GET("/:id", couponHandler.GetCoupon)
+			coupons.POST("", couponHandler.CreateCoupon)
+			coupons.PUT("/:id", couponHandler.UpdateCoupon)
+			coupons.DELETE("/:id", couponHandler.DeleteCoupon)
+		}
+
+		// Rutas de clientes (admin)
+		customers := api.Group("/customers")
+		customers.Use(middleware.AuthRequired())
+		{
+			customers.GET("", customerHandler.GetCustomers)
+			customers.GET("/:id", customerHandler.GetCustomer)
+			customers.PUT("/:id", customerHandler.UpdateCustomer)
+			customers.POST("/:id/merge", customerHandler.MergeCustomer)
+		}
+
+		// Checkout público de la tienda (sin auth, con límite de pedidos por IP)
+		api.POST("/checkout", middleware.RateLimit(10, time.Minute), idempotency, checkoutHandler.Checkout)
+		api.POST("/checkout/:reference/mercadopago", middleware.RateLimit(10, time.Minute), paymentHandler.CreateCheckoutPreference)
+
+		// Seguimiento público del pedido (sin auth: el token no adivinable es la credencial)
+		api.GET("/public/orders/:token", middleware.RateLimit(60, time.Minute), orderHandler.GetPublicTracking)
+
+		// Webhook de Mercado Pago (sin auth: se valida la firma y se consulta el pago a la API)
+		api.POST("/webhooks/mercadopago", paymentHandler.MercadoPagoWebhook)
+
+		// Medios de pago (públicos: la tienda muestra recargos, descuentos y cuotas)
+		paymentMethods := api.Group("/payment-methods")
+		{
+			paymentMethods.GET("", paymentMethodHandler.GetPaymentMethods)
+			paymentMethods.GET("/quote", paymentMethodHandler.QuotePayment)
+		}
+
+		// Envíos (público: la tienda cotiza antes de confirmar el pedido)
+		api.POST("/shipping/quote", shippingHandler.QuoteShipping)
+
 		// Rutas de configuración
 		config := api.Group("/config")
 		{
@@ -0,0 +1,11 @@
+package services
+
+import "tiendaedgar/backend/models"
+
+// CarrierTracker consulta el estado de un envío en el sistema de un transportista. Cada
+// transportista integrado se registra en ShipmentService con su código (ej. "andreani");
+// en tests se usa carriers.Fake.
+type CarrierTracker interface {
+	// TrackShipment devuelve el estado actual del envío con ese número de seguimiento
+	TrackShipment(trackingNumber string) (*models.CarrierStatus, error)
+}
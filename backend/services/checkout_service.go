@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// checkoutActor identifica en el historial y el ledger a los pedidos de la tienda
+var checkoutActor = models.SystemActor("checkout")
+
+// reservationExpiryActor identifica las cancelaciones de pedidos del checkout sin pago
+var reservationExpiryActor = models.SystemActor("vencimiento-reserva")
+
+// CheckoutService convierte el carrito de un cliente en un pedido Pendiente
+type CheckoutService struct {
+	productRepo  *repositories.ProductRepository
+	orderService *OrderService
+}
+
+// NewCheckoutService crea una nueva instancia del servicio
+func NewCheckoutService(productRepo *repositories.ProductRepository, orderService *OrderService) *CheckoutService {
+	return &CheckoutService{
+		productRepo:  productRepo,
+		orderService: orderService,
+	}
+}
+
+// Checkout valida el carrito y crea el pedido. Nombres, precios y totales los calcula
+// OrderService desde el catálogo (ver PriceOrder): el cliente solo indica qué compra.
+func (s *CheckoutService) Checkout(req *models.CheckoutRequest) (*models.Order, error) {
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	order := &models.Order{
+		CustomerName:       req.CustomerName,
+		CustomerEmail:      req.CustomerEmail,
+		CustomerPhone:      req.CustomerPhone,
+		CustomerAddress:    req.CustomerAddress,
+		ShippingMethod:     req.ShippingMethod,
+		ShippingPostalCode: req.PostalCode,
+		ShippingProvince:   req.Province,
+		PaymentMethod:      req.PaymentMethod,
+		Installments:       req.Installments,
+		CouponCode:         req.CouponCode,
+		Notes:              req.Notes,
+		Status:             models.OrderStatusPending,
+	}
+
+	for _, cartItem := range req.Items {
+		if err := s.ensureAvailable(cartItem.ProductID); err != nil {
+			return nil, err
+		}
+		order.Items = append(order.Items, models.OrderItem{
+			ProductID: cartItem.ProductID,
+			VariantID: cartItem.VariantID,
+			Talla:     cartItem.Talla,
+			Color:     cartItem.Color,
+			Quantity:  cartItem.Quantity,
+		})
+	}
+
+	if err := s.orderService.CreateOrder(order, checkoutActor); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ensureAvailable verifica que el producto exista y esté publicado en la tienda
+func (s *CheckoutService) ensureAvailable(productID uint) error {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return fmt.Errorf("error al verificar producto %d: %w", productID, err)
+	}
+	if product == nil || !product.Activo {
+		return fmt.Errorf("producto %d no disponible", productID)
+	}
+	return nil
+}
+
+// ExpireReservations cancela los pedidos del checkout que siguen Pendiente, sin pagos ni
+// cambios, después de ttl. La cancelación pasa por el flujo normal de estados: el stock
+// vuelve al catálogo y queda registrado en el ledger y en el historial.
+// Devuelve la cantidad de pedidos cancelados.
+func (s *CheckoutService) ExpireReservations(ttl time.Duration) (int, error) {
+	ids, err := s.orderService.repo.GetStalePendingIDs(checkoutActor.Username, time.Now().Add(-ttl))
+	if err != nil {
+		return 0, err
+	}
+
+	note := fmt.Sprintf("Reserva vencida: sin pago después de %s", ttl)
+	expired := 0
+	for _, id := range ids {
+		cancelled := false
+		err := s.orderService.uow.Do(func(repos *repositories.TxRepositories) error {
+			order, err := repos.Orders.GetByID(id)
+			if err != nil {
+				return fmt.Errorf("error al obtener orden: %w", err)
+			}
+			// Pudo pagarse o cambiar de estado desde la búsqueda
+			if order == nil || order.Status != models.OrderStatusPending {
+				return nil
+			}
+			if err := changeOrderStatus(repos, order, models.OrderStatusCancelled, reservationExpiryActor, note); err != nil {
+				return err
+			}
+			cancelled = true
+			return nil
+		})
+		if err != nil {
+			// Un pedido que no se puede cancelar no frena al resto
+			log.Printf("Error al cancelar el pedido %d con la reserva vencida: %v", id, err)
+			continue
+		}
+		if cancelled {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// StartExpiry ejecuta ExpireReservations cada interval en segundo plano. Si ttl es 0 las
+// reservas no vencen: no hace nada y lo avisa en el log.
+func (s *CheckoutService) StartExpiry(ttl, interval time.Duration) {
+	if ttl <= 0 {
+		log.Printf("Vencimiento de reservas del checkout deshabilitado (CHECKOUT_RESERVATION_TTL=0)")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := s.ExpireReservations(ttl)
+			if err != nil {
+				log.Printf("Error al cancelar reservas vencidas: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Checkout: %d pedidos sin pago cancelados por reserva vencida", expired)
+			}
+		}
+	}()
+	log.Printf("Reservas del checkout vencen a las %s (revisión cada %s)", ttl, interval)
+}
@@ -1,11 +1,17 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+
 	"tiendaedgar/backend/database"
 	"tiendaedgar/backend/models"
 	"tiendaedgar/backend/repositories"
 )
 
+// ErrInvalidConfig indica que los datos de la configuración no son válidos
+var ErrInvalidConfig = errors.New("configuración inválida")
+
 type ConfigService struct {
 	repo *repositories.ConfigRepository
 }
@@ -21,6 +27,66 @@ func (s *ConfigService) GetConfig() (*models.SiteConfig, error) {
 }
 
 func (s *ConfigService) UpdateConfig(config *models.SiteConfig) error {
-	// Add potential validation logic here (e.g. valid phone number format)
+	// Si no se envían medios de pago o métodos de envío se conservan los actuales
+	if config.PaymentMethods == nil || config.ShippingMethods == nil {
+		current, err := s.repo.GetConfig()
+		if err != nil {
+			return err
+		}
+		if config.PaymentMethods == nil {
+			config.PaymentMethods = current.PaymentMethods
+		}
+		if config.ShippingMethods == nil {
+			config.ShippingMethods = current.ShippingMethods
+		}
+	}
+
+	if err := normalizeConfig(config); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
 	return s.repo.UpdateConfig(config)
 }
+
+// normalizeConfig normaliza y valida los datos cargados por el admin
+func normalizeConfig(config *models.SiteConfig) error {
+	// Add potential validation logic here (e.g. valid phone number format)
+	if config.CreditCardSurcharge < 0 {
+		return fmt.Errorf("el recargo de crédito no puede ser negativo")
+	}
+
+	if config.FreeShippingOver < 0 {
+		return fmt.Errorf("el mínimo para envío gratis no puede ser negativo")
+	}
+
+	config.NormalizeOrderNumbering()
+	if err := config.ValidateOrderNumbering(); err != nil {
+		return err
+	}
+
+	config.NormalizeWhatsAppMessages()
+	if err := config.ValidateWhatsAppMessages(); err != nil {
+		return err
+	}
+
+	for i := range config.PaymentMethods {
+		if err := config.PaymentMethods[i].Validate(); err != nil {
+			return err
+		}
+	}
+	config.NormalizePaymentMethods()
+
+	config.NormalizeShippingMethods()
+	seen := map[models.ShippingMethodCode]bool{}
+	for i := range config.ShippingMethods {
+		method := &config.ShippingMethods[i]
+		if err := method.Validate(); err != nil {
+			return err
+		}
+		if seen[method.Code] {
+			return fmt.Errorf("el método de envío %s está repetido", method.Code)
+		}
+		seen[method.Code] = true
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// CouponService maneja la lógica de negocio de cupones de descuento
+type CouponService struct {
+	repo *repositories.CouponRepository
+}
+
+// NewCouponService crea una nueva instancia del servicio
+func NewCouponService(repo *repositories.CouponRepository) *CouponService {
+	return &CouponService{repo: repo}
+}
+
+// GetAllCoupons obtiene todos los cupones
+func (s *CouponService) GetAllCoupons() ([]models.Coupon, error) {
+	return s.repo.GetAll()
+}
+
+// GetCouponByID obtiene un cupón por su ID
+func (s *CouponService) GetCouponByID(id uint) (*models.Coupon, error) {
+	coupon, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cupón: %w", err)
+	}
+	if coupon == nil {
+		return nil, fmt.Errorf("cupón no encontrado")
+	}
+	return coupon, nil
+}
+
+// CreateCoupon crea un nuevo cupón con validaciones
+func (s *CouponService) CreateCoupon(coupon *models.Coupon) error {
+	coupon.Normalize()
+	if err := coupon.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Create(coupon)
+}
+
+// UpdateCoupon actualiza la definición de un cupón. Los pedidos ya registrados
+// conservan el descuento con el que se crearon.
+func (s *CouponService) UpdateCoupon(id uint, coupon *models.Coupon) error {
+	existing, err := s.GetCouponByID(id)
+	if err != nil {
+		return err
+	}
+
+	coupon.ID = id
+	coupon.Normalize()
+	if err := coupon.Validate(); err != nil {
+		return err
+	}
+	if err := s.repo.Update(coupon); err != nil {
+		return err
+	}
+
+	coupon.UsageCount = existing.UsageCount
+	coupon.CreatedAt = existing.CreatedAt
+	return nil
+}
+
+// DeleteCoupon elimina un cupón
+func (s *CouponService) DeleteCoupon(id uint) error {
+	return s.repo.Delete(id)
+}
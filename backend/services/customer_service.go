@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// CustomerService maneja la lógica de negocio de clientes
+type CustomerService struct {
+	repo      *repositories.CustomerRepository
+	orderRepo *repositories.OrderRepository
+	uow       *repositories.UnitOfWork
+}
+
+// NewCustomerService crea una nueva instancia del servicio
+func NewCustomerService(repo *repositories.CustomerRepository, orderRepo *repositories.OrderRepository, uow *repositories.UnitOfWork) *CustomerService {
+	return &CustomerService{repo: repo, orderRepo: orderRepo, uow: uow}
+}
+
+// GetAllCustomers obtiene clientes con paginación y búsqueda por nombre, email o teléfono
+func (s *CustomerService) GetAllCustomers(page, limit int, search string) ([]models.Customer, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	return s.repo.GetAll(limit, (page-1)*limit, search)
+}
+
+// GetCustomerByID obtiene un cliente con sus estadísticas y su historial de pedidos
+func (s *CustomerService) GetCustomerByID(id uint) (*models.Customer, error) {
+	customer, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cliente: %w", err)
+	}
+	if customer == nil {
+		return nil, fmt.Errorf("cliente no encontrado")
+	}
+
+	orders, err := s.orderRepo.GetByCustomerID(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pedidos del cliente: %w", err)
+	}
+	customer.Orders = orders
+	return customer, nil
+}
+
+// UpdateCustomer actualiza los datos de contacto y las notas de un cliente.
+// Los pedidos ya registrados conservan los datos con los que se crearon.
+func (s *CustomerService) UpdateCustomer(id uint, customer *models.Customer) error {
+	if _, err := s.GetCustomerByID(id); err != nil {
+		return err
+	}
+
+	customer.ID = id
+	customer.Normalize()
+	if err := customer.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Update(customer)
+}
+
+// MergeCustomers une dos fichas del mismo cliente: los pedidos de source pasan a target,
+// que completa con los datos de source los que le falten, y source se elimina
+func (s *CustomerService) MergeCustomers(targetID, sourceID uint) (*models.Customer, error) {
+	if targetID == sourceID {
+		return nil, fmt.Errorf("no se puede unir un cliente consigo mismo")
+	}
+
+	err := s.uow.Do(func(repos *repositories.TxRepositories) error {
+		target, err := repos.Customers.GetByID(targetID)
+		if err != nil {
+			return err
+		}
+		source, err := repos.Customers.GetByID(sourceID)
+		if err != nil {
+			return err
+		}
+		if target == nil || source == nil {
+			return fmt.Errorf("cliente no encontrado")
+		}
+
+		// target conserva su nombre y sus datos; de source solo se toma lo que le falte
+		if target.Email == "" {
+			target.Email = source.Email
+		}
+		if models.NormalizePhone(target.Phone) == "" {
+			target.Phone = source.Phone
+		}
+		if target.Address == "" {
+			target.Address = source.Address
+		}
+		if target.Notes == "" {
+			target.Notes = source.Notes
+		}
+		if err := repos.Customers.Update(target); err != nil {
+			return err
+		}
+		return repos.Customers.Merge(targetID, sourceID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCustomerByID(targetID)
+}
@@ -0,0 +1,10 @@
+package services
+
+import "tiendaedgar/backend/models"
+
+// EmailSender envía emails. La implementación real es mailer.SMTPSender; en desarrollo y
+// en tests se apunta al servidor de prueba mailer.Sink.
+type EmailSender interface {
+	// Send entrega el mensaje a todos sus destinatarios (To y Bcc)
+	Send(msg models.EmailMessage) error
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// Límites del envío de la cola de emails
+const (
+	notificationBatchSize = 50
+	notificationMaxAge    = 48 * time.Hour // Los emails más viejos se descartan (ej. el SMTP estuvo caído días)
+)
+
+// NotificationService envía los emails de los pedidos encolados en order_notifications
+// (ver recordStatusChange) al cliente y una copia oculta a la tienda
+type NotificationService struct {
+	notificationRepo *repositories.OrderNotificationRepository
+	orderService     *OrderService
+	shipmentRepo     *repositories.ShipmentRepository
+	configRepo       *repositories.ConfigRepository
+	sender           EmailSender // nil si no hay servidor SMTP configurado
+	adminEmail       string      // Copia para la tienda (puede estar vacía)
+	storeURL         string      // URL de la tienda, para el enlace al seguimiento (puede estar vacía)
+}
+
+// NewNotificationService crea una nueva instancia del servicio
+func NewNotificationService(notificationRepo *repositories.OrderNotificationRepository, orderService *OrderService, shipmentRepo *repositories.ShipmentRepository, configRepo *repositories.ConfigRepository, sender EmailSender, adminEmail, storeURL string) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		orderService:     orderService,
+		shipmentRepo:     shipmentRepo,
+		configRepo:       configRepo,
+		sender:           sender,
+		adminEmail:       strings.TrimSpace(adminEmail),
+		storeURL:         strings.TrimRight(storeURL, "/"),
+	}
+}
+
+// GetOrderNotifications obtiene los emails de un pedido y su estado de envío
+func (s *NotificationService) GetOrderNotifications(orderID uint) ([]models.OrderNotification, error) {
+	order, err := s.orderService.GetOrderByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+	return s.notificationRepo.GetByOrderID(orderID)
+}
+
+// DispatchPending envía los emails en cola y devuelve cuántos se enviaron. Si las alertas
+// de pedidos están deshabilitadas en la configuración, o el pedido no tiene a quién
+// avisar, la notificación se descarta con el motivo; los errores de envío se reintentan
+// en la siguiente pasada hasta models.MaxNotificationAttempts.
+func (s *NotificationService) DispatchPending() (int, error) {
+	if s.sender == nil {
+		return 0, nil
+	}
+
+	pending, err := s.notificationRepo.GetPending(notificationBatchSize)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	config, err := s.configRepo.GetConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, notification := range pending {
+		if !config.EnableOrderAlerts {
+			s.skip(notification, "alertas de pedidos deshabilitadas")
+			continue
+		}
+		if time.Since(notification.CreatedAt) > notificationMaxAge {
+			s.skip(notification, "notificación vencida")
+			continue
+		}
+
+		msg, reason, err := s.buildMessage(notification, config)
+		if err != nil {
+			s.fail(notification, err)
+			continue
+		}
+		if msg == nil {
+			s.skip(notification, reason)
+			continue
+		}
+
+		if err := s.sender.Send(*msg); err != nil {
+			s.fail(notification, err)
+			continue
+		}
+		if err := s.notificationRepo.MarkSent(notification.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// buildMessage arma el email de la notificación. Devuelve nil y el motivo si no hay que enviarlo.
+func (s *NotificationService) buildMessage(notification models.OrderNotification, config *models.SiteConfig) (*models.EmailMessage, string, error) {
+	order, err := s.orderService.GetOrderByID(notification.OrderID)
+	if err != nil {
+		return nil, "", err
+	}
+	if order == nil {
+		return nil, "el pedido ya no existe", nil
+	}
+
+	var to, bcc []string
+	if address, err := mail.ParseAddress(order.CustomerEmail); err == nil {
+		to = append(to, address.Address)
+	}
+	if s.adminEmail != "" {
+		bcc = append(bcc, s.adminEmail)
+	}
+	if len(to) == 0 {
+		if len(bcc) == 0 {
+			return nil, "el pedido no tiene un email válido", nil
+		}
+		to, bcc = bcc, nil
+	}
+
+	var shipment *models.Shipment
+	if notification.Kind == models.OrderNotificationShipped {
+		shipments, err := s.shipmentRepo.GetByOrderID(order.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(shipments) > 0 {
+			shipment = &shipments[len(shipments)-1]
+		}
+	}
+
+	msg, err := RenderOrderEmail(NewOrderEmail(notification.Kind, order, shipment, config.StoreName, orderTrackingURL(s.storeURL, order)))
+	if err != nil {
+		return nil, "", err
+	}
+	msg.To, msg.Bcc = to, bcc
+	return msg, "", nil
+}
+
+func (s *NotificationService) skip(notification models.OrderNotification, reason string) {
+	if err := s.notificationRepo.MarkSkipped(notification.ID, reason); err != nil {
+		log.Printf("Error al descartar notificación %d: %v", notification.ID, err)
+	}
+}
+
+func (s *NotificationService) fail(notification models.OrderNotification, cause error) {
+	log.Printf("Error al enviar email %s del pedido %d: %v", notification.Kind, notification.OrderID, cause)
+	if err := s.notificationRepo.MarkFailed(notification.ID, cause); err != nil {
+		log.Printf("Error al registrar el fallo de la notificación %d: %v", notification.ID, err)
+	}
+}
+
+// StartDispatcher ejecuta DispatchPending cada interval en segundo plano. No hace nada si
+// no hay servidor SMTP configurado.
+func (s *NotificationService) StartDispatcher(interval time.Duration) {
+	if s.sender == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.DispatchPending(); err != nil {
+				log.Printf("Error al enviar emails de pedidos: %v", err)
+			}
+		}
+	}()
+	log.Printf("Envío de emails de pedidos cada %s", interval)
+}
+
+// orderTrackingURL devuelve el enlace al seguimiento público del pedido, o "" si no hay URL de la tienda
+func orderTrackingURL(storeURL string, order *models.Order) string {
+	if storeURL == "" || order.TrackingToken == "" {
+		return ""
+	}
+	return storeURL + "/seguimiento/" + order.TrackingToken
+}
@@ -0,0 +1,497 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // Decodificadores para el logo de la tienda
+	_ "image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/pdf"
+	"tiendaedgar/backend/repositories"
+)
+
+// Márgenes y medidas de los comprobantes (en puntos, ver pdf.PageWidth)
+const (
+	docMargin     = 40.0
+	docRight      = pdf.PageWidth - docMargin
+	docBottom     = pdf.PageHeight - 60 // Límite inferior del contenido (debajo va el pie)
+	docRowHeight  = 18.0
+	docLogoHeight = 56.0
+)
+
+// OrderDocument es un PDF generado para un pedido
+type OrderDocument struct {
+	Filename string
+	Content  []byte
+}
+
+// OrderDocumentService genera el comprobante y el remito de los pedidos en PDF, a partir
+// de los snapshots del pedido (precios y nombres al momento de la compra) y de SiteConfig
+type OrderDocumentService struct {
+	orderService *OrderService
+	configRepo   *repositories.ConfigRepository
+	uploadsDir   string // Carpeta servida en /uploads, de donde se lee el logo
+}
+
+// NewOrderDocumentService crea una nueva instancia del servicio
+func NewOrderDocumentService(orderService *OrderService, configRepo *repositories.ConfigRepository, uploadsDir string) *OrderDocumentService {
+	return &OrderDocumentService{
+		orderService: orderService,
+		configRepo:   configRepo,
+		uploadsDir:   uploadsDir,
+	}
+}
+
+// Invoice genera el comprobante de compra: productos con precios, descuentos, recargos,
+// envío, total y estado de pago. No es una factura fiscal.
+func (s *OrderDocumentService) Invoice(orderID uint) (*OrderDocument, error) {
+	order, config, err := s.load(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	w := s.newDocumentWriter(config, "COMPROBANTE", order)
+	w.customerAndShipping(order, config)
+
+	columns := []docColumn{
+		{Title: "Producto", X: docMargin + 6},
+		{Title: "Cant.", X: 360, Right: true},
+		{Title: "Precio unit.", X: 460, Right: true},
+		{Title: "Subtotal", X: docRight - 6, Right: true},
+	}
+	w.table(columns, len(order.Items), func(i int) []string {
+		item := order.Items[i]
+		return []string{
+			fitText(itemDescription(item), pdf.Regular, 9, 300),
+			fmt.Sprintf("%d", item.Quantity),
+			FormatMoney(item.UnitPrice),
+			FormatMoney(item.Subtotal),
+		}
+	})
+
+	// Totales
+	w.space(8)
+	w.total("Subtotal", order.Subtotal, false)
+	if order.Discount > 0 {
+		label := "Descuento"
+		if order.CouponCode != "" {
+			label += " (" + order.CouponCode + ")"
+		}
+		w.total(label, -order.Discount, false)
+	}
+	if order.Surcharge > 0 {
+		w.total("Recargo "+paymentLabel(config, order), order.Surcharge, false)
+	} else if order.Surcharge < 0 {
+		w.total("Descuento "+paymentLabel(config, order), order.Surcharge, false)
+	}
+	if order.ShippingMethod != "" {
+		w.total("Envío", order.ShippingCost, false)
+	}
+	w.total("Total", order.TotalAmount, true)
+	if order.ReturnAdjustment != 0 {
+		w.total("Devoluciones y cambios", -order.ReturnAdjustment, false)
+		w.total("Total a pagar", order.AmountDue(), true)
+	}
+
+	// Pago
+	w.space(14)
+	w.section("Pago")
+	payment := paymentLabel(config, order)
+	if order.Installments > 1 {
+		payment += fmt.Sprintf(" en %d cuotas", order.Installments)
+	}
+	w.line("Medio de pago: " + payment)
+	if order.PaymentSummary != nil {
+		w.line("Pagado: " + FormatMoney(order.AmountPaid))
+		w.line("Saldo pendiente: " + FormatMoney(order.BalanceDue))
+	}
+	w.line("Estado del pedido: " + string(order.Status))
+
+	w.notes(order.Notes)
+	return w.finish("comprobante-" + order.DisplayNumber() + ".pdf")
+}
+
+// PackingSlip genera el remito para preparar y despachar el pedido: destinatario, envío
+// y productos con talla y color, sin precios
+func (s *OrderDocumentService) PackingSlip(orderID uint) (*OrderDocument, error) {
+	order, config, err := s.load(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	w := s.newDocumentWriter(config, "REMITO", order)
+	w.customerAndShipping(order, config)
+
+	columns := []docColumn{
+		{Title: "", X: docMargin + 6},
+		{Title: "Producto", X: docMargin + 26},
+		{Title: "Talla", X: 330},
+		{Title: "Color", X: 400},
+		{Title: "Cant.", X: docRight - 6, Right: true},
+	}
+	units := 0
+	w.table(columns, len(order.Items), func(i int) []string {
+		item := order.Items[i]
+		units += item.Quantity
+		return []string{
+			"[   ]",
+			fitText(item.ProductName, pdf.Regular, 9, 270),
+			fitText(item.Talla, pdf.Regular, 9, 60),
+			fitText(item.Color, pdf.Regular, 9, 80),
+			fmt.Sprintf("%d", item.Quantity),
+		}
+	})
+
+	w.space(8)
+	w.ensureSpace(docRowHeight)
+	w.doc.TextRight(docRight-6, w.y+12, pdf.Bold, 10, fmt.Sprintf("Total de unidades: %d", units))
+	w.y += docRowHeight
+
+	w.notes(order.Notes)
+
+	// Conformidad de entrega
+	w.space(40)
+	w.ensureSpace(30)
+	w.doc.Line(docMargin, w.y, docMargin+200, w.y, 0.5, 0)
+	w.doc.Line(docRight-200, w.y, docRight, w.y, 0.5, 0)
+	w.doc.Text(docMargin, w.y+12, pdf.Regular, 8, "Firma y aclaración")
+	w.doc.Text(docRight-200, w.y+12, pdf.Regular, 8, "Fecha de entrega")
+	w.y += 20
+
+	return w.finish("remito-" + order.DisplayNumber() + ".pdf")
+}
+
+// load obtiene el pedido (con pagos) y la configuración de la tienda
+func (s *OrderDocumentService) load(orderID uint) (*models.Order, *models.SiteConfig, error) {
+	order, err := s.orderService.GetOrderByID(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order == nil {
+		return nil, nil, fmt.Errorf("orden no encontrada")
+	}
+	config, err := s.configRepo.GetConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, config, nil
+}
+
+// loadLogo lee el logo de la tienda si es un archivo subido (/uploads/...) en PNG o JPG.
+// Los logos externos (URLs absolutas) o en otros formatos se omiten.
+func (s *OrderDocumentService) loadLogo(logoURL string) image.Image {
+	if !strings.HasPrefix(logoURL, "/uploads/") {
+		return nil
+	}
+	path := filepath.Join(s.uploadsDir, filepath.Base(logoURL))
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("WARN: no se pudo abrir el logo %s: %v", path, err)
+		return nil
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		log.Printf("WARN: logo %s en formato no soportado para PDF: %v", path, err)
+		return nil
+	}
+	return img
+}
+
+// docColumn es una columna de la tabla de productos
+type docColumn struct {
+	Title string
+	X     float64 // Borde izquierdo, o derecho si Right
+	Right bool
+}
+
+// documentWriter dibuja un comprobante de arriba hacia abajo, agregando páginas
+// (con el encabezado del pedido) cuando el contenido no entra
+type documentWriter struct {
+	doc    *pdf.Document
+	config *models.SiteConfig
+	title  string
+	order  *models.Order
+	logo   pdf.ImageID
+	logoW  float64 // Ancho del logo en el encabezado; 0 si no hay logo
+	y      float64 // Posición vertical actual
+}
+
+func (s *OrderDocumentService) newDocumentWriter(config *models.SiteConfig, title string, order *models.Order) *documentWriter {
+	w := &documentWriter{
+		doc:    pdf.New(),
+		config: config,
+		title:  title,
+		order:  order,
+	}
+	if logo := s.loadLogo(config.LogoURL); logo != nil {
+		id, err := w.doc.AddImage(logo)
+		if err != nil {
+			log.Printf("WARN: no se pudo agregar el logo al PDF: %v", err)
+		} else {
+			bounds := logo.Bounds()
+			w.logo = id
+			w.logoW = math.Min(docLogoHeight*float64(bounds.Dx())/float64(bounds.Dy()), 140)
+		}
+	}
+	w.newPage()
+	return w
+}
+
+// newPage agrega una página con el encabezado: logo, tienda, tipo de documento, número y fecha
+func (w *documentWriter) newPage() {
+	w.doc.AddPage()
+
+	textX := docMargin
+	if w.logoW > 0 {
+		w.doc.DrawImage(w.logo, docMargin, docMargin, w.logoW, docLogoHeight)
+		textX += w.logoW + 12
+	}
+	w.doc.Text(textX, docMargin+20, pdf.Bold, 16, fitText(w.config.StoreName, pdf.Bold, 16, 300-textX))
+	w.doc.Text(textX, docMargin+36, pdf.Regular, 9, fitText(w.config.Description, pdf.Regular, 9, 330-textX))
+
+	w.doc.TextRight(docRight, docMargin+16, pdf.Bold, 14, w.title)
+	w.doc.TextRight(docRight, docMargin+34, pdf.Bold, 11, "Pedido N° "+w.order.DisplayNumber())
+	w.doc.TextRight(docRight, docMargin+48, pdf.Regular, 9, "Fecha: "+w.order.CreatedAt.In(time.Local).Format("02/01/2006"))
+	if page := w.doc.PageCount(); page > 1 {
+		w.doc.TextRight(docRight, docMargin+60, pdf.Regular, 8, fmt.Sprintf("Página %d", page))
+	}
+
+	w.doc.Line(docMargin, docMargin+docLogoHeight+14, docRight, docMargin+docLogoHeight+14, 1, 0.6)
+	w.doc.Text(docMargin, pdf.PageHeight-30, pdf.Regular, 7, "Documento no válido como factura.")
+	w.y = docMargin + docLogoHeight + 34
+}
+
+// ensureSpace pasa a una página nueva si no entran height puntos más
+func (w *documentWriter) ensureSpace(height float64) bool {
+	if w.y+height <= docBottom {
+		return false
+	}
+	w.newPage()
+	return true
+}
+
+func (w *documentWriter) space(height float64) {
+	w.y += height
+}
+
+// section escribe el título de una sección
+func (w *documentWriter) section(title string) {
+	w.ensureSpace(32)
+	w.doc.Text(docMargin, w.y, pdf.Bold, 10, title)
+	w.y += 14
+}
+
+// line escribe una línea de texto
+func (w *documentWriter) line(text string) {
+	w.ensureSpace(12)
+	w.doc.Text(docMargin, w.y, pdf.Regular, 9, fitText(text, pdf.Regular, 9, docRight-docMargin))
+	w.y += 12
+}
+
+// customerAndShipping escribe los datos del cliente y del envío en dos columnas
+func (w *documentWriter) customerAndShipping(order *models.Order, config *models.SiteConfig) {
+	const column = 300.0
+
+	customer := []string{order.CustomerName}
+	if order.CustomerPhone != "" {
+		customer = append(customer, "Tel.: "+order.CustomerPhone)
+	}
+	if order.CustomerEmail != "" {
+		customer = append(customer, order.CustomerEmail)
+	}
+	if order.CustomerAddress != "" {
+		customer = append(customer, order.CustomerAddress)
+	}
+
+	shipping := []string{shippingLabel(config, order)}
+	destination := order.ShippingPostalCode
+	if order.ShippingProvince != "" {
+		if destination != "" {
+			destination += " - "
+		}
+		destination += order.ShippingProvince
+	}
+	if destination != "" {
+		shipping = append(shipping, "CP "+destination)
+	}
+
+	w.doc.Text(docMargin, w.y, pdf.Bold, 10, "Cliente")
+	w.doc.Text(column, w.y, pdf.Bold, 10, "Envío")
+	y := w.y + 14
+	for i := 0; i < len(customer) || i < len(shipping); i++ {
+		if i < len(customer) {
+			w.doc.Text(docMargin, y, pdf.Regular, 9, fitText(customer[i], pdf.Regular, 9, column-docMargin-10))
+		}
+		if i < len(shipping) {
+			w.doc.Text(column, y, pdf.Regular, 9, fitText(shipping[i], pdf.Regular, 9, docRight-column))
+		}
+		y += 12
+	}
+	w.y = y + 14
+}
+
+// table dibuja la tabla de productos; row devuelve el texto de cada columna de la fila i.
+// Si la tabla sigue en otra página se repite la fila de títulos.
+func (w *documentWriter) table(columns []docColumn, rows int, row func(i int) []string) {
+	header := func() {
+		w.doc.FillRect(docMargin, w.y, docRight-docMargin, docRowHeight, 0.9)
+		for _, col := range columns {
+			w.cell(col, pdf.Bold, col.Title)
+		}
+		w.y += docRowHeight
+	}
+
+	w.ensureSpace(2 * docRowHeight)
+	header()
+	for i := 0; i < rows; i++ {
+		if w.ensureSpace(docRowHeight) {
+			header()
+		}
+		for j, text := range row(i) {
+			w.cell(columns[j], pdf.Regular, text)
+		}
+		w.doc.Line(docMargin, w.y+docRowHeight, docRight, w.y+docRowHeight, 0.5, 0.8)
+		w.y += docRowHeight
+	}
+}
+
+func (w *documentWriter) cell(col docColumn, font pdf.Font, text string) {
+	baseline := w.y + 12
+	if col.Right {
+		w.doc.TextRight(col.X, baseline, font, 9, text)
+	} else {
+		w.doc.Text(col.X, baseline, font, 9, text)
+	}
+}
+
+// total escribe una línea de totales alineada a la derecha
+func (w *documentWriter) total(label string, amount float64, bold bool) {
+	font, size := pdf.Regular, 9.0
+	if bold {
+		font, size = pdf.Bold, 11
+	}
+	w.ensureSpace(16)
+	w.doc.TextRight(440, w.y+12, font, size, label)
+	w.doc.TextRight(docRight-6, w.y+12, font, size, FormatMoney(amount))
+	w.y += 16
+}
+
+// notes escribe las observaciones del pedido (si hay), en varias líneas si hace falta
+func (w *documentWriter) notes(notes string) {
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return
+	}
+	w.space(14)
+	w.section("Observaciones")
+	for _, line := range wrapText(notes, pdf.Regular, 9, docRight-docMargin) {
+		w.line(line)
+	}
+}
+
+func (w *documentWriter) finish(filename string) (*OrderDocument, error) {
+	content, err := w.doc.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("error al generar PDF: %w", err)
+	}
+	return &OrderDocument{Filename: filename, Content: content}, nil
+}
+
+// itemDescription describe un item con su talla y color (ej. "Air Max 90 - Talla 42 / Negro")
+func itemDescription(item models.OrderItem) string {
+	var variant []string
+	if item.Talla != "" {
+		variant = append(variant, "Talla "+item.Talla)
+	}
+	if item.Color != "" {
+		variant = append(variant, item.Color)
+	}
+	if len(variant) == 0 {
+		return item.ProductName
+	}
+	return item.ProductName + " - " + strings.Join(variant, " / ")
+}
+
+// paymentLabel devuelve el nombre del medio de pago del pedido según la configuración
+func paymentLabel(config *models.SiteConfig, order *models.Order) string {
+	if order.PaymentMethod == "" {
+		return "a convenir"
+	}
+	if rule := config.FindPaymentMethod(order.PaymentMethod); rule != nil {
+		return rule.Label
+	}
+	return string(order.PaymentMethod)
+}
+
+// shippingLabel devuelve el nombre del método de envío del pedido según la configuración
+func shippingLabel(config *models.SiteConfig, order *models.Order) string {
+	if order.ShippingMethod == "" {
+		return "Sin envío"
+	}
+	if method := config.FindShippingMethod(order.ShippingMethod); method != nil {
+		return method.Label
+	}
+	return string(order.ShippingMethod)
+}
+
+// FormatMoney formatea un importe en pesos con separadores locales (ej. "$ 12.345,67")
+func FormatMoney(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	integer := fmt.Sprintf("%d", cents/100)
+
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+	return fmt.Sprintf("%s$ %s,%02d", sign, grouped.String(), cents%100)
+}
+
+// fitText recorta el texto con "..." para que no supere el ancho indicado
+func fitText(text string, font pdf.Font, size, width float64) string {
+	if pdf.TextWidth(text, font, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"...", font, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+// wrapText divide el texto en líneas que no superen el ancho indicado
+func wrapText(text string, font pdf.Font, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if current != "" && pdf.TextWidth(candidate, font, size) > width {
+				lines = append(lines, current)
+				candidate = word
+			}
+			current = candidate
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"tiendaedgar/backend/models"
+)
+
+// OrderEmail son los datos de un email al cliente por su pedido, con los importes ya
+// formateados. Los campos vacíos no se muestran.
+type OrderEmail struct {
+	Kind            models.OrderNotificationKind
+	StoreName       string
+	CustomerName    string // Primer nombre, para el saludo
+	OrderNumber     string
+	Items           []OrderEmailItem
+	Discount        string // Descuento del cupón
+	AdjustmentLabel string // Recargo o descuento por medio de pago
+	Adjustment      string
+	ShippingCost    string
+	Total           string
+	Balance         string // Saldo pendiente de pago
+	Carrier         string // Solo en el email de despacho
+	TrackingNumber  string
+	TrackingURL     string // Seguimiento público del pedido
+}
+
+// OrderEmailItem es una línea del detalle del pedido
+type OrderEmailItem struct {
+	Description string
+	Quantity    int
+	Subtotal    string
+}
+
+// NewOrderEmail arma los datos del email a partir del pedido (con su resumen de pagos) y,
+// para el email de despacho, del último envío registrado
+func NewOrderEmail(kind models.OrderNotificationKind, order *models.Order, shipment *models.Shipment, storeName, trackingURL string) OrderEmail {
+	email := OrderEmail{
+		Kind:         kind,
+		StoreName:    storeName,
+		CustomerName: firstName(order.CustomerName),
+		OrderNumber:  order.DisplayNumber(),
+		Total:        FormatMoney(order.AmountDue()),
+		TrackingURL:  trackingURL,
+	}
+	for _, item := range order.Items {
+		email.Items = append(email.Items, OrderEmailItem{
+			Description: itemDescription(item),
+			Quantity:    item.Quantity,
+			Subtotal:    FormatMoney(item.Subtotal),
+		})
+	}
+	if order.Discount > 0 {
+		email.Discount = FormatMoney(order.Discount)
+	}
+	if order.Surcharge > 0 {
+		email.AdjustmentLabel, email.Adjustment = "Recargo por medio de pago", FormatMoney(order.Surcharge)
+	} else if order.Surcharge < 0 {
+		email.AdjustmentLabel, email.Adjustment = "Descuento por medio de pago", "-"+FormatMoney(-order.Surcharge)
+	}
+	if order.ShippingCost > 0 {
+		email.ShippingCost = FormatMoney(order.ShippingCost)
+	}
+	if order.PaymentSummary != nil && order.BalanceDue > 0 {
+		email.Balance = FormatMoney(order.BalanceDue)
+	}
+	if kind == models.OrderNotificationShipped && shipment != nil {
+		email.Carrier = carrierName(shipment.Carrier)
+		email.TrackingNumber = shipment.TrackingNumber
+	}
+	return email
+}
+
+// Subject devuelve el asunto del email
+func (e OrderEmail) Subject() string {
+	switch e.Kind {
+	case models.OrderNotificationPaid:
+		return fmt.Sprintf("Confirmamos el pago de tu pedido %s", e.OrderNumber)
+	case models.OrderNotificationShipped:
+		return fmt.Sprintf("Tu pedido %s está en camino", e.OrderNumber)
+	case models.OrderNotificationDelivered:
+		return fmt.Sprintf("Tu pedido %s fue entregado", e.OrderNumber)
+	}
+	return fmt.Sprintf("Recibimos tu pedido %s", e.OrderNumber)
+}
+
+// Intro devuelve el primer párrafo del email
+func (e OrderEmail) Intro() string {
+	switch e.Kind {
+	case models.OrderNotificationPaid:
+		return fmt.Sprintf("Confirmamos el pago de tu pedido %s. Ya lo estamos preparando.", e.OrderNumber)
+	case models.OrderNotificationShipped:
+		return fmt.Sprintf("Tu pedido %s ya fue despachado.", e.OrderNumber)
+	case models.OrderNotificationDelivered:
+		return fmt.Sprintf("Tu pedido %s fue entregado. ¡Gracias por tu compra!", e.OrderNumber)
+	}
+	return fmt.Sprintf("Recibimos tu pedido %s. Te avisamos cuando confirmemos el pago.", e.OrderNumber)
+}
+
+var orderEmailText = texttemplate.Must(texttemplate.New("text").Parse(`Hola{{if .CustomerName}} {{.CustomerName}}{{end}},
+
+{{.Intro}}
+{{if .Carrier}}
+Transportista: {{.Carrier}}{{if .TrackingNumber}}
+Número de seguimiento: {{.TrackingNumber}}{{end}}
+{{end}}
+Detalle del pedido:
+{{range .Items}}- {{.Quantity}} x {{.Description}}: {{.Subtotal}}
+{{end}}{{if .Discount}}Descuento: -{{.Discount}}
+{{end}}{{if .Adjustment}}{{.AdjustmentLabel}}: {{.Adjustment}}
+{{end}}{{if .ShippingCost}}Envío: {{.ShippingCost}}
+{{end}}Total: {{.Total}}
+{{if .Balance}}Saldo a pagar: {{.Balance}}
+{{end}}{{if .TrackingURL}}
+Seguí tu pedido en {{.TrackingURL}}
+{{end}}
+{{.StoreName}}
+`))
+
+var orderEmailHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html lang="es">
+<body style="font-family: Arial, sans-serif; color: #222; max-width: 600px;">
+<p>Hola{{if .CustomerName}} {{.CustomerName}}{{end}},</p>
+<p>{{.Intro}}</p>
+{{if .Carrier}}<p>Transportista: <strong>{{.Carrier}}</strong>{{if .TrackingNumber}}<br>Número de seguimiento: <strong>{{.TrackingNumber}}</strong>{{end}}</p>
+{{end}}<table style="width: 100%; border-collapse: collapse;">
+<tr><th style="text-align: left;">Producto</th><th style="text-align: right;">Cant.</th><th style="text-align: right;">Subtotal</th></tr>
+{{range .Items}}<tr><td>{{.Description}}</td><td style="text-align: right;">{{.Quantity}}</td><td style="text-align: right;">{{.Subtotal}}</td></tr>
+{{end}}{{if .Discount}}<tr><td colspan="2">Descuento</td><td style="text-align: right;">-{{.Discount}}</td></tr>
+{{end}}{{if .Adjustment}}<tr><td colspan="2">{{.AdjustmentLabel}}</td><td style="text-align: right;">{{.Adjustment}}</td></tr>
+{{end}}{{if .ShippingCost}}<tr><td colspan="2">Envío</td><td style="text-align: right;">{{.ShippingCost}}</td></tr>
+{{end}}<tr><td colspan="2"><strong>Total</strong></td><td style="text-align: right;"><strong>{{.Total}}</strong></td></tr>
+{{if .Balance}}<tr><td colspan="2">Saldo a pagar</td><td style="text-align: right;">{{.Balance}}</td></tr>
+{{end}}</table>
+{{if .TrackingURL}}<p><a href="{{.TrackingURL}}">Seguí tu pedido</a></p>
+{{end}}<p>{{.StoreName}}</p>
+</body>
+</html>
+`))
+
+// RenderOrderEmail arma el email en texto y en HTML (los datos del pedido se escapan en el HTML)
+func RenderOrderEmail(email OrderEmail) (*models.EmailMessage, error) {
+	var text, html bytes.Buffer
+	if err := orderEmailText.Execute(&text, email); err != nil {
+		return nil, fmt.Errorf("error al armar el email: %w", err)
+	}
+	if err := orderEmailHTML.Execute(&html, email); err != nil {
+		return nil, fmt.Errorf("error al armar el email: %w", err)
+	}
+	return &models.EmailMessage{
+		FromName: email.StoreName,
+		Subject:  email.Subject(),
+		Text:     text.String(),
+		HTML:     html.String(),
+	}, nil
+}
+
+// carrierName convierte el código del transportista en un nombre legible (ej. correo_argentino → Correo argentino)
+func carrierName(code string) string {
+	name := strings.ReplaceAll(code, "_", " ")
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
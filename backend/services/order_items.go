@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+var (
+	// ErrOrderNotEditable indica que el pedido ya no admite cambios de productos
+	ErrOrderNotEditable = errors.New("los productos del pedido ya no pueden modificarse")
+	// ErrNoItemChanges indica que la lista enviada es igual a la del pedido
+	ErrNoItemChanges = errors.New("los productos del pedido no cambiaron")
+)
+
+// GetOrderRevisions obtiene la auditoría de cambios de productos de un pedido
+func (s *OrderService) GetOrderRevisions(orderID uint) ([]models.OrderRevision, error) {
+	order, err := s.repo.GetByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+
+	return s.revisionRepo.GetByOrderID(orderID)
+}
+
+// UpdateOrderItems reemplaza los productos de un pedido en una única transacción:
+//   - valida el stock contando lo que el pedido ya tenía reservado
+//   - descuenta o devuelve al stock solo la diferencia de cada variante
+//   - recalcula subtotal, descuento, recargo y total (las líneas que ya estaban
+//     conservan su precio; las nuevas toman el precio vigente). El costo de envío
+//     pactado en el checkout no cambia
+//   - registra el cambio en la auditoría del pedido (ver models.OrderRevision)
+//
+// Si el pedido estaba Pendiente y los cobros ya cubren el nuevo total, pasa a Pagado.
+func (s *OrderService) UpdateOrderItems(orderID uint, items []models.OrderItem, actor models.Actor, note string) (*models.OrderRevision, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("el pedido debe tener al menos un producto")
+	}
+
+	rules, err := s.pricer.Rules()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener reglas de precios: %w", err)
+	}
+
+	var revision *models.OrderRevision
+	err = s.uow.Do(func(repos *repositories.TxRepositories) error {
+		order, err := repos.Orders.GetByID(orderID)
+		if err != nil {
+			return fmt.Errorf("error al obtener orden: %w", err)
+		}
+		if order == nil {
+			return fmt.Errorf("orden no encontrada")
+		}
+		if !order.Status.AllowsItemChanges() {
+			return fmt.Errorf("%w: el pedido está %s", ErrOrderNotEditable, order.Status)
+		}
+		if err := ensureNoReturns(repos, orderID); err != nil {
+			return err
+		}
+
+		// 1. Resolver variantes y validar stock (lo reservado por el pedido sigue disponible para él)
+		reserved := map[string]int{}
+		for _, item := range order.Items {
+			reserved[item.StockKey()] += item.Quantity
+		}
+		products, err := resolveItemsWithReserved(repos, items, reserved)
+		if err != nil {
+			return err
+		}
+
+		changes := models.DiffOrderItems(order.Items, items)
+		if len(changes) == 0 {
+			return ErrNoItemChanges
+		}
+
+		// 2. Recalcular importes con el cupón y el costo de envío originales
+		coupon, err := orderCoupon(repos, order)
+		if err != nil {
+			return err
+		}
+		totalBefore := order.TotalAmount
+		previous := order.Items
+		order.Items = items
+		if err := RepriceOrderItems(order, snapshotPrices(products, previous), rules, coupon); err != nil {
+			return err
+		}
+
+		// 3. Ajustar el stock por la diferencia de cada variante
+		movement := models.NewStockMovement(models.StockMovementOrderEdit, actor, orderEditNote(note)).ForOrder(order.ID)
+		for i := range changes {
+			change := &changes[i]
+			if product, ok := products[change.ProductID]; ok && change.ProductName == "" {
+				change.ProductName = product.Nombre
+			}
+			item := models.OrderItem{
+				ProductID:   change.ProductID,
+				VariantID:   change.VariantID,
+				Talla:       change.Talla,
+				Color:       change.Color,
+				ProductName: change.ProductName,
+			}
+			if delta := change.Delta(); delta > 0 {
+				item.Quantity = delta
+				if err := reduceItemStock(repos, item, movement); err != nil {
+					return fmt.Errorf("error al descontar stock de %s: %w", item.ProductName, err)
+				}
+			} else {
+				item.Quantity = -delta
+				if err := restoreItemStock(repos, item, movement); err != nil {
+					return fmt.Errorf("error al devolver stock de %s: %w", item.ProductName, err)
+				}
+			}
+		}
+
+		// 4. Guardar items e importes y registrar la auditoría
+		if err := repos.Orders.ReplaceItems(order); err != nil {
+			return err
+		}
+
+		revision = &models.OrderRevision{
+			OrderID:     order.ID,
+			Changes:     changes,
+			TotalBefore: totalBefore,
+			TotalAfter:  order.TotalAmount,
+			UserID:      actor.UserID,
+			ChangedBy:   actor.Username,
+			Note:        note,
+		}
+		if revision.ChangedBy == "" {
+			revision.ChangedBy = "sistema"
+		}
+		if err := repos.Revisions.Create(revision); err != nil {
+			return err
+		}
+
+		// 5. Si bajó el total y los cobros ya lo cubren, el pedido queda pago
+		if order.Status != models.OrderStatusPending {
+			return nil
+		}
+		payments, err := repos.Payments.GetByOrderID(order.ID)
+		if err != nil {
+			return err
+		}
+		summary := models.SummarizePayments(order.AmountDue(), payments)
+		if summary.AmountPaid > 0 && summary.BalanceDue <= paymentTolerance {
+			return changeOrderStatus(repos, order, models.OrderStatusPaid, actor, "Pago total registrado (pedido modificado)")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// orderEditNote arma la nota de los movimientos de stock de una edición
+func orderEditNote(note string) string {
+	if note == "" {
+		return "Pedido modificado"
+	}
+	return "Pedido modificado: " + note
+}
+
+// orderCoupon obtiene el cupón ya aplicado a un pedido para recalcular el descuento.
+// No se vuelve a validar vigencia ni usos (el uso ya se descontó al crear el pedido).
+// Si el cupón fue eliminado se mantiene el descuento original como monto fijo.
+func orderCoupon(repos *repositories.TxRepositories, order *models.Order) (*models.Coupon, error) {
+	if order.CouponCode == "" {
+		return nil, nil
+	}
+
+	coupon, err := repos.Coupons.GetByCode(order.CouponCode)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cupón: %w", err)
+	}
+	if coupon == nil {
+		coupon = &models.Coupon{Code: order.CouponCode, Type: models.CouponTypeFixed, Value: order.Discount}
+	}
+	return coupon, nil
+}
+
+// snapshotPrices devuelve una copia de los productos en la que las variantes (o productos
+// sin variantes) que ya estaban en el pedido conservan el precio con el que se vendieron
+func snapshotPrices(products map[uint]*models.Product, previous []models.OrderItem) map[uint]*models.Product {
+	priced := make(map[uint]*models.Product, len(products))
+	for id, product := range products {
+		clone := *product
+		clone.Variantes = append([]models.ProductVariant(nil), product.Variantes...)
+		priced[id] = &clone
+	}
+
+	seen := map[string]bool{}
+	for _, item := range previous {
+		product, ok := priced[item.ProductID]
+		if !ok || seen[item.StockKey()] {
+			continue
+		}
+		seen[item.StockKey()] = true
+
+		price := item.UnitPrice
+		if item.VariantID == nil {
+			product.Precio = price
+			continue
+		}
+		for i := range product.Variantes {
+			if product.Variantes[i].ID == *item.VariantID {
+				product.Variantes[i].Precio = &price
+				break
+			}
+		}
+	}
+	return priced
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+var (
+	// ErrPaymentExceedsBalance indica un cobro mayor al saldo pendiente del pedido
+	ErrPaymentExceedsBalance = errors.New("el pago supera el saldo pendiente")
+	// ErrRefundExceedsPaid indica una devolución mayor a lo cobrado
+	ErrRefundExceedsPaid = errors.New("la devolución supera lo cobrado")
+	// ErrOrderCancelled indica que el pedido cancelado no admite cobros
+	ErrOrderCancelled = errors.New("el pedido está cancelado")
+)
+
+// paymentTolerance absorbe diferencias de redondeo entre importes
+const paymentTolerance = 0.01
+
+// GetOrderPayments obtiene los cobros y devoluciones de un pedido con su estado de cuenta
+func (s *OrderService) GetOrderPayments(orderID uint) ([]models.OrderPayment, models.PaymentSummary, error) {
+	order, err := s.repo.GetByID(orderID)
+	if err != nil {
+		return nil, models.PaymentSummary{}, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return nil, models.PaymentSummary{}, fmt.Errorf("orden no encontrada")
+	}
+
+	payments, err := s.paymentRepo.GetByOrderID(orderID)
+	if err != nil {
+		return nil, models.PaymentSummary{}, err
+	}
+
+	return payments, models.SummarizePayments(order.AmountDue(), payments), nil
+}
+
+// RecordPayment registra un cobro (seña, saldo o pago total). Cuando el pedido
+// queda saldado y está Pendiente, pasa automáticamente a Pagado.
+func (s *OrderService) RecordPayment(orderID uint, payment *models.OrderPayment, actor models.Actor) (models.PaymentSummary, error) {
+	payment.Kind = models.OrderPaymentKindPayment
+	return s.recordPayment(orderID, payment, actor)
+}
+
+// RecordRefund registra una devolución de dinero. No cambia el estado del pedido:
+// cancelarlo (y devolver el stock) es una decisión aparte.
+func (s *OrderService) RecordRefund(orderID uint, refund *models.OrderPayment, actor models.Actor) (models.PaymentSummary, error) {
+	refund.Kind = models.OrderPaymentKindRefund
+	return s.recordPayment(orderID, refund, actor)
+}
+
+func (s *OrderService) recordPayment(orderID uint, payment *models.OrderPayment, actor models.Actor) (models.PaymentSummary, error) {
+	payment.Normalize()
+	if err := payment.Validate(); err != nil {
+		return models.PaymentSummary{}, err
+	}
+
+	var summary models.PaymentSummary
+	err := s.uow.Do(func(repos *repositories.TxRepositories) error {
+		var err error
+		summary, err = applyPayment(repos, orderID, payment, actor)
+		return err
+	})
+	return summary, err
+}
+
+// applyPayment registra el pago dentro de una transacción en curso y, si el pedido
+// queda saldado, lo pasa a Pagado. Devuelve el estado de cuenta actualizado.
+func applyPayment(repos *repositories.TxRepositories, orderID uint, payment *models.OrderPayment, actor models.Actor) (models.PaymentSummary, error) {
+	order, err := repos.Orders.GetByID(orderID)
+	if err != nil {
+		return models.PaymentSummary{}, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return models.PaymentSummary{}, fmt.Errorf("orden no encontrada")
+	}
+
+	payments, err := repos.Payments.GetByOrderID(orderID)
+	if err != nil {
+		return models.PaymentSummary{}, err
+	}
+	before := models.SummarizePayments(order.AmountDue(), payments)
+
+	switch payment.Kind {
+	case models.OrderPaymentKindPayment:
+		if order.Status == models.OrderStatusCancelled {
+			return models.PaymentSummary{}, ErrOrderCancelled
+		}
+		if payment.Amount > before.BalanceDue+paymentTolerance {
+			return models.PaymentSummary{}, fmt.Errorf("%w: pago $%.2f, saldo $%.2f", ErrPaymentExceedsBalance, payment.Amount, before.BalanceDue)
+		}
+	case models.OrderPaymentKindRefund:
+		if payment.Amount > before.AmountPaid+paymentTolerance {
+			return models.PaymentSummary{}, fmt.Errorf("%w: devolución $%.2f, cobrado $%.2f", ErrRefundExceedsPaid, payment.Amount, before.AmountPaid)
+		}
+	}
+
+	payment.OrderID = orderID
+	payment.UserID = actor.UserID
+	payment.CreatedBy = actor.Username
+	if payment.CreatedBy == "" {
+		payment.CreatedBy = "sistema"
+	}
+	if err := repos.Payments.Create(payment); err != nil {
+		return models.PaymentSummary{}, err
+	}
+
+	summary := models.SummarizePayments(order.AmountDue(), append(payments, *payment))
+
+	if payment.Kind == models.OrderPaymentKindPayment && summary.BalanceDue <= paymentTolerance &&
+		order.Status == models.OrderStatusPending {
+		note := "Pago total registrado"
+		if payment.Reference != "" {
+			note += " (" + payment.Reference + ")"
+		}
+		if err := changeOrderStatus(repos, order, models.OrderStatusPaid, actor, note); err != nil {
+			return models.PaymentSummary{}, err
+		}
+	}
+
+	return summary, nil
+}
+
+// RecordPaymentOnce registra un cobro salvo que ya exista otro con el mismo comprobante.
+// Lo usan las notificaciones de la pasarela, que pueden llegar repetidas.
+// Devuelve false si el pago ya estaba registrado.
+func (s *OrderService) RecordPaymentOnce(orderID uint, payment *models.OrderPayment, actor models.Actor) (models.PaymentSummary, bool, error) {
+	payment.Kind = models.OrderPaymentKindPayment
+	payment.Normalize()
+	if err := payment.Validate(); err != nil {
+		return models.PaymentSummary{}, false, err
+	}
+	if payment.Reference == "" {
+		return models.PaymentSummary{}, false, fmt.Errorf("la referencia del pago es requerida")
+	}
+
+	var summary models.PaymentSummary
+	recorded := false
+	err := s.uow.Do(func(repos *repositories.TxRepositories) error {
+		exists, err := repos.Payments.ExistsByReference(orderID, payment.Kind, payment.Reference)
+		if err != nil || exists {
+			return err
+		}
+		summary, err = applyPayment(repos, orderID, payment, actor)
+		recorded = err == nil
+		return err
+	})
+	return summary, recorded, err
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// priceTolerance es la diferencia máxima aceptada entre un importe enviado y el calculado
+const priceTolerance = 0.01
+
+// PriceMismatch describe un importe enviado que no coincide con el calculado
+type PriceMismatch struct {
+	Line        int     `json:"line,omitempty"` // Número de item (1..n); 0 para importes del pedido
+	ProductID   uint    `json:"product_id,omitempty"`
+	ProductName string  `json:"product_name,omitempty"`
+	Field       string  `json:"field"`
+	Submitted   float64 `json:"submitted"`
+	Expected    float64 `json:"expected"`
+}
+
+// PriceMismatchError se devuelve cuando los precios o totales enviados no coinciden con los del catálogo
+type PriceMismatchError struct {
+	Items []PriceMismatch
+}
+
+func (e *PriceMismatchError) Error() string {
+	parts := make([]string, len(e.Items))
+	for i, m := range e.Items {
+		label := m.Field
+		if m.Line > 0 {
+			label = fmt.Sprintf("item %d (%s) %s", m.Line, m.ProductName, m.Field)
+		}
+		parts[i] = fmt.Sprintf("%s: enviado %.2f, esperado %.2f", label, m.Submitted, m.Expected)
+	}
+	return "los importes del pedido no coinciden con los precios vigentes: " + strings.Join(parts, "; ")
+}
+
+// PricingRules son las reglas de precios vigentes, tomadas de SiteConfig
+type PricingRules struct {
+	PaymentMethods   []models.PaymentMethodRule // Recargos/descuentos por medio de pago y cuotas
+	ShippingMethods  []models.ShippingMethod    // Métodos de envío y costos por zona
+	FreeShippingOver float64                    // Envío gratis desde este importe (0 = nunca)
+}
+
+// PaymentAdjustment devuelve el porcentaje de ajuste para el medio de pago y las cuotas.
+// Sin medio de pago no hay ajuste.
+func (r PricingRules) PaymentAdjustment(method models.PaymentMethod, installments int) (float64, error) {
+	if method == "" {
+		if installments > 1 {
+			return 0, fmt.Errorf("debe indicar el medio de pago para pagar en cuotas")
+		}
+		return 0, nil
+	}
+
+	for i := range r.PaymentMethods {
+		rule := &r.PaymentMethods[i]
+		if rule.Method != method {
+			continue
+		}
+		if !rule.Enabled {
+			return 0, fmt.Errorf("el medio de pago %s no está disponible", rule.Label)
+		}
+		return rule.AdjustmentFor(installments)
+	}
+
+	return 0, fmt.Errorf("el medio de pago %s no está disponible", method)
+}
+
+// ShippingCost calcula el envío con el método elegido para el destino y el importe de
+// la compra (con descuentos). Sin método no hay envío.
+func (r PricingRules) ShippingCost(code models.ShippingMethodCode, postalCode, province string, amount float64) (models.ShippingQuote, error) {
+	if code == "" {
+		return models.ShippingQuote{}, nil
+	}
+
+	for i := range r.ShippingMethods {
+		method := &r.ShippingMethods[i]
+		if method.Code != code {
+			continue
+		}
+		if !method.Enabled {
+			return models.ShippingQuote{}, fmt.Errorf("el método de envío %s no está disponible", method.Label)
+		}
+		return models.QuoteShipping(method, models.ParsePostalCode(postalCode), province, amount, r.FreeShippingOver)
+	}
+
+	return models.ShippingQuote{}, fmt.Errorf("el método de envío %s no está disponible", code)
+}
+
+// ShippingQuotes calcula el envío a un destino con cada método habilitado que llegue a él
+func (r PricingRules) ShippingQuotes(postalCode, province string, amount float64) []models.ShippingQuote {
+	quotes := []models.ShippingQuote{}
+	for i := range r.ShippingMethods {
+		method := &r.ShippingMethods[i]
+		if !method.Enabled {
+			continue
+		}
+		quote, err := models.QuoteShipping(method, models.ParsePostalCode(postalCode), province, amount, r.FreeShippingOver)
+		if err != nil {
+			continue
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes
+}
+
+// PaymentQuote es el importe a pagar con un medio de pago y cantidad de cuotas
+type PaymentQuote struct {
+	Method            models.PaymentMethod `json:"method"`
+	Label             string               `json:"label"`
+	Installments      int                  `json:"installments"`
+	AdjustmentPercent float64              `json:"adjustment_percent"`
+	Total             float64              `json:"total"`
+	InstallmentAmount float64              `json:"installment_amount"`
+}
+
+// Quote calcula el total de un importe con cada medio de pago habilitado y sus planes de cuotas
+func (r PricingRules) Quote(amount float64) []PaymentQuote {
+	quotes := []PaymentQuote{}
+	for _, rule := range r.PaymentMethods {
+		if !rule.Enabled {
+			continue
+		}
+
+		options := []models.InstallmentPlan{{Cuotas: 1, SurchargePercent: rule.AdjustmentPercent}}
+		options = append(options, rule.Installments...)
+		for _, option := range options {
+			total := roundMoney(amount + roundMoney(amount*option.SurchargePercent/100))
+			quotes = append(quotes, PaymentQuote{
+				Method:            rule.Method,
+				Label:             rule.Label,
+				Installments:      option.Cuotas,
+				AdjustmentPercent: option.SurchargePercent,
+				Total:             total,
+				InstallmentAmount: roundMoney(total / float64(option.Cuotas)),
+			})
+		}
+	}
+	return quotes
+}
+
+// OrderPricer calcula los importes de los pedidos a partir del catálogo y la configuración
+type OrderPricer struct {
+	configRepo *repositories.ConfigRepository
+}
+
+// NewOrderPricer crea una nueva instancia del calculador de precios
+func NewOrderPricer(configRepo *repositories.ConfigRepository) *OrderPricer {
+	return &OrderPricer{configRepo: configRepo}
+}
+
+// Rules obtiene las reglas vigentes. Debe llamarse fuera de la transacción del pedido
+// (el repositorio de configuración usa la conexión principal).
+func (p *OrderPricer) Rules() (PricingRules, error) {
+	config, err := p.configRepo.GetConfig()
+	if err != nil {
+		return PricingRules{}, err
+	}
+	return PricingRules{
+		PaymentMethods:   config.PaymentMethods,
+		ShippingMethods:  config.ShippingMethods,
+		FreeShippingOver: config.FreeShippingOver,
+	}, nil
+}
+
+// PriceOrder completa nombre, precio unitario y subtotal de cada item con los datos del
+// catálogo y calcula subtotal, descuento del cupón (si hay), recargo (o descuento) por medio
+// de pago, costo de envío y total del pedido. Los importes que haya enviado el cliente (distintos de cero)
+// deben coincidir con los calculados; si no, se devuelve un *PriceMismatchError y el pedido
+// no se registra.
+// Los items deben tener la variante ya resuelta (ver resolveItems). La vigencia y los usos
+// del cupón se validan antes (ver Coupon.CheckAvailable).
+func PriceOrder(order *models.Order, products map[uint]*models.Product, rules PricingRules, coupon *models.Coupon) error {
+	return priceOrder(order, products, rules, coupon, func(discounted float64) (float64, error) {
+		shipping, err := rules.ShippingCost(order.ShippingMethod, order.ShippingPostalCode, order.ShippingProvince, discounted)
+		return shipping.Cost, err
+	})
+}
+
+// RepriceOrderItems recalcula los importes de un pedido ya registrado cuyos items cambiaron.
+// Conserva el costo de envío pactado en el checkout: el medio y la zona de envío no cambian
+// al editar items, así que no se vuelve a cotizar con las tarifas vigentes.
+func RepriceOrderItems(order *models.Order, products map[uint]*models.Product, rules PricingRules, coupon *models.Coupon) error {
+	shippingCost := order.ShippingCost
+	order.Subtotal, order.Discount, order.ShippingCost, order.TotalAmount = 0, 0, 0, 0
+	return priceOrder(order, products, rules, coupon, func(float64) (float64, error) {
+		return shippingCost, nil
+	})
+}
+
+// priceOrder implementa PriceOrder; shippingCost devuelve el costo de envío para la
+// compra con descuento
+func priceOrder(order *models.Order, products map[uint]*models.Product, rules PricingRules, coupon *models.Coupon, shippingCost func(discounted float64) (float64, error)) error {
+	var mismatches []PriceMismatch
+	check := func(line int, item *models.OrderItem, field string, submitted, expected float64) {
+		if submitted == 0 || math.Abs(submitted-expected) < priceTolerance {
+			return
+		}
+		m := PriceMismatch{Line: line, Field: field, Submitted: submitted, Expected: expected}
+		if item != nil {
+			m.ProductID = item.ProductID
+			m.ProductName = item.ProductName
+		}
+		mismatches = append(mismatches, m)
+	}
+
+	subtotal := 0.0
+	eligible := 0.0 // Subtotal de los productos alcanzados por el cupón
+	for i := range order.Items {
+		item := &order.Items[i]
+		product, ok := products[item.ProductID]
+		if !ok {
+			return fmt.Errorf("producto %d no encontrado", item.ProductID)
+		}
+
+		unitPrice := product.Precio
+		if item.VariantID != nil {
+			for _, v := range product.Variantes {
+				if v.ID == *item.VariantID {
+					unitPrice = v.EffectivePrice(product.Precio)
+					break
+				}
+			}
+		}
+		unitPrice = roundMoney(unitPrice)
+		lineSubtotal := roundMoney(unitPrice * float64(item.Quantity))
+
+		item.ProductName = product.Nombre
+		check(i+1, item, "unit_price", item.UnitPrice, unitPrice)
+		check(i+1, item, "subtotal", item.Subtotal, lineSubtotal)
+
+		item.UnitPrice = unitPrice
+		item.Subtotal = lineSubtotal
+		subtotal += lineSubtotal
+		if coupon != nil && coupon.AppliesTo(product) {
+			eligible += lineSubtotal
+		}
+	}
+
+	adjustment, err := rules.PaymentAdjustment(order.PaymentMethod, order.Installments)
+	if err != nil {
+		return err
+	}
+
+	subtotal = roundMoney(subtotal)
+	discount := 0.0
+	if coupon != nil {
+		if subtotal < coupon.MinPurchase {
+			return fmt.Errorf("el cupón %s requiere una compra mínima de $%.2f", coupon.Code, coupon.MinPurchase)
+		}
+		discount = coupon.DiscountFor(roundMoney(eligible))
+		if discount <= 0 {
+			return fmt.Errorf("el cupón %s no aplica a los productos del pedido", coupon.Code)
+		}
+	}
+
+	discounted := roundMoney(subtotal - discount)
+	surcharge := roundMoney(discounted * adjustment / 100)
+
+	// El envío no lleva recargo por medio de pago; el mínimo para envío gratis se
+	// compara contra la compra con descuento
+	shipping, err := shippingCost(discounted)
+	if err != nil {
+		return err
+	}
+	total := roundMoney(discounted + surcharge + shipping)
+
+	check(0, nil, "subtotal", order.Subtotal, subtotal)
+	check(0, nil, "discount", order.Discount, discount)
+	check(0, nil, "shipping_cost", order.ShippingCost, shipping)
+	check(0, nil, "total_amount", order.TotalAmount, total)
+
+	if len(mismatches) > 0 {
+		return &PriceMismatchError{Items: mismatches}
+	}
+
+	// Un pago se registra como 1 cuota en crédito y sin cuotas en el resto
+	if order.PaymentMethod == models.PaymentMethodCreditCard && order.Installments < 1 {
+		order.Installments = 1
+	} else if order.PaymentMethod != models.PaymentMethodCreditCard {
+		order.Installments = 0
+	}
+
+	order.Subtotal = subtotal
+	order.CouponCode = ""
+	if coupon != nil {
+		order.CouponCode = coupon.Code
+	}
+	order.Discount = discount
+	order.ShippingCost = shipping
+	order.Surcharge = surcharge
+	order.TotalAmount = total
+	return nil
+}
+
+// roundMoney redondea un importe a centavos
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -1,65 +1,138 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"tiendaedgar/backend/models"
 	"tiendaedgar/backend/repositories"
+	"time"
+)
+
+var (
+	// ErrInvalidOrderStatus indica un estado que no pertenece al flujo de pedidos
+	ErrInvalidOrderStatus = errors.New("estado de pedido inválido")
+	// ErrInvalidStatusTransition indica un cambio de estado no permitido por el flujo de pedidos
+	ErrInvalidStatusTransition = errors.New("transición de estado no permitida")
+	// ErrCouponExhausted indica que el cupón del pedido ya no tiene usos disponibles
+	ErrCouponExhausted = errors.New("el cupón alcanzó su límite de usos")
 )
 
 type OrderService struct {
-	repo        *repositories.OrderRepository
-	productRepo *repositories.ProductRepository
+	repo         *repositories.OrderRepository
+	historyRepo  *repositories.OrderStatusHistoryRepository
+	paymentRepo  *repositories.OrderPaymentRepository
+	revisionRepo *repositories.OrderRevisionRepository
+	uow          *repositories.UnitOfWork // Operaciones que tocan pedidos y stock a la vez
+	pricer       *OrderPricer
+	configRepo   *repositories.ConfigRepository // Formato del número de pedido
 }
 
-func NewOrderService(repo *repositories.OrderRepository, productRepo *repositories.ProductRepository) *OrderService {
+func NewOrderService(repo *repositories.OrderRepository, historyRepo *repositories.OrderStatusHistoryRepository, paymentRepo *repositories.OrderPaymentRepository, revisionRepo *repositories.OrderRevisionRepository, uow *repositories.UnitOfWork, pricer *OrderPricer, configRepo *repositories.ConfigRepository) *OrderService {
 	return &OrderService{
-		repo:        repo,
-		productRepo: productRepo,
+		repo:         repo,
+		historyRepo:  historyRepo,
+		paymentRepo:  paymentRepo,
+		revisionRepo: revisionRepo,
+		uow:          uow,
+		pricer:       pricer,
+		configRepo:   configRepo,
 	}
 }
 
-// CreateOrder crea un nuevo pedido y actualiza el stock
-func (s *OrderService) CreateOrder(order *models.Order) error {
-	// 1. Validar stock primero
-	for _, item := range order.Items {
-		product, err := s.productRepo.GetByID(item.ProductID)
+// CreateOrder crea un nuevo pedido y reserva el stock en una única transacción:
+// si falla la validación, la inserción o algún descuento, no se persiste nada.
+// Precios, nombres y totales se calculan desde el catálogo (ver PriceOrder).
+func (s *OrderService) CreateOrder(order *models.Order, actor models.Actor) error {
+	if order.Status == "" {
+		order.Status = models.OrderStatusPending
+	}
+	if !order.Status.IsInitial() {
+		return fmt.Errorf("%w: un pedido nuevo debe crearse %s", ErrInvalidOrderStatus, models.OrderStatusPending)
+	}
+	if !order.PaymentMethod.IsValid() {
+		return fmt.Errorf("medio de pago inválido: %s", order.PaymentMethod)
+	}
+	if len(order.Items) == 0 {
+		return fmt.Errorf("el pedido debe tener al menos un producto")
+	}
+	order.Reference = models.NewOrderReference()
+	order.TrackingToken = models.NewTrackingToken()
+	order.TrackingNumber = strings.TrimSpace(order.TrackingNumber)
+	order.CouponCode = models.NormalizeCouponCode(order.CouponCode)
+	order.ShippingMethod = models.ShippingMethodCode(strings.ToLower(strings.TrimSpace(string(order.ShippingMethod))))
+	order.ShippingPostalCode = strings.ToUpper(strings.TrimSpace(order.ShippingPostalCode))
+	order.ShippingProvince = strings.TrimSpace(order.ShippingProvince)
+
+	rules, err := s.pricer.Rules()
+	if err != nil {
+		return fmt.Errorf("error al obtener reglas de precios: %w", err)
+	}
+	config, err := s.configRepo.GetConfig()
+	if err != nil {
+		return err
+	}
+	numbering := config.OrderNumbering()
+
+	return s.uow.Do(func(repos *repositories.TxRepositories) error {
+		// 1. Resolver la variante de cada item y validar stock por talla/color
+		products, err := resolveItems(repos, order.Items)
 		if err != nil {
-			return fmt.Errorf("error al verificar producto %d: %w", item.ProductID, err)
+			return err
 		}
-		if product == nil {
-			return fmt.Errorf("producto %d no encontrado", item.ProductID)
+
+		// 1b. Validar el cupón y calcular precios, descuento y totales desde el catálogo
+		coupon, err := findCoupon(repos, order.CouponCode)
+		if err != nil {
+			return err
 		}
-		if product.Stock < item.Quantity {
-			return fmt.Errorf("stock insuficiente para producto %s (Stock: %d, Solicitado: %d)", product.Nombre, product.Stock, item.Quantity)
+		if err := PriceOrder(order, products, rules, coupon); err != nil {
+			return err
 		}
-	}
 
-	// 2. Crear la orden
-	// Nota: Idealmente esto debería ser una transacción única, pero GORM/Sqlite básico
-	// requiere pasar la Tx entre repositorios. Por simplicidad en este MVP, lo hacemos en pasos.
-	// Riesgo: Si falla la creación de orden después de descontar stock (o viceversa).
-	if err := s.repo.Create(order); err != nil {
-		return fmt.Errorf("error al crear orden: %w", err)
-	}
+		// 2. Asociar el cliente (por teléfono o email), numerar y crear la orden.
+		// Los pedidos sin usuario (checkout público) no pisan los datos del cliente.
+		if err := linkCustomer(repos, order, actor.UserID != nil); err != nil {
+			return err
+		}
+		year := time.Now().Year()
+		seq, err := repos.Orders.NextOrderNumber(year)
+		if err != nil {
+			return err
+		}
+		order.OrderNumber = numbering.Format(year, seq)
+		if err := repos.Orders.Create(order); err != nil {
+			return fmt.Errorf("error al crear orden: %w", err)
+		}
 
-	// 3. Descontar stock (si la orden no es Cancelada)
-	// Asumimos que una nueva orden manual ya descuenta stock inmediatamente.
-	if order.Status != models.OrderStatusCancelled {
-		for _, item := range order.Items {
-			// Ignoramos error de "insuficiente" aquí porque ya validamos arriba, 
-			// aunque podría haber condición de carrera en alta concurrencia.
-			if err := s.productRepo.ReduceStock(item.ProductID, item.Quantity); err != nil {
-				// LOG: Error crítico, inconsistencia de stock
-				fmt.Printf("ERROR CRÍTICO: Falló descuento de stock para producto %d en orden %d: %v\n", item.ProductID, order.ID, err)
+		// 3. Descontar stock (si la orden no es Cancelada)
+		// Asumimos que una nueva orden manual ya descuenta stock inmediatamente.
+		if order.Status.ReservesStock() {
+			movement := models.NewStockMovement(models.StockMovementSale, actor, "Pedido creado").ForOrder(order.ID)
+			for _, item := range order.Items {
+				// El UPDATE es condicional (stock >= cantidad): si otro pedido tomó
+				// el stock entre la validación y el descuento, se revierte todo.
+				if err := reduceItemStock(repos, item, movement); err != nil {
+					return fmt.Errorf("error al descontar stock de %s: %w", item.ProductName, err)
+				}
+			}
+
+			// El uso del cupón se descuenta con el mismo criterio que el stock
+			if coupon != nil {
+				if err := repos.Coupons.Redeem(coupon.Code); err != nil {
+					return fmt.Errorf("%w: %s", ErrCouponExhausted, coupon.Code)
+				}
 			}
 		}
-	}
 
-	return nil
+		// 4. Registrar el estado inicial en el historial
+		return recordStatusChange(repos, order.ID, "", order.Status, actor, "Pedido creado")
+	})
 }
 
-// GetAllOrders obtiene pedidos con paginación y filtros
-func (s *OrderService) GetAllOrders(page, limit int, status string, search string) ([]models.Order, int, error) {
+// GetAllOrders obtiene pedidos con paginación, filtros y orden (ver models.OrderFilter).
+// Con includeItems se cargan también los items de toda la página en una sola consulta.
+func (s *OrderService) GetAllOrders(page, limit int, filter models.OrderFilter, includeItems bool) ([]models.Order, int, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -67,83 +140,513 @@ func (s *OrderService) GetAllOrders(page, limit int, status string, search strin
 		limit = 10
 	}
 	offset := (page - 1) * limit
-	return s.repo.GetAll(limit, offset, status, search)
+	orders, total, err := s.repo.GetAll(filter, limit, offset)
+	if err != nil || !includeItems {
+		return orders, total, err
+	}
+	if err := s.repo.AttachItems(orders); err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
 }
 
-// GetOrderByID obtiene un pedido por ID
+// GetOrderByID obtiene un pedido por ID con sus pagos y el saldo pendiente
 func (s *OrderService) GetOrderByID(id uint) (*models.Order, error) {
-	return s.repo.GetByID(id)
-}
-
-// UpdateOrderStatus actualiza el estado de un pedido
-// UpdateOrderStatus actualiza el estado de un pedido y maneja el stock si es necesario
-func (s *OrderService) UpdateOrderStatus(id uint, status models.OrderStatus) error {
-	// 1. Obtener orden actual para ver estado previo
 	order, err := s.repo.GetByID(id)
+	if err != nil || order == nil {
+		return order, err
+	}
+
+	payments, err := s.paymentRepo.GetByOrderID(id)
 	if err != nil {
-		return fmt.Errorf("error al obtener orden: %w", err)
+		return nil, err
 	}
-	if order == nil {
-		return fmt.Errorf("orden no encontrada")
+	order.Payments = payments
+	summary := models.SummarizePayments(order.AmountDue(), payments)
+	order.PaymentSummary = &summary
+
+	return order, nil
+}
+
+// GetOrderByNumber obtiene un pedido por su número (ej. CS-2026-000123) con sus pagos
+func (s *OrderService) GetOrderByNumber(number string) (*models.Order, error) {
+	order, err := s.repo.GetByOrderNumber(strings.ToUpper(strings.TrimSpace(number)))
+	if err != nil || order == nil {
+		return order, err
 	}
+	return s.GetOrderByID(order.ID)
+}
+
+// UpdateOrderStatus cambia el estado de un pedido respetando el flujo permitido
+// (ver models.OrderStatus.CanTransitionTo), ajusta el stock y registra el historial:
+//   - pasar a Cancelado devuelve el stock reservado (no se permite si el pedido tiene devoluciones)
+//   - reactivar un pedido Cancelado vuelve a reservarlo (falla si ya no hay stock)
+func (s *OrderService) UpdateOrderStatus(id uint, status models.OrderStatus, actor models.Actor, note string) error {
+	if !status.IsValid() {
+		return fmt.Errorf("%w: %s", ErrInvalidOrderStatus, status)
+	}
+
+	return s.uow.Do(func(repos *repositories.TxRepositories) error {
+		// Obtener orden actual para ver estado previo
+		order, err := repos.Orders.GetByID(id)
+		if err != nil {
+			return fmt.Errorf("error al obtener orden: %w", err)
+		}
+		if order == nil {
+			return fmt.Errorf("orden no encontrada")
+		}
+
+		return changeOrderStatus(repos, order, status, actor, note)
+	})
+}
 
-	// 2. Si el estado nuevo es CANCELADO y el anterior NO lo era, devolvemos stock
-	if status == models.OrderStatusCancelled && order.Status != models.OrderStatusCancelled {
+// changeOrderStatus aplica un cambio de estado dentro de una transacción en curso:
+// valida la transición, ajusta el stock, actualiza el pedido y registra el historial
+func changeOrderStatus(repos *repositories.TxRepositories, order *models.Order, status models.OrderStatus, actor models.Actor, note string) error {
+	id := order.ID
+	if !order.Status.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidStatusTransition, order.Status, status)
+	}
+
+	// 1. Efectos sobre el stock
+	switch {
+	case order.Status.ReservesStock() && !status.ReservesStock():
+		if err := ensureNoReturns(repos, id); err != nil {
+			return err
+		}
+		movement := models.NewStockMovement(models.StockMovementCancel, actor, note).ForOrder(id)
 		for _, item := range order.Items {
-			if err := s.productRepo.IncreaseStock(item.ProductID, item.Quantity); err != nil {
-				// Log error pero continuamos (o podríamos retornar error parcial)
-				fmt.Printf("ERROR: Falló restitución de stock para producto %d: %v\n", item.ProductID, err)
+			if err := restoreItemStock(repos, item, movement); err != nil {
+				return fmt.Errorf("error al devolver stock de %s: %w", item.ProductName, err)
 			}
 		}
+		if order.CouponCode != "" {
+			if err := repos.Coupons.Release(order.CouponCode); err != nil {
+				return err
+			}
+		}
+	case !order.Status.ReservesStock() && status.ReservesStock():
+		if _, err := resolveItems(repos, order.Items); err != nil {
+			return err
+		}
+		movement := models.NewStockMovement(models.StockMovementSale, actor, "Pedido reactivado").ForOrder(id)
+		for _, item := range order.Items {
+			if err := reduceItemStock(repos, item, movement); err != nil {
+				return fmt.Errorf("error al descontar stock de %s: %w", item.ProductName, err)
+			}
+		}
+		if order.CouponCode != "" {
+			if err := redeemExistingCoupon(repos, order.CouponCode); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 2. Actualizar estado y registrar historial
+	if err := repos.Orders.UpdateStatus(id, status); err != nil {
+		return err
 	}
 
-	// 3. (Opcional) Si reactivamos una orden cancelada, deberíamos descontar stock de nuevo
-	// Por ahora lo dejamos simple: No se permite reactivar stock automáticamente o se asume manual.
-	
-	// 4. Actualizar estado
-	return s.repo.UpdateStatus(id, status)
+	from := order.Status
+	order.Status = status
+	return recordStatusChange(repos, id, from, status, actor, note)
 }
 
-// UpdateOrder actualiza los datos generales de un pedido
-func (s *OrderService) UpdateOrder(id uint, updates models.Order) error {
+// GetOrderHistory obtiene el historial de estados de un pedido
+func (s *OrderService) GetOrderHistory(id uint) ([]models.OrderStatusHistory, error) {
 	order, err := s.repo.GetByID(id)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
 	}
 	if order == nil {
-		return fmt.Errorf("orden no encontrada")
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+
+	return s.historyRepo.GetByOrderID(id)
+}
+
+// GetOrderTracking obtiene el seguimiento público de un pedido por su token.
+// Devuelve nil si el token no corresponde a ningún pedido.
+func (s *OrderService) GetOrderTracking(token string) (*models.OrderTracking, error) {
+	order, err := s.repo.GetByTrackingToken(strings.TrimSpace(token))
+	if err != nil || order == nil {
+		return nil, err
+	}
+
+	history, err := s.historyRepo.GetByOrderID(order.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update allowed fields
-	order.CustomerName = updates.CustomerName
-	order.CustomerEmail = updates.CustomerEmail
-	order.CustomerPhone = updates.CustomerPhone
-	order.CustomerAddress = updates.CustomerAddress
-	order.Notes = updates.Notes
+	shippingLabel := ""
+	if order.ShippingMethod != "" {
+		config, err := s.configRepo.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+		shippingLabel = string(order.ShippingMethod)
+		if method := config.FindShippingMethod(order.ShippingMethod); method != nil {
+			shippingLabel = method.Label
+		}
+	}
 
-	return s.repo.Update(order)
+	tracking := models.NewOrderTracking(order, history, shippingLabel)
+	return &tracking, nil
 }
 
-// DeleteOrder elimina un pedido y devuelve el stock
-func (s *OrderService) DeleteOrder(id uint) error {
-	// 1. Obtener orden para devolver el stock
+// RegenerateTrackingToken reemplaza el token del seguimiento público de un pedido (por
+// ejemplo, si el enlace se compartió por error); el enlace anterior deja de funcionar
+func (s *OrderService) RegenerateTrackingToken(id uint) (string, error) {
 	order, err := s.repo.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("error al obtener orden: %w", err)
+		return "", fmt.Errorf("error al obtener orden: %w", err)
 	}
 	if order == nil {
-		return fmt.Errorf("orden no encontrada")
+		return "", fmt.Errorf("orden no encontrada")
 	}
 
-	// 2. Devolver stock si la orden no estaba cancelada
-	if order.Status != models.OrderStatusCancelled {
-		for _, item := range order.Items {
-			if err := s.productRepo.IncreaseStock(item.ProductID, item.Quantity); err != nil {
-				fmt.Printf("ERROR: Falló restitución de stock para producto %d: %v\n", item.ProductID, err)
+	token := models.NewTrackingToken()
+	if err := s.repo.UpdateTrackingToken(id, token); err != nil {
+		return "", fmt.Errorf("error al actualizar token de seguimiento: %w", err)
+	}
+	return token, nil
+}
+
+// UpdateOrder actualiza los datos generales de un pedido y vuelve a asociar el cliente
+func (s *OrderService) UpdateOrder(id uint, updates models.Order) error {
+	return s.uow.Do(func(repos *repositories.TxRepositories) error {
+		order, err := repos.Orders.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return fmt.Errorf("orden no encontrada")
+		}
+
+		// Update allowed fields
+		order.CustomerName = updates.CustomerName
+		order.CustomerEmail = updates.CustomerEmail
+		order.CustomerPhone = updates.CustomerPhone
+		order.CustomerAddress = updates.CustomerAddress
+		order.TrackingNumber = strings.TrimSpace(updates.TrackingNumber)
+		order.Notes = updates.Notes
+
+		if err := linkCustomer(repos, order, true); err != nil {
+			return err
+		}
+		return repos.Orders.Update(order)
+	})
+}
+
+// DeleteOrder elimina un pedido y devuelve el stock. Los pedidos con devoluciones no
+// se eliminan (ver ErrOrderHasReturns).
+func (s *OrderService) DeleteOrder(id uint, actor models.Actor) error {
+	return s.uow.Do(func(repos *repositories.TxRepositories) error {
+		// 1. Obtener orden para devolver el stock
+		order, err := repos.Orders.GetByID(id)
+		if err != nil {
+			return fmt.Errorf("error al obtener orden: %w", err)
+		}
+		if order == nil {
+			return fmt.Errorf("orden no encontrada")
+		}
+
+		if err := ensureNoReturns(repos, id); err != nil {
+			return err
+		}
+
+		// 2. Devolver stock si la orden no estaba cancelada
+		if order.Status.ReservesStock() {
+			movement := models.NewStockMovement(models.StockMovementCancel, actor, "Pedido eliminado").ForOrder(id)
+			for _, item := range order.Items {
+				if err := restoreItemStock(repos, item, movement); err != nil {
+					return fmt.Errorf("error al devolver stock de %s: %w", item.ProductName, err)
+				}
+			}
+			if order.CouponCode != "" {
+				if err := repos.Coupons.Release(order.CouponCode); err != nil {
+					return err
+				}
+			}
+		}
+
+		// 3. Eliminar la orden
+		return repos.Orders.Delete(id)
+	})
+}
+
+// linkCustomer asocia el pedido al cliente con el mismo teléfono (o, si no, el mismo email)
+// y actualiza sus datos de contacto; si no existe lo crea. Los pedidos sin teléfono ni
+// email quedan sin cliente. Solo un admin (overwrite) reemplaza el nombre y la dirección
+// del cliente; los demás pedidos solo completan los datos que falten.
+func linkCustomer(repos *repositories.TxRepositories, order *models.Order, overwrite bool) error {
+	customer, err := repos.Customers.FindByPhone(order.CustomerPhone)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		if customer, err = repos.Customers.FindByEmail(order.CustomerEmail); err != nil {
+			return err
+		}
+	}
+
+	if customer == nil {
+		customer = &models.Customer{
+			Name:    order.CustomerName,
+			Email:   order.CustomerEmail,
+			Phone:   order.CustomerPhone,
+			Address: order.CustomerAddress,
+		}
+		customer.Normalize()
+		if customer.Email == "" && models.NormalizePhone(customer.Phone) == "" {
+			order.CustomerID = nil
+			return nil
+		}
+		if err := repos.Customers.Create(customer); err != nil {
+			return err
+		}
+	} else {
+		if overwrite {
+			customer.MergeContact(order.CustomerName, order.CustomerEmail, order.CustomerPhone, order.CustomerAddress)
+		} else {
+			customer.FillContact(order.CustomerName, order.CustomerEmail, order.CustomerPhone, order.CustomerAddress)
+		}
+		if err := repos.Customers.Update(customer); err != nil {
+			return err
+		}
+	}
+
+	order.CustomerID = &customer.ID
+	return nil
+}
+
+// findCoupon obtiene el cupón del pedido y verifica que pueda usarse. Sin código devuelve nil.
+func findCoupon(repos *repositories.TxRepositories, code string) (*models.Coupon, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	coupon, err := repos.Coupons.GetByCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cupón: %w", err)
+	}
+	if coupon == nil {
+		return nil, fmt.Errorf("el cupón %s no existe", code)
+	}
+	if err := coupon.CheckAvailable(time.Now()); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// redeemExistingCoupon vuelve a tomar el uso del cupón de un pedido reactivado.
+// Si el cupón fue eliminado se mantiene el descuento ya aplicado.
+func redeemExistingCoupon(repos *repositories.TxRepositories, code string) error {
+	coupon, err := repos.Coupons.GetByCode(code)
+	if err != nil {
+		return fmt.Errorf("error al obtener cupón: %w", err)
+	}
+	if coupon == nil {
+		return nil
+	}
+	if err := repos.Coupons.Redeem(code); err != nil {
+		return fmt.Errorf("%w: %s", ErrCouponExhausted, code)
+	}
+	return nil
+}
+
+// recordStatusChange registra un cambio de estado en el historial del pedido y encola el
+// email al cliente que corresponda al nuevo estado
+func recordStatusChange(repos *repositories.TxRepositories, orderID uint, from, to models.OrderStatus, actor models.Actor, note string) error {
+	changedBy := actor.Username
+	if changedBy == "" {
+		changedBy = "sistema"
+	}
+
+	err := repos.StatusHistory.Create(&models.OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		UserID:     actor.UserID,
+		ChangedBy:  changedBy,
+		Note:       note,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Email al cliente (ver NotificationService): se encola con el cambio de estado y se envía después
+	if kind, ok := models.NotificationForStatus(to); ok && from != to {
+		return repos.Notifications.Enqueue(orderID, kind)
+	}
+	return nil
+}
+
+// resolveItems completa variante, talla y color de cada item y valida el stock disponible.
+// Los productos sin variantes se validan contra el stock total. Si falta stock se
+// devuelve un *InsufficientStockError con el detalle de todos los items afectados.
+// Devuelve los productos leídos, indexados por ID.
+func resolveItems(repos *repositories.TxRepositories, items []models.OrderItem) (map[uint]*models.Product, error) {
+	return resolveItemsWithReserved(repos, items, nil)
+}
+
+// resolveItemsWithReserved es resolveItems sumando al stock disponible lo que el pedido
+// ya tiene reservado (indexado por OrderItem.StockKey). Se usa al editar un pedido existente.
+func resolveItemsWithReserved(repos *repositories.TxRepositories, items []models.OrderItem, reserved map[string]int) (map[uint]*models.Product, error) {
+	// Cantidades acumuladas por variante/producto (un mismo SKU puede venir en varias líneas)
+	products := map[uint]*models.Product{}
+	var shortages []StockShortage
+	shortageIndex := map[string]int{}
+	requested := map[string]int{}
+
+	for i := range items {
+		item := &items[i]
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("cantidad inválida para producto %d", item.ProductID)
+		}
+
+		product, ok := products[item.ProductID]
+		if !ok {
+			var err error
+			product, err = repos.Products.GetByID(item.ProductID)
+			if err != nil {
+				return nil, fmt.Errorf("error al verificar producto %d: %w", item.ProductID, err)
+			}
+			if product == nil {
+				return nil, fmt.Errorf("producto %d no encontrado", item.ProductID)
 			}
+			products[product.ID] = product
 		}
+
+		variant, err := resolveVariant(product, item)
+		if err != nil {
+			return nil, err
+		}
+
+		available := product.Stock
+		if variant != nil {
+			item.VariantID = &variant.ID
+			item.Talla = variant.Talla
+			item.Color = variant.Color
+			available = variant.Stock
+		}
+		key := item.StockKey()
+		available += reserved[key]
+
+		requested[key] += item.Quantity
+		if requested[key] <= available {
+			continue
+		}
+
+		if idx, ok := shortageIndex[key]; ok {
+			shortages[idx].Requested = requested[key]
+			continue
+		}
+		shortageIndex[key] = len(shortages)
+		shortages = append(shortages, StockShortage{
+			ProductID:   product.ID,
+			VariantID:   item.VariantID,
+			ProductName: product.Nombre,
+			Talla:       item.Talla,
+			Color:       item.Color,
+			Available:   available,
+			Requested:   requested[key],
+		})
+	}
+
+	if len(shortages) > 0 {
+		return nil, &InsufficientStockError{Items: shortages}
+	}
+
+	return products, nil
+}
+
+// resolveVariant busca la variante del item por ID o por talla/color
+func resolveVariant(product *models.Product, item *models.OrderItem) (*models.ProductVariant, error) {
+	if item.VariantID != nil {
+		for i := range product.Variantes {
+			if product.Variantes[i].ID == *item.VariantID {
+				return &product.Variantes[i], nil
+			}
+		}
+		return nil, fmt.Errorf("la variante %d no pertenece al producto %s", *item.VariantID, product.Nombre)
+	}
+
+	if len(product.Variantes) == 0 {
+		return nil, nil
+	}
+
+	talla := strings.TrimSpace(item.Talla)
+	color := strings.ToLower(strings.TrimSpace(item.Color))
+	if talla == "" {
+		return nil, fmt.Errorf("debe indicar la talla para %s", product.Nombre)
+	}
+
+	var sameSize []*models.ProductVariant
+	for i := range product.Variantes {
+		v := &product.Variantes[i]
+		if v.Talla != talla {
+			continue
+		}
+		if v.Color == color {
+			return v, nil
+		}
+		sameSize = append(sameSize, v)
+	}
+
+	// Sin color indicado y una única variante para la talla: no hay ambigüedad
+	if color == "" && len(sameSize) == 1 {
+		return sameSize[0], nil
+	}
+
+	if color == "" && len(sameSize) > 1 {
+		return nil, fmt.Errorf("debe indicar el color para %s talla %s", product.Nombre, talla)
+	}
+
+	return nil, fmt.Errorf("no existe la talla %s en %s", describeVariant(&models.ProductVariant{Talla: talla, Color: color}), product.Nombre)
+}
+
+// describeVariant arma un texto "42 negro" para mensajes de error
+func describeVariant(v *models.ProductVariant) string {
+	if v.Color == "" {
+		return v.Talla
+	}
+	return v.Talla + " " + v.Color
+}
+
+// reduceItemStock descuenta el stock del item (variante o producto sin variantes)
+func reduceItemStock(repos *repositories.TxRepositories, item models.OrderItem, movement models.StockMovement) error {
+	if item.VariantID != nil {
+		return repos.Variants.ReduceStock(*item.VariantID, item.Quantity, movement)
+	}
+	return repos.Products.ReduceStock(item.ProductID, item.Quantity, movement)
+}
+
+// restoreItemStock devuelve al stock la cantidad del item en su misma talla/color. Falla
+// (ErrStockManagedByVariants) si el item no tiene talla y el producto ahora tiene variantes.
+func restoreItemStock(repos *repositories.TxRepositories, item models.OrderItem, movement models.StockMovement) error {
+	if item.VariantID != nil {
+		return repos.Variants.IncreaseStock(*item.VariantID, item.Quantity, movement)
+	}
+
+	// La variante pudo haberse eliminado y recreado: se busca por talla y color
+	if item.Talla != "" {
+		variant, err := repos.Variants.FindBySizeAndColor(item.ProductID, item.Talla, item.Color)
+		if err != nil {
+			return err
+		}
+		if variant == nil {
+			return fmt.Errorf("la talla %s del producto %d ya no existe", describeVariant(&models.ProductVariant{Talla: item.Talla, Color: item.Color}), item.ProductID)
+		}
+		return repos.Variants.IncreaseStock(variant.ID, item.Quantity, movement)
 	}
 
-	// 3. Eliminar la orden
-	return s.repo.Delete(id)
+	// Un pedido anterior a las variantes no sabe a qué talla devolver: no se adivina
+	if err := repos.Products.IncreaseStock(item.ProductID, item.Quantity, movement); err != nil {
+		if errors.Is(err, repositories.ErrStockManagedByVariants) {
+			return fmt.Errorf("%s no indica talla ni color y el producto ahora tiene variantes (el stock debe ajustarse desde las variantes): %w", item.ProductName, err)
+		}
+		return err
+	}
+	return nil
 }
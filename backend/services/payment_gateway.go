@@ -0,0 +1,14 @@
+package services
+
+import "tiendaedgar/backend/models"
+
+// PaymentGateway abstrae la pasarela de pagos online. La implementación real es
+// mercadopago.Client; en tests se usa el mismo cliente contra mercadopagotest.Server.
+type PaymentGateway interface {
+	// CreatePreference inicia un pago y devuelve la URL a la que redirigir al cliente
+	CreatePreference(req models.PaymentPreferenceRequest) (*models.PaymentPreference, error)
+	// GetPayment consulta el estado real de un pago
+	GetPayment(id string) (*models.GatewayPayment, error)
+	// VerifyNotification valida que el aviso del webhook provenga de la pasarela
+	VerifyNotification(n models.PaymentNotification) error
+}
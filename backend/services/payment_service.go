@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// Errores del servicio de pagos online
+var (
+	ErrPaymentGatewayDisabled     = errors.New("los pagos online no están habilitados")
+	ErrInvalidPaymentNotification = errors.New("notificación de pago inválida")
+	ErrOrderNotPayable            = errors.New("el pedido no admite pagos online")
+)
+
+// paymentGatewayActor es el actor registrado en el historial cuando la pasarela confirma un pago
+var paymentGatewayActor = models.SystemActor("mercadopago")
+
+// PaymentNotificationResult describe qué se hizo con un aviso del webhook
+type PaymentNotificationResult struct {
+	Processed bool   `json:"processed"` // true si se registró el pago en el pedido
+	OrderID   uint   `json:"order_id,omitempty"`
+	Message   string `json:"message"`
+}
+
+// PaymentService inicia pagos online de pedidos y procesa las confirmaciones de la pasarela
+type PaymentService struct {
+	orderRepo       *repositories.OrderRepository
+	orderService    *OrderService
+	gateway         PaymentGateway
+	notificationURL string
+	storeURL        string
+}
+
+// NewPaymentService crea una nueva instancia del servicio. gateway puede ser nil
+// si la pasarela no está configurada: los pagos online quedan deshabilitados.
+func NewPaymentService(orderRepo *repositories.OrderRepository, orderService *OrderService, gateway PaymentGateway, notificationURL, storeURL string) *PaymentService {
+	return &PaymentService{
+		orderRepo:       orderRepo,
+		orderService:    orderService,
+		gateway:         gateway,
+		notificationURL: notificationURL,
+		storeURL:        strings.TrimRight(storeURL, "/"),
+	}
+}
+
+// CreatePreferenceByReference inicia el pago de un pedido desde la tienda pública
+func (s *PaymentService) CreatePreferenceByReference(reference string) (*models.PaymentPreference, error) {
+	if s.gateway == nil {
+		return nil, ErrPaymentGatewayDisabled
+	}
+
+	order, err := s.orderRepo.GetByReference(strings.ToUpper(strings.TrimSpace(reference)))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+
+	return s.createPreference(order)
+}
+
+// CreatePreference inicia el pago de un pedido desde el panel de admin
+func (s *PaymentService) CreatePreference(orderID uint) (*models.PaymentPreference, error) {
+	if s.gateway == nil {
+		return nil, ErrPaymentGatewayDisabled
+	}
+
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+
+	return s.createPreference(order)
+}
+
+func (s *PaymentService) createPreference(order *models.Order) (*models.PaymentPreference, error) {
+	if order.Status != models.OrderStatusPending {
+		return nil, fmt.Errorf("%w: el pedido está %s", ErrOrderNotPayable, order.Status)
+	}
+
+	// Se cobra el saldo pendiente (el total menos las señas ya registradas)
+	_, summary, err := s.orderService.GetOrderPayments(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if summary.BalanceDue <= 0 {
+		return nil, fmt.Errorf("%w: el pedido no tiene saldo pendiente", ErrOrderNotPayable)
+	}
+
+	req := models.PaymentPreferenceRequest{
+		ExternalReference: order.Reference,
+		Title:             "Pedido " + order.DisplayNumber(),
+		Amount:            summary.BalanceDue,
+		PayerName:         order.CustomerName,
+		PayerEmail:        order.CustomerEmail,
+		NotificationURL:   s.notificationURL,
+	}
+	if s.storeURL != "" {
+		req.SuccessURL = s.returnURL(order.Reference, "aprobado")
+		req.FailureURL = s.returnURL(order.Reference, "rechazado")
+		req.PendingURL = s.returnURL(order.Reference, "pendiente")
+	}
+
+	preference, err := s.gateway.CreatePreference(req)
+	if err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// returnURL arma la URL de la tienda a la que vuelve el cliente luego de pagar
+func (s *PaymentService) returnURL(reference, result string) string {
+	query := url.Values{"ref": {reference}, "pago": {result}}
+	return s.storeURL + "/pedido?" + query.Encode()
+}
+
+// HandleNotification procesa un aviso del webhook. Nunca confía en el contenido del
+// aviso: valida la firma y consulta el pago a la pasarela antes de tocar el pedido.
+// Es idempotente: un mismo pago se registra una sola vez aunque el aviso se repita.
+// Los avisos de otros tópicos o con un ID de pago inválido se ignoran sin error, para que
+// la pasarela no los reintente; solo las fallas de la pasarela o de la base devuelven error.
+func (s *PaymentService) HandleNotification(n models.PaymentNotification) (*PaymentNotificationResult, error) {
+	if s.gateway == nil {
+		return nil, ErrPaymentGatewayDisabled
+	}
+
+	if n.Type != "payment" || n.DataID == "" {
+		return &PaymentNotificationResult{Message: "notificación ignorada"}, nil
+	}
+
+	if err := s.gateway.VerifyNotification(n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPaymentNotification, err)
+	}
+
+	payment, err := s.gateway.GetPayment(n.DataID)
+	if errors.Is(err, models.ErrInvalidPaymentID) {
+		log.Printf("WARN: aviso de Mercado Pago con ID de pago inválido %q", n.DataID)
+		return &PaymentNotificationResult{Message: "notificación ignorada: ID de pago inválido"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != models.GatewayPaymentApproved {
+		return &PaymentNotificationResult{Message: "pago " + payment.Status}, nil
+	}
+
+	order, err := s.orderRepo.GetByReference(payment.ExternalReference)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		log.Printf("WARN: pago %s aprobado sin pedido asociado (referencia %q)", payment.ID, payment.ExternalReference)
+		return &PaymentNotificationResult{Message: "pedido no encontrado"}, nil
+	}
+
+	result := &PaymentNotificationResult{OrderID: order.ID}
+
+	if order.Status == models.OrderStatusCancelled {
+		log.Printf("WARN: pago %s aprobado para el pedido cancelado %s", payment.ID, order.DisplayNumber())
+		result.Message = "el pedido está cancelado"
+		return result, nil
+	}
+
+	// El pago se registra en el ledger del pedido: si cubre el saldo, el pedido pasa a Pagado
+	entry := &models.OrderPayment{
+		Amount:    roundMoney(payment.Amount),
+		Method:    gatewayPaymentMethod(payment.PaymentTypeID),
+		Reference: "MP-" + payment.ID,
+		Note:      "Mercado Pago",
+	}
+	summary, recorded, err := s.orderService.RecordPaymentOnce(order.ID, entry, paymentGatewayActor)
+	if errors.Is(err, ErrPaymentExceedsBalance) {
+		// Reintentar no lo resuelve: queda para revisión manual
+		log.Printf("WARN: pago %s de $%.2f supera el saldo del pedido %s", payment.ID, payment.Amount, order.DisplayNumber())
+		result.Message = "el pago supera el saldo pendiente del pedido"
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !recorded {
+		result.Message = "pago ya registrado"
+		return result, nil
+	}
+
+	result.Processed = true
+	result.Message = "pago registrado"
+	if summary.BalanceDue <= paymentTolerance {
+		result.Message = "pedido pagado"
+	}
+	return result, nil
+}
+
+// gatewayPaymentMethod traduce el tipo de pago de Mercado Pago a un medio de pago de la tienda
+func gatewayPaymentMethod(paymentTypeID string) models.PaymentMethod {
+	switch paymentTypeID {
+	case "credit_card":
+		return models.PaymentMethodCreditCard
+	case "debit_card", "prepaid_card":
+		return models.PaymentMethodDebitCard
+	case "ticket", "atm":
+		return models.PaymentMethodCash
+	default: // account_money, bank_transfer
+		return models.PaymentMethodTransfer
+	}
+}
@@ -9,28 +9,95 @@ import (
 
 // ProductService maneja la lógica de negocio de productos
 type ProductService struct {
-	repo *repositories.ProductRepository
+	repo        *repositories.ProductRepository
+	variantRepo *repositories.ProductVariantRepository
+	uow         *repositories.UnitOfWork
 }
 
 // NewProductService crea una nueva instancia del servicio
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
+func NewProductService(repo *repositories.ProductRepository, variantRepo *repositories.ProductVariantRepository, uow *repositories.UnitOfWork) *ProductService {
 	return &ProductService{
-		repo: repo,
+		repo:        repo,
+		variantRepo: variantRepo,
+		uow:         uow,
 	}
 }
 
-// CreateProduct crea un nuevo producto con validaciones
-func (s *ProductService) CreateProduct(product *models.Product) error {
+// CreateProduct crea un nuevo producto con validaciones. El producto y sus variantes se
+// crean en una única transacción: si alguna variante falla no queda nada registrado.
+func (s *ProductService) CreateProduct(product *models.Product, actor models.Actor) error {
 	// Validar el producto
 	if err := product.ValidateCreate(); err != nil {
 		return err
 	}
 
-	// Crear el producto en la base de datos
-	if err := s.repo.Create(product); err != nil {
-		return fmt.Errorf("error al crear producto: %w", err)
+	seen := map[[2]string]bool{}
+	for i := range product.Variantes {
+		if err := product.Variantes[i].Validate(); err != nil {
+			return fmt.Errorf("variante %d: %w", i+1, err)
+		}
+		// El SKU se genera al crearla (con el ID del producto): acá solo importan talla y color
+		variant := product.Variantes[i]
+		variant.Normalize()
+		key := [2]string{variant.Talla, variant.Color}
+		if seen[key] {
+			return fmt.Errorf("variante %d: la talla %s color %q está repetida", i+1, variant.Talla, variant.Color)
+		}
+		seen[key] = true
+	}
+
+	// Con variantes el stock total se calcula desde ellas; el valor recibido se descarta
+	// para no registrar en el ledger un stock que luego se reemplaza.
+	if len(product.Variantes) > 0 || len(product.StockBySize) > 0 {
+		product.Stock = 0
+	}
+
+	movement := models.NewStockMovement(models.StockMovementManualAdjust, actor, "Alta de producto")
+
+	err := s.uow.Do(func(repos *repositories.TxRepositories) error {
+		// Crear el producto en la base de datos
+		if err := repos.Products.Create(product, movement); err != nil {
+			return fmt.Errorf("error al crear producto: %w", err)
+		}
+
+		// Crear las variantes; si no vienen, se derivan del stock_by_size legacy
+		if len(product.Variantes) > 0 {
+			for i := range product.Variantes {
+				variant := &product.Variantes[i]
+				variant.ProductID = product.ID
+				variant.Normalize()
+				if err := repos.Variants.Create(variant, movement); err != nil {
+					return fmt.Errorf("error al crear variante %s/%s: %w", variant.Talla, variant.Color, err)
+				}
+			}
+		} else if len(product.StockBySize) > 0 {
+			if err := repos.Variants.ReplaceSizeStock(product.ID, product.StockBySize, movement); err != nil {
+				return fmt.Errorf("error al crear variantes: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		product.ID = 0
+		return err
 	}
 
+	return s.refreshStock(product)
+}
+
+// refreshStock recarga stock, stock_by_size y variantes calculados en la base de datos
+func (s *ProductService) refreshStock(product *models.Product) error {
+	saved, err := s.repo.GetByID(product.ID)
+	if err != nil {
+		return fmt.Errorf("error al obtener producto: %w", err)
+	}
+	if saved == nil {
+		return fmt.Errorf("producto no encontrado")
+	}
+
+	product.Stock = saved.Stock
+	product.StockBySize = saved.StockBySize
+	product.Variantes = saved.Variantes
 	return nil
 }
 
@@ -67,7 +134,7 @@ func (s *ProductService) GetProductByID(id uint) (*models.Product, error) {
 }
 
 // UpdateProduct actualiza un producto completo
-func (s *ProductService) UpdateProduct(product *models.Product) error {
+func (s *ProductService) UpdateProduct(product *models.Product, actor models.Actor) error {
 	// Validar el producto
 	if err := product.ValidateUpdate(); err != nil {
 		return err
@@ -83,16 +150,29 @@ func (s *ProductService) UpdateProduct(product *models.Product) error {
 		return fmt.Errorf("producto no encontrado")
 	}
 
+	movement := models.NewStockMovement(models.StockMovementManualAdjust, actor, "Edición de producto")
+
 	// Actualizar el producto
-	if err := s.repo.Update(product); err != nil {
+	if err := s.repo.Update(product, movement); err != nil {
 		return fmt.Errorf("error al actualizar producto: %w", err)
 	}
 
-	return nil
+	// El stock de un producto con variantes se gestiona desde /variants: el stock y el
+	// stock_by_size que trae el PUT (posiblemente desactualizados) se ignoran. Un producto
+	// sin variantes que envía stock_by_size pasa a tenerlas (una por talla).
+	if len(product.StockBySize) > 0 && len(existing.Variantes) == 0 {
+		if err := s.variantRepo.ReplaceSizeStock(product.ID, product.StockBySize, movement); err != nil {
+			return fmt.Errorf("error al actualizar variantes: %w", err)
+		}
+	} else if err := s.variantRepo.SyncProductStock(product.ID); err != nil {
+		return fmt.Errorf("error al sincronizar stock: %w", err)
+	}
+
+	return s.refreshStock(product)
 }
 
 // PartialUpdateProduct actualiza campos específicos de un producto
-func (s *ProductService) PartialUpdateProduct(id uint, updates map[string]interface{}) error {
+func (s *ProductService) PartialUpdateProduct(id uint, updates map[string]interface{}, actor models.Actor) error {
 	// Verificar que el producto existe
 	existing, err := s.repo.GetByID(id)
 	if err != nil {
@@ -110,8 +190,16 @@ func (s *ProductService) PartialUpdateProduct(id uint, updates map[string]interf
 		}
 	}
 
+	// El stock de productos con variantes se gestiona desde /variants
+	_, hasStock := updates["stock"]
+	_, hasStockBySize := updates["stock_by_size"]
+	if (hasStock || hasStockBySize) && len(existing.Variantes) > 0 {
+		return repositories.ErrStockManagedByVariants
+	}
+
 	// Actualizar el producto
-	if err := s.repo.PartialUpdate(id, updates); err != nil {
+	movement := models.NewStockMovement(models.StockMovementManualAdjust, actor, "Ajuste de stock")
+	if err := s.repo.PartialUpdate(id, updates, movement); err != nil {
 		return fmt.Errorf("error al actualizar producto: %w", err)
 	}
 
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// ProductVariantService maneja la lógica de negocio de variantes de producto
+type ProductVariantService struct {
+	repo        *repositories.ProductVariantRepository
+	productRepo *repositories.ProductRepository
+}
+
+// NewProductVariantService crea una nueva instancia del servicio
+func NewProductVariantService(repo *repositories.ProductVariantRepository, productRepo *repositories.ProductRepository) *ProductVariantService {
+	return &ProductVariantService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+// GetVariants obtiene las variantes de un producto
+func (s *ProductVariantService) GetVariants(productID uint) ([]models.ProductVariant, error) {
+	if err := s.ensureProduct(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByProductID(productID)
+}
+
+// CreateVariant crea una nueva variante para un producto
+func (s *ProductVariantService) CreateVariant(productID uint, variant *models.ProductVariant, actor models.Actor) error {
+	if err := s.ensureProduct(productID); err != nil {
+		return err
+	}
+
+	variant.ProductID = productID
+	variant.Normalize()
+	if err := variant.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.FindBySizeAndColor(productID, variant.Talla, variant.Color)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("ya existe una variante talla %s color %q para este producto", variant.Talla, variant.Color)
+	}
+
+	return s.repo.Create(variant, models.NewStockMovement(models.StockMovementManualAdjust, actor, "Variante creada"))
+}
+
+// UpdateVariant actualiza una variante existente
+func (s *ProductVariantService) UpdateVariant(productID uint, variant *models.ProductVariant, actor models.Actor) error {
+	existing, err := s.repo.GetByID(variant.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.ProductID != productID {
+		return fmt.Errorf("variante no encontrada")
+	}
+
+	variant.ProductID = productID
+	variant.Normalize()
+	if err := variant.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(variant, models.NewStockMovement(models.StockMovementManualAdjust, actor, "Variante actualizada")); err != nil {
+		return err
+	}
+
+	// Recargar para devolver los timestamps persistidos
+	updated, err := s.repo.GetByID(variant.ID)
+	if err != nil {
+		return err
+	}
+	if updated != nil {
+		*variant = *updated
+	}
+
+	return nil
+}
+
+// DeleteVariant elimina una variante de un producto
+func (s *ProductVariantService) DeleteVariant(productID, variantID uint, actor models.Actor) error {
+	return s.repo.Delete(productID, variantID, models.NewStockMovement(models.StockMovementManualAdjust, actor, "Variante eliminada"))
+}
+
+// ensureProduct verifica que el producto exista
+func (s *ProductVariantService) ensureProduct(productID uint) error {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return fmt.Errorf("error al verificar producto: %w", err)
+	}
+	if product == nil {
+		return fmt.Errorf("producto no encontrado")
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+var (
+	// ErrReturnExceedsPurchased indica que se intenta devolver más unidades de las compradas
+	ErrReturnExceedsPurchased = errors.New("la devolución supera las unidades compradas")
+	// ErrOrderNotReturnable indica que el pedido todavía no fue despachado
+	ErrOrderNotReturnable = errors.New("solo se aceptan devoluciones de pedidos enviados o entregados")
+	// ErrOrderHasReturns indica que el pedido tiene devoluciones y ya no puede cancelarse,
+	// eliminarse ni modificarse: lo devuelto ya volvió al stock y al saldo del pedido
+	ErrOrderHasReturns = errors.New("el pedido tiene devoluciones registradas")
+)
+
+// ReturnService maneja las devoluciones y cambios de talla de los pedidos
+type ReturnService struct {
+	orderRepo  *repositories.OrderRepository
+	returnRepo *repositories.OrderReturnRepository
+	uow        *repositories.UnitOfWork
+}
+
+// NewReturnService crea una nueva instancia del servicio
+func NewReturnService(orderRepo *repositories.OrderRepository, returnRepo *repositories.OrderReturnRepository, uow *repositories.UnitOfWork) *ReturnService {
+	return &ReturnService{orderRepo: orderRepo, returnRepo: returnRepo, uow: uow}
+}
+
+// GetOrderReturns obtiene las devoluciones y cambios de un pedido
+func (s *ReturnService) GetOrderReturns(orderID uint) ([]models.OrderReturn, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+
+	return s.returnRepo.GetByOrderID(orderID)
+}
+
+// CreateReturn registra una devolución o un cambio en una única transacción:
+//   - los productos en buen estado vuelven al stock (motivo "return"); los dañados se dan de baja
+//   - en un cambio se descuentan los productos entregados (motivo "exchange") y, si valen menos
+//     que lo devuelto, la diferencia se emite como crédito
+//   - el crédito es un cupón de monto fijo y un solo uso; el reintegro se registra en el ledger de pagos
+//   - el saldo del pedido se ajusta por la diferencia (ver models.Order.AmountDue)
+//   - el resultado queda en el historial del pedido
+func (s *ReturnService) CreateReturn(orderID uint, req *models.OrderReturnRequest, actor models.Actor) (*models.OrderReturn, error) {
+	ret := &models.OrderReturn{
+		OrderID:    orderID,
+		Resolution: req.Resolution,
+		Note:       req.Note,
+		UserID:     actor.UserID,
+		CreatedBy:  actor.Username,
+	}
+	if ret.CreatedBy == "" {
+		ret.CreatedBy = "sistema"
+	}
+
+	err := s.uow.Do(func(repos *repositories.TxRepositories) error {
+		order, err := repos.Orders.GetByID(orderID)
+		if err != nil {
+			return fmt.Errorf("error al obtener orden: %w", err)
+		}
+		if order == nil {
+			return fmt.Errorf("orden no encontrada")
+		}
+		if !order.Status.AllowsReturns() {
+			return fmt.Errorf("%w: el pedido está %s", ErrOrderNotReturnable, order.Status)
+		}
+
+		// 1. Validar lo devuelto contra lo comprado y lo ya devuelto antes
+		previous, err := repos.Returns.GetByOrderID(orderID)
+		if err != nil {
+			return err
+		}
+		if err := buildReturnItems(ret, order, req.Items, models.ReturnedQuantities(previous)); err != nil {
+			return err
+		}
+
+		// 2. Reingresar al stock lo que está en condiciones de venderse
+		movement := models.NewStockMovement(models.StockMovementReturn, actor, "Devolución del pedido "+order.DisplayNumber()).ForOrder(orderID)
+		for i := range ret.Items {
+			item := &ret.Items[i]
+			if item.Condition != models.ReturnConditionResellable {
+				continue
+			}
+			orderItem := models.OrderItem{ProductID: item.ProductID, VariantID: item.VariantID, Talla: item.Talla, Color: item.Color, Quantity: item.Quantity}
+			if err := restoreItemStock(repos, orderItem, movement); err != nil {
+				return fmt.Errorf("error al reingresar stock de %s: %w", item.ProductName, err)
+			}
+			item.Restocked = true
+		}
+
+		// 3. Entregar los productos del cambio
+		if err := deliverReplacements(repos, ret, order, req.Replacements, actor); err != nil {
+			return err
+		}
+		ret.Difference = roundMoney(ret.ReplacementAmount - ret.ReturnedAmount)
+
+		// 4. Crédito o reintegro
+		switch {
+		case req.Resolution == models.ReturnResolutionStoreCredit:
+			if err := issueStoreCredit(repos, ret, order, ret.ReturnedAmount); err != nil {
+				return err
+			}
+		case req.Resolution == models.ReturnResolutionExchange && ret.Difference < -paymentTolerance:
+			if err := issueStoreCredit(repos, ret, order, -ret.Difference); err != nil {
+				return err
+			}
+		case req.Resolution == models.ReturnResolutionRefund:
+			refund := &models.OrderPayment{
+				Kind:      models.OrderPaymentKindRefund,
+				Amount:    ret.ReturnedAmount,
+				Method:    req.RefundMethod,
+				Reference: "DEV-" + order.Reference,
+				Note:      req.Note,
+			}
+			if _, err := applyPayment(repos, orderID, refund, actor); err != nil {
+				return err
+			}
+			ret.RefundPaymentID = &refund.ID
+		}
+
+		// 5. Ajustar el saldo del pedido, registrar la devolución y dejarla en el historial
+		if err := repos.Orders.AddReturnAdjustment(orderID, -ret.Difference); err != nil {
+			return err
+		}
+		if err := repos.Returns.Create(ret); err != nil {
+			return err
+		}
+		return recordStatusChange(repos, orderID, order.Status, order.Status, actor, describeReturn(ret))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ensureNoReturns falla con ErrOrderHasReturns si el pedido tiene devoluciones. Se usa
+// antes de devolver al stock los items del pedido, para no reingresar dos veces lo devuelto.
+func ensureNoReturns(repos *repositories.TxRepositories, orderID uint) error {
+	returns, err := repos.Returns.GetByOrderID(orderID)
+	if err != nil {
+		return err
+	}
+	if len(returns) > 0 {
+		return ErrOrderHasReturns
+	}
+	return nil
+}
+
+// buildReturnItems arma las líneas devueltas a partir de las líneas del pedido y valida
+// que no se devuelvan más unidades de las compradas (descontando devoluciones anteriores)
+func buildReturnItems(ret *models.OrderReturn, order *models.Order, requested []models.ReturnItemRequest, returned map[string]int) error {
+	purchased := map[string]int{}
+	for _, item := range order.Items {
+		purchased[item.StockKey()] += item.Quantity
+	}
+
+	for _, req := range requested {
+		var line *models.OrderItem
+		for i := range order.Items {
+			if order.Items[i].ID == req.OrderItemID {
+				line = &order.Items[i]
+				break
+			}
+		}
+		if line == nil {
+			return fmt.Errorf("la línea %d no pertenece al pedido", req.OrderItemID)
+		}
+
+		key := line.StockKey()
+		returned[key] += req.Quantity
+		if returned[key] > purchased[key] {
+			return fmt.Errorf("%w: %s (compradas %d, devueltas %d)", ErrReturnExceedsPurchased, line.ProductName, purchased[key], returned[key])
+		}
+
+		unitPrice := models.PaidUnitPrice(order, *line)
+		ret.Items = append(ret.Items, models.ReturnItem{
+			OrderItemID: line.ID,
+			ProductID:   line.ProductID,
+			VariantID:   line.VariantID,
+			ProductName: line.ProductName,
+			Talla:       line.Talla,
+			Color:       line.Color,
+			Quantity:    req.Quantity,
+			UnitPrice:   unitPrice,
+			Condition:   req.Condition,
+		})
+		ret.ReturnedAmount += unitPrice * float64(req.Quantity)
+	}
+
+	ret.ReturnedAmount = roundMoney(ret.ReturnedAmount)
+	return nil
+}
+
+// deliverReplacements valida el stock de los productos del cambio, los descuenta y los
+// valoriza con el precio vigente
+func deliverReplacements(repos *repositories.TxRepositories, ret *models.OrderReturn, order *models.Order, requested []models.CheckoutItem, actor models.Actor) error {
+	ret.Replacements = []models.ReturnReplacement{}
+	if len(requested) == 0 {
+		return nil
+	}
+
+	items := make([]models.OrderItem, len(requested))
+	for i, line := range requested {
+		items[i] = models.OrderItem{ProductID: line.ProductID, VariantID: line.VariantID, Talla: line.Talla, Color: line.Color, Quantity: line.Quantity}
+	}
+	products, err := resolveItems(repos, items)
+	if err != nil {
+		return err
+	}
+
+	movement := models.NewStockMovement(models.StockMovementExchange, actor, "Cambio del pedido "+order.DisplayNumber()).ForOrder(order.ID)
+	for _, item := range items {
+		product := products[item.ProductID]
+		unitPrice := product.Precio
+		if item.VariantID != nil {
+			for _, v := range product.Variantes {
+				if v.ID == *item.VariantID {
+					unitPrice = v.EffectivePrice(product.Precio)
+					break
+				}
+			}
+		}
+		unitPrice = roundMoney(unitPrice)
+		item.ProductName = product.Nombre
+
+		if err := reduceItemStock(repos, item, movement); err != nil {
+			return fmt.Errorf("error al descontar stock de %s: %w", item.ProductName, err)
+		}
+
+		ret.Replacements = append(ret.Replacements, models.ReturnReplacement{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			ProductName: item.ProductName,
+			Talla:       item.Talla,
+			Color:       item.Color,
+			Quantity:    item.Quantity,
+			UnitPrice:   unitPrice,
+		})
+		ret.ReplacementAmount += unitPrice * float64(item.Quantity)
+	}
+
+	ret.ReplacementAmount = roundMoney(ret.ReplacementAmount)
+	return nil
+}
+
+// issueStoreCredit emite el crédito como un cupón de monto fijo y un solo uso
+func issueStoreCredit(repos *repositories.TxRepositories, ret *models.OrderReturn, order *models.Order, amount float64) error {
+	coupon := &models.Coupon{
+		Code:        "CRED-" + models.NewOrderReference(),
+		Description: "Crédito por devolución del pedido " + order.DisplayNumber(),
+		Type:        models.CouponTypeFixed,
+		Value:       roundMoney(amount),
+		UsageLimit:  1,
+		Active:      true,
+	}
+	coupon.Normalize()
+	if err := coupon.Validate(); err != nil {
+		return err
+	}
+	if err := repos.Coupons.Create(coupon); err != nil {
+		return err
+	}
+
+	ret.StoreCreditCode = coupon.Code
+	return nil
+}
+
+// describeReturn resume la devolución para el historial del pedido.
+// Ej: "Cambio: devuelve Zapa 42 x1; entrega Zapa 43 x1"
+func describeReturn(ret *models.OrderReturn) string {
+	label := map[models.ReturnResolution]string{
+		models.ReturnResolutionExchange:    "Cambio",
+		models.ReturnResolutionStoreCredit: "Devolución con crédito",
+		models.ReturnResolutionRefund:      "Devolución con reintegro",
+	}[ret.Resolution]
+
+	returned := make([]string, len(ret.Items))
+	for i, item := range ret.Items {
+		returned[i] = describeLine(item.ProductName, item.Talla, item.Color, item.Quantity)
+		if !item.Restocked {
+			returned[i] += " (baja)"
+		}
+	}
+	note := label + ": devuelve " + strings.Join(returned, ", ")
+
+	if len(ret.Replacements) > 0 {
+		delivered := make([]string, len(ret.Replacements))
+		for i, item := range ret.Replacements {
+			delivered[i] = describeLine(item.ProductName, item.Talla, item.Color, item.Quantity)
+		}
+		note += "; entrega " + strings.Join(delivered, ", ")
+	}
+	if ret.Difference > paymentTolerance {
+		note += fmt.Sprintf("; diferencia a cobrar $%.2f", ret.Difference)
+	}
+	if ret.StoreCreditCode != "" {
+		note += fmt.Sprintf("; crédito %s", ret.StoreCreditCode)
+	}
+	if ret.RefundPaymentID != nil {
+		note += fmt.Sprintf("; reintegro $%.2f", ret.ReturnedAmount)
+	}
+	if ret.Note != "" {
+		note += " — " + ret.Note
+	}
+	return note
+}
+
+// describeLine arma un texto "Zapa 42 negro x1"
+func describeLine(name, talla, color string, quantity int) string {
+	parts := []string{name}
+	if variant := describeVariant(&models.ProductVariant{Talla: talla, Color: color}); variant != "" {
+		parts = append(parts, variant)
+	}
+	return fmt.Sprintf("%s x%d", strings.Join(parts, " "), quantity)
+}
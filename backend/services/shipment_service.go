@@ -0,0 +1,324 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// Errores de los envíos
+var (
+	ErrOrderNotShippable = errors.New("el pedido debe estar en preparación o enviado para registrar un envío")
+	ErrShipmentNotFound  = errors.New("envío no encontrado")
+	ErrLabelNotFound     = errors.New("el envío no tiene etiqueta")
+	ErrInvalidLabelType  = errors.New("tipo de archivo no permitido. Use PDF, PNG o JPG")
+	ErrInvalidDelivery   = errors.New("la entrega estimada no puede ser anterior al despacho")
+)
+
+// ShipmentService maneja los envíos de los pedidos y la actualización automática de su
+// estado consultando a los transportistas integrados
+type ShipmentService struct {
+	orderRepo    *repositories.OrderRepository
+	shipmentRepo *repositories.ShipmentRepository
+	uow          *repositories.UnitOfWork
+	labelsDir    string                    // Carpeta privada de las etiquetas
+	trackers     map[string]CarrierTracker // Transportistas con seguimiento, por código
+}
+
+// NewShipmentService crea una nueva instancia del servicio. trackers asocia el código de
+// cada transportista integrado con su cliente; los envíos de otros transportistas se
+// actualizan a mano.
+func NewShipmentService(orderRepo *repositories.OrderRepository, shipmentRepo *repositories.ShipmentRepository, uow *repositories.UnitOfWork, labelsDir string, trackers map[string]CarrierTracker) *ShipmentService {
+	return &ShipmentService{
+		orderRepo:    orderRepo,
+		shipmentRepo: shipmentRepo,
+		uow:          uow,
+		labelsDir:    labelsDir,
+		trackers:     trackers,
+	}
+}
+
+// GetOrderShipments obtiene los envíos de un pedido
+func (s *ShipmentService) GetOrderShipments(orderID uint) ([]models.Shipment, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener orden: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+
+	return s.shipmentRepo.GetByOrderID(orderID)
+}
+
+// CreateShipment registra el despacho de un pedido en preparación o ya enviado. Si el
+// pedido estaba en preparación pasa a Enviado; el número de seguimiento se copia al
+// pedido para que lo vea el cliente en el seguimiento público.
+func (s *ShipmentService) CreateShipment(orderID uint, req *models.ShipmentRequest, actor models.Actor) (*models.Shipment, error) {
+	shipment := &models.Shipment{OrderID: orderID, CreatedBy: actor.Username}
+	if shipment.CreatedBy == "" {
+		shipment.CreatedBy = "sistema"
+	}
+	req.Apply(shipment, time.Now())
+
+	err := s.uow.Do(func(repos *repositories.TxRepositories) error {
+		order, err := repos.Orders.GetByID(orderID)
+		if err != nil {
+			return fmt.Errorf("error al obtener orden: %w", err)
+		}
+		if order == nil {
+			return fmt.Errorf("orden no encontrada")
+		}
+		if order.Status != models.OrderStatusProcessing && order.Status != models.OrderStatusShipped {
+			return ErrOrderNotShippable
+		}
+
+		if err := repos.Shipments.Create(shipment); err != nil {
+			return err
+		}
+		if shipment.TrackingNumber != "" {
+			if err := repos.Orders.UpdateTrackingNumber(orderID, shipment.TrackingNumber); err != nil {
+				return err
+			}
+		}
+
+		if order.Status == models.OrderStatusProcessing {
+			note := "Despachado por " + shipment.Carrier
+			if shipment.TrackingNumber != "" {
+				note += " (" + shipment.TrackingNumber + ")"
+			}
+			return changeOrderStatus(repos, order, models.OrderStatusShipped, actor, note)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// UpdateShipment corrige el transportista, el número de seguimiento o las fechas de un envío
+func (s *ShipmentService) UpdateShipment(orderID, shipmentID uint, req *models.ShipmentRequest) (*models.Shipment, error) {
+	var shipment *models.Shipment
+	err := s.uow.Do(func(repos *repositories.TxRepositories) error {
+		var err error
+		shipment, err = repos.Shipments.GetByID(orderID, shipmentID)
+		if err != nil {
+			return err
+		}
+		if shipment == nil {
+			return ErrShipmentNotFound
+		}
+
+		shippedAt := shipment.ShippedAt
+		req.Apply(shipment, shippedAt)
+		if req.EstimatedDelivery != nil && req.EstimatedDelivery.Before(shipment.ShippedAt) {
+			return ErrInvalidDelivery
+		}
+		if err := repos.Shipments.Update(shipment); err != nil {
+			return err
+		}
+		if shipment.TrackingNumber != "" {
+			return repos.Orders.UpdateTrackingNumber(orderID, shipment.TrackingNumber)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// labelExtensions son los formatos de etiqueta aceptados
+var labelExtensions = map[string]bool{".pdf": true, ".png": true, ".jpg": true, ".jpeg": true}
+
+// IsLabelExtension indica si la extensión es de un formato de etiqueta aceptado
+func IsLabelExtension(ext string) bool {
+	return labelExtensions[strings.ToLower(ext)]
+}
+
+// SaveLabel guarda la etiqueta del envío en la carpeta privada, reemplazando la anterior.
+// ext es la extensión del archivo original (ver IsLabelExtension).
+func (s *ShipmentService) SaveLabel(orderID, shipmentID uint, ext string, content io.Reader) (*models.Shipment, error) {
+	ext = strings.ToLower(ext)
+	if !IsLabelExtension(ext) {
+		return nil, ErrInvalidLabelType
+	}
+
+	shipment, err := s.shipmentRepo.GetByID(orderID, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment == nil {
+		return nil, ErrShipmentNotFound
+	}
+
+	if err := os.MkdirAll(s.labelsDir, 0o750); err != nil {
+		return nil, fmt.Errorf("error al crear la carpeta de etiquetas: %w", err)
+	}
+	filename := fmt.Sprintf("shipment-%d-%d%s", shipment.ID, time.Now().UnixNano(), ext)
+	file, err := os.OpenFile(filepath.Join(s.labelsDir, filename), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar la etiqueta: %w", err)
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("error al guardar la etiqueta: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("error al guardar la etiqueta: %w", err)
+	}
+
+	previous := shipment.LabelFile
+	shipment.LabelFile = filename
+	if err := s.shipmentRepo.Update(shipment); err != nil {
+		os.Remove(filepath.Join(s.labelsDir, filename))
+		return nil, err
+	}
+	if previous != "" {
+		os.Remove(filepath.Join(s.labelsDir, previous))
+	}
+
+	return shipment, nil
+}
+
+// LabelPath devuelve la ruta del archivo de la etiqueta del envío
+func (s *ShipmentService) LabelPath(orderID, shipmentID uint) (string, error) {
+	shipment, err := s.shipmentRepo.GetByID(orderID, shipmentID)
+	if err != nil {
+		return "", err
+	}
+	if shipment == nil {
+		return "", ErrShipmentNotFound
+	}
+	if shipment.LabelFile == "" {
+		return "", ErrLabelNotFound
+	}
+	return filepath.Join(s.labelsDir, shipment.LabelFile), nil
+}
+
+// SyncDeliveries consulta a los transportistas integrados el estado de los envíos en
+// camino y guarda lo informado. Cuando un envío figura entregado, el pedido pasa de
+// Enviado a Entregado (en el historial queda "transportista:{código}"). Los errores de
+// un envío se registran en el log y no frenan al resto. Devuelve cuántos pedidos se
+// marcaron como entregados.
+func (s *ShipmentService) SyncDeliveries() (int, error) {
+	shipments, err := s.shipmentRepo.GetPendingTracking(s.trackedCarriers())
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range shipments {
+		shipment := &shipments[i]
+		// La consulta al transportista se hace fuera de la transacción
+		status, err := s.trackers[shipment.Carrier].TrackShipment(shipment.TrackingNumber)
+		if err != nil {
+			log.Printf("WARN: no se pudo consultar el envío %d (%s %s): %v", shipment.ID, shipment.Carrier, shipment.TrackingNumber, err)
+			continue
+		}
+
+		ok, err := s.applyCarrierStatus(shipment, *status)
+		if err != nil {
+			log.Printf("WARN: no se pudo actualizar el envío %d: %v", shipment.ID, err)
+			continue
+		}
+		if ok {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// trackedCarriers devuelve los códigos de los transportistas integrados, ordenados
+func (s *ShipmentService) trackedCarriers() []string {
+	carriers := make([]string, 0, len(s.trackers))
+	for code := range s.trackers {
+		carriers = append(carriers, code)
+	}
+	sort.Strings(carriers)
+	return carriers
+}
+
+// applyCarrierStatus guarda el estado informado y, si el envío se entregó, marca el pedido
+// como Entregado. Devuelve true si el pedido cambió de estado.
+func (s *ShipmentService) applyCarrierStatus(shipment *models.Shipment, status models.CarrierStatus) (bool, error) {
+	justDelivered, err := shipment.ApplyCarrierStatus(status, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	orderDelivered := false
+	err = s.uow.Do(func(repos *repositories.TxRepositories) error {
+		if err := repos.Shipments.Update(shipment); err != nil {
+			return err
+		}
+		if !justDelivered {
+			return nil
+		}
+
+		order, err := repos.Orders.GetByID(shipment.OrderID)
+		if err != nil {
+			return fmt.Errorf("error al obtener orden: %w", err)
+		}
+		// Si el pedido ya no está Enviado (ej. se marcó a mano) solo se guarda el envío
+		if order == nil || order.Status != models.OrderStatusShipped {
+			return nil
+		}
+
+		note := "Entregado según " + shipment.Carrier
+		if shipment.TrackingNumber != "" {
+			note += " (" + shipment.TrackingNumber + ")"
+		}
+		actor := models.SystemActor("transportista:" + shipment.Carrier)
+		if err := changeOrderStatus(repos, order, models.OrderStatusDelivered, actor, note); err != nil {
+			return err
+		}
+		orderDelivered = true
+		return nil
+	})
+	return orderDelivered, err
+}
+
+// StartSync ejecuta SyncDeliveries cada interval en segundo plano. Si el intervalo es 0 o
+// no hay transportistas integrados no hace nada y lo avisa en el log.
+func (s *ShipmentService) StartSync(interval time.Duration) {
+	if interval <= 0 {
+		log.Printf("Seguimiento automático de envíos deshabilitado (SHIPMENT_SYNC_INTERVAL=0)")
+		return
+	}
+	if len(s.trackers) == 0 {
+		log.Printf("Seguimiento automático de envíos deshabilitado: no hay transportistas integrados")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			delivered, err := s.SyncDeliveries()
+			if err != nil {
+				log.Printf("Error al actualizar envíos: %v", err)
+				continue
+			}
+			if delivered > 0 {
+				log.Printf("Envíos: %d pedidos marcados como entregados", delivered)
+			}
+		}
+	}()
+	log.Printf("Seguimiento de envíos cada %s (%s)", interval, strings.Join(s.trackedCarriers(), ", "))
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// stockAlertBatchSize es la cantidad máxima de alertas por email
+const stockAlertBatchSize = 100
+
+// StockAlertService lista el stock bajo y envía a la tienda las alertas que generan los
+// movimientos de stock al cruzar LowStockThreshold (ver repositories.raiseStockAlert)
+type StockAlertService struct {
+	alertRepo  *repositories.StockAlertRepository
+	configRepo *repositories.ConfigRepository
+	sender     EmailSender // nil si no hay servidor SMTP configurado
+	adminEmail string      // Destinatario de las alertas (puede estar vacío)
+}
+
+// NewStockAlertService crea una nueva instancia del servicio
+func NewStockAlertService(alertRepo *repositories.StockAlertRepository, configRepo *repositories.ConfigRepository, sender EmailSender, adminEmail string) *StockAlertService {
+	return &StockAlertService{
+		alertRepo:  alertRepo,
+		configRepo: configRepo,
+		sender:     sender,
+		adminEmail: strings.TrimSpace(adminEmail),
+	}
+}
+
+// GetLowStock obtiene los productos y variantes activos con stock en el umbral de la
+// configuración o por debajo, con el umbral usado
+func (s *StockAlertService) GetLowStock() ([]models.LowStockItem, int, error) {
+	config, err := s.configRepo.GetConfig()
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := s.alertRepo.GetLowStock(config.LowStockThreshold)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, config.LowStockThreshold, nil
+}
+
+// GetAlerts obtiene las últimas alertas; unreadOnly devuelve solo las no vistas
+func (s *StockAlertService) GetAlerts(unreadOnly bool, limit int) ([]models.StockAlert, error) {
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	return s.alertRepo.GetAll(unreadOnly, limit)
+}
+
+// MarkRead marca una alerta como vista
+func (s *StockAlertService) MarkRead(id uint) error {
+	found, err := s.alertRepo.MarkRead(id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("alerta no encontrada")
+	}
+	return nil
+}
+
+// DispatchPending envía en un solo email las alertas pendientes y devuelve cuántas se
+// enviaron. Si las alertas de stock están deshabilitadas o no hay email de la tienda, se
+// descartan (siguen visibles en el panel); los errores de envío se reintentan en la
+// siguiente pasada hasta models.MaxNotificationAttempts.
+func (s *StockAlertService) DispatchPending() (int, error) {
+	if s.sender == nil {
+		return 0, nil
+	}
+
+	alerts, err := s.alertRepo.GetPendingEmail(stockAlertBatchSize)
+	if err != nil || len(alerts) == 0 {
+		return 0, err
+	}
+	ids := make([]uint, len(alerts))
+	for i, alert := range alerts {
+		ids[i] = alert.ID
+	}
+
+	config, err := s.configRepo.GetConfig()
+	if err != nil {
+		return 0, err
+	}
+	if !config.EnableStockAlerts {
+		return 0, s.alertRepo.MarkSkipped(ids, "alertas de stock deshabilitadas")
+	}
+	if s.adminEmail == "" {
+		return 0, s.alertRepo.MarkSkipped(ids, "no hay email de la tienda (ORDER_ALERTS_EMAIL)")
+	}
+
+	msg, err := RenderStockAlertEmail(config.StoreName, alerts)
+	if err != nil {
+		return 0, err
+	}
+	msg.To = []string{s.adminEmail}
+
+	if err := s.sender.Send(*msg); err != nil {
+		log.Printf("Error al enviar alertas de stock: %v", err)
+		return 0, s.alertRepo.MarkFailed(ids, err)
+	}
+	if err := s.alertRepo.MarkSent(ids); err != nil {
+		return 0, err
+	}
+	return len(alerts), nil
+}
+
+// StartDispatcher ejecuta DispatchPending cada interval en segundo plano. No hace nada si
+// no hay servidor SMTP configurado.
+func (s *StockAlertService) StartDispatcher(interval time.Duration) {
+	if s.sender == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.DispatchPending(); err != nil {
+				log.Printf("Error al enviar alertas de stock: %v", err)
+			}
+		}
+	}()
+	log.Printf("Envío de alertas de stock cada %s", interval)
+}
+
+// stockAlertLine es una alerta formateada para el email
+type stockAlertLine struct {
+	Product string
+	Status  string
+	Stock   int
+}
+
+var stockAlertText = texttemplate.Must(texttemplate.New("text").Parse(`Alertas de stock de {{.StoreName}}:
+
+{{range .Lines}}- {{.Product}}: {{.Status}} (stock {{.Stock}})
+{{end}}`))
+
+var stockAlertHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html lang="es">
+<body style="font-family: Arial, sans-serif; color: #222; max-width: 600px;">
+<p>Alertas de stock de {{.StoreName}}:</p>
+<table style="width: 100%; border-collapse: collapse;">
+<tr><th style="text-align: left;">Producto</th><th style="text-align: left;">Estado</th><th style="text-align: right;">Stock</th></tr>
+{{range .Lines}}<tr><td>{{.Product}}</td><td>{{.Status}}</td><td style="text-align: right;">{{.Stock}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// RenderStockAlertEmail arma el email a la tienda con las alertas de stock
+func RenderStockAlertEmail(storeName string, alerts []models.StockAlert) (*models.EmailMessage, error) {
+	outOfStock := 0
+	lines := make([]stockAlertLine, len(alerts))
+	for i, alert := range alerts {
+		status := fmt.Sprintf("stock bajo (umbral %d)", alert.Threshold)
+		if alert.Kind == models.StockAlertOutOfStock {
+			status = "agotado"
+			outOfStock++
+		}
+		lines[i] = stockAlertLine{
+			Product: itemDescription(models.OrderItem{ProductName: alert.ProductName, Talla: alert.Talla, Color: alert.Color}),
+			Status:  status,
+			Stock:   alert.Stock,
+		}
+	}
+
+	subject := fmt.Sprintf("Alertas de stock: %d productos", len(alerts))
+	if outOfStock > 0 {
+		subject = fmt.Sprintf("%s (agotados: %d)", subject, outOfStock)
+	}
+	if len(alerts) == 1 {
+		subject = fmt.Sprintf("Stock bajo: %s", lines[0].Product)
+		if outOfStock == 1 {
+			subject = fmt.Sprintf("Agotado: %s", lines[0].Product)
+		}
+	}
+
+	data := struct {
+		StoreName string
+		Lines     []stockAlertLine
+	}{storeName, lines}
+	var text, html bytes.Buffer
+	if err := stockAlertText.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("error al armar el email: %w", err)
+	}
+	if err := stockAlertHTML.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("error al armar el email: %w", err)
+	}
+	return &models.EmailMessage{
+		FromName: storeName,
+		Subject:  subject,
+		Text:     text.String(),
+		HTML:     html.String(),
+	}, nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StockShortage describe un item del pedido sin stock suficiente
+type StockShortage struct {
+	ProductID   uint   `json:"product_id"`
+	VariantID   *uint  `json:"variant_id,omitempty"`
+	ProductName string `json:"product_name"`
+	Talla       string `json:"talla,omitempty"`
+	Color       string `json:"color,omitempty"`
+	Available   int    `json:"available"`
+	Requested   int    `json:"requested"`
+}
+
+// InsufficientStockError se devuelve cuando uno o más items no tienen stock suficiente
+type InsufficientStockError struct {
+	Items []StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		name := item.ProductName
+		if item.Talla != "" {
+			name += " talla " + strings.TrimSpace(item.Talla+" "+item.Color)
+		}
+		parts[i] = fmt.Sprintf("%s (Stock: %d, Solicitado: %d)", name, item.Available, item.Requested)
+	}
+	return "stock insuficiente para " + strings.Join(parts, "; ")
+}
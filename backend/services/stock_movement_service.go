@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// StockMovementService maneja las consultas del ledger de inventario
+type StockMovementService struct {
+	repo        *repositories.StockMovementRepository
+	productRepo *repositories.ProductRepository
+}
+
+// NewStockMovementService crea una nueva instancia del servicio
+func NewStockMovementService(repo *repositories.StockMovementRepository, productRepo *repositories.ProductRepository) *StockMovementService {
+	return &StockMovementService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+// GetProductMovements obtiene los movimientos de un producto con paginación
+func (s *StockMovementService) GetProductMovements(productID uint, page, limit int) ([]models.StockMovement, int, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error al verificar producto: %w", err)
+	}
+	if product == nil {
+		return nil, 0, fmt.Errorf("producto no encontrado")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	return s.repo.GetByProductID(productID, limit, (page-1)*limit)
+}
+
+// GetReport agrupa los movimientos del período por producto, variante y motivo.
+// from y to son fechas inclusive (se toma el día completo de to).
+func (s *StockMovementService) GetReport(from, to time.Time) ([]models.StockMovementSummary, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("la fecha 'to' debe ser posterior a 'from'")
+	}
+
+	return s.repo.GetReport(from, to.AddDate(0, 0, 1))
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// Errores de los enlaces de WhatsApp
+var (
+	ErrWhatsAppNotConfigured = errors.New("la tienda no tiene un número de WhatsApp válido")
+	ErrOrderWithoutPhone     = errors.New("el pedido no tiene un teléfono válido para WhatsApp")
+	ErrVariantNotAvailable   = errors.New("la talla o el color no existen para este producto")
+)
+
+// WhatsAppService arma los mensajes de WhatsApp a partir de las plantillas de SiteConfig,
+// para que la consulta por un producto y el aviso al cliente por su pedido digan lo mismo
+// desde cualquier pantalla
+type WhatsAppService struct {
+	productService *ProductService
+	orderService   *OrderService
+	configRepo     *repositories.ConfigRepository
+	storeURL       string // URL de la tienda, para los enlaces al producto y al seguimiento (puede estar vacía)
+}
+
+// NewWhatsAppService crea una nueva instancia del servicio
+func NewWhatsAppService(productService *ProductService, orderService *OrderService, configRepo *repositories.ConfigRepository, storeURL string) *WhatsAppService {
+	return &WhatsAppService{
+		productService: productService,
+		orderService:   orderService,
+		configRepo:     configRepo,
+		storeURL:       strings.TrimRight(storeURL, "/"),
+	}
+}
+
+// ProductLink arma la consulta de un cliente a la tienda por un producto. talla y color son
+// opcionales; si se indican, el precio es el de esa variante.
+func (s *WhatsAppService) ProductLink(productID uint, talla, color string) (*models.WhatsAppLink, error) {
+	product, err := s.productService.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.configRepo.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	phone := models.WhatsAppPhone(config.WhatsAppNumber)
+	if phone == "" {
+		return nil, ErrWhatsAppNotConfigured
+	}
+
+	talla = strings.TrimSpace(talla)
+	color = strings.ToLower(strings.TrimSpace(color))
+	price := product.Precio
+	if talla != "" || color != "" {
+		variant := findVariant(product.Variantes, talla, color)
+		if variant == nil {
+			return nil, ErrVariantNotAvailable
+		}
+		price = variant.EffectivePrice(product.Precio)
+	}
+
+	link := ""
+	if s.storeURL != "" {
+		link = fmt.Sprintf("%s/producto/%d", s.storeURL, product.ID)
+	}
+
+	message := models.RenderWhatsAppMessage(config.WhatsAppProductMessage, map[string]string{
+		"tienda":   config.StoreName,
+		"producto": product.Nombre,
+		"talla":    talla,
+		"color":    color,
+		"precio":   FormatMoney(price),
+		"enlace":   link,
+	})
+	result := models.NewWhatsAppLink(phone, message)
+	return &result, nil
+}
+
+// OrderLink arma el mensaje de la tienda al cliente por su pedido: número, total, saldo,
+// estado y enlace al seguimiento público
+func (s *WhatsAppService) OrderLink(orderID uint) (*models.WhatsAppLink, error) {
+	order, err := s.orderService.GetOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("orden no encontrada")
+	}
+
+	phone := models.WhatsAppPhone(order.CustomerPhone)
+	if phone == "" {
+		return nil, ErrOrderWithoutPhone
+	}
+
+	config, err := s.configRepo.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	balance := ""
+	if order.PaymentSummary != nil && order.BalanceDue > 0 {
+		balance = FormatMoney(order.BalanceDue)
+	}
+
+	message := models.RenderWhatsAppMessage(config.WhatsAppOrderMessage, map[string]string{
+		"tienda":      config.StoreName,
+		"cliente":     firstName(order.CustomerName),
+		"pedido":      order.DisplayNumber(),
+		"total":       FormatMoney(order.AmountDue()),
+		"saldo":       balance,
+		"estado":      string(order.Status),
+		"seguimiento": orderTrackingURL(s.storeURL, order),
+	})
+	result := models.NewWhatsAppLink(phone, message)
+	return &result, nil
+}
+
+// findVariant busca la variante por talla y color (los vacíos no filtran)
+func findVariant(variants []models.ProductVariant, talla, color string) *models.ProductVariant {
+	for i := range variants {
+		v := &variants[i]
+		if talla != "" && !strings.EqualFold(v.Talla, talla) {
+			continue
+		}
+		if color != "" && v.Color != color {
+			continue
+		}
+		return v
+	}
+	return nil
+}
+
+// firstName devuelve el primer nombre del cliente, para un saludo más cercano
+func firstName(name string) string {
+	if fields := strings.Fields(name); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
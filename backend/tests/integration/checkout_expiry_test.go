@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+	"tiendaedgar/backend/services"
+)
+
+// TestExpireReservations verifica que los pedidos del checkout sin pago se cancelen al
+// vencer la reserva, devolviendo el stock, y que los pedidos cargados por un admin no
+func TestExpireReservations(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	orders := newTestOrderService()
+	checkout := services.NewCheckoutService(repositories.NewProductRepository(database.DB), orders)
+	product := createTestProduct(t, 10)
+
+	public := &models.Order{
+		CustomerName:  "Ana Pérez",
+		CustomerPhone: "1155551234",
+		PaymentMethod: models.PaymentMethodCash,
+		Items:         []models.OrderItem{{ProductID: product.ID, Quantity: 3}},
+	}
+	if err := orders.CreateOrder(public, models.SystemActor("checkout")); err != nil {
+		t.Fatalf("CreateOrder(checkout) error = %v", err)
+	}
+	manual := createTestOrder(t, orders, product.ID, 2)
+
+	// Reserva vigente: no se cancela nada
+	if expired, err := checkout.ExpireReservations(time.Hour); err != nil || expired != 0 {
+		t.Fatalf("ExpireReservations() = %d, %v, want 0", expired, err)
+	}
+
+	// Los dos pedidos se crearon hace tres horas
+	if _, err := database.DB.Exec(`UPDATE order_status_history SET created_at = ?`, time.Now().Add(-3*time.Hour)); err != nil {
+		t.Fatalf("UPDATE order_status_history error = %v", err)
+	}
+	if expired, err := checkout.ExpireReservations(time.Hour); err != nil || expired != 1 {
+		t.Fatalf("ExpireReservations() = %d, %v, want 1", expired, err)
+	}
+
+	for _, tt := range []struct {
+		order *models.Order
+		want  models.OrderStatus
+	}{
+		{public, models.OrderStatusCancelled},
+		{manual, models.OrderStatusPending},
+	} {
+		got, err := orders.GetOrderByID(tt.order.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetOrderByID(%d) = %v, %v", tt.order.ID, got, err)
+		}
+		if got.Status != tt.want {
+			t.Errorf("pedido %d: Status = %s, want %s", tt.order.ID, got.Status, tt.want)
+		}
+	}
+	if stock := productStock(t, product.ID); stock != 8 {
+		t.Errorf("stock = %d, want 8 (solo el pedido del admin sigue reservando)", stock)
+	}
+
+	var movements int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM stock_movements WHERE order_id = ? AND reason = ? AND delta = 3`,
+		public.ID, models.StockMovementCancel).Scan(&movements); err != nil {
+		t.Fatalf("COUNT stock_movements error = %v", err)
+	}
+	if movements != 1 {
+		t.Errorf("movimientos de cancelación = %d, want 1", movements)
+	}
+
+	// Una segunda pasada no vuelve a cancelar el pedido
+	if expired, err := checkout.ExpireReservations(time.Hour); err != nil || expired != 0 {
+		t.Errorf("ExpireReservations() = %d, %v, want 0", expired, err)
+	}
+}
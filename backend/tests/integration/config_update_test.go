@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/handlers"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestUpdateConfig_StatusCodes verifica que solo los datos inválidos respondan 400 y que
+// una falla al guardar responda 500
+func TestUpdateConfig_StatusCodes(t *testing.T) {
+	setupTestDB()
+	defer os.Remove("test_catalog.db")
+
+	r := gin.New()
+	r.PUT("/api/config", handlers.NewConfigHandler().UpdateConfig)
+	send := func(body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(`{"order_number_prefix": "A-B"}`); code != http.StatusBadRequest {
+		t.Errorf("prefijo inválido: status = %d, want 400", code)
+	}
+	if code := send(`{"order_number_prefix": "TE"}`); code != http.StatusOK {
+		t.Errorf("configuración válida: status = %d, want 200", code)
+	}
+
+	database.CloseDB()
+	if code := send(`{"order_number_prefix": "TE"}`); code != http.StatusInternalServerError {
+		t.Errorf("base cerrada: status = %d, want 500", code)
+	}
+}
+
+// TestUpdateConfig_KeepsOmittedFields verifica que un PUT parcial no borre los campos que
+// no envía (numeración, umbral de stock, plantillas, medios de pago)
+func TestUpdateConfig_KeepsOmittedFields(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	r := gin.New()
+	r.PUT("/api/config", handlers.NewConfigHandler().UpdateConfig)
+	send := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("PUT %s: status = %d, body = %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	send(`{
+		"store_name": "Tienda",
+		"order_number_prefix": "TE",
+		"order_number_digits": 4,
+		"low_stock_threshold": 7,
+		"enable_stock_alerts": true,
+		"whatsapp_order_message": "Hola {cliente}, su pedido {pedido} está {estado}",
+		"payment_methods": [{"method": "efectivo", "label": "Efectivo", "adjustment_percent": -10}]
+	}`)
+	send(`{"store_name": "Tienda Nueva"}`)
+
+	config, err := services.NewConfigService().GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() error = %v", err)
+	}
+	if config.StoreName != "Tienda Nueva" {
+		t.Errorf("StoreName = %q, want Tienda Nueva", config.StoreName)
+	}
+	if config.OrderNumberPrefix != "TE" || config.OrderNumberDigits != 4 {
+		t.Errorf("numeración = %s/%d, want TE/4", config.OrderNumberPrefix, config.OrderNumberDigits)
+	}
+	if config.LowStockThreshold != 7 || !config.EnableStockAlerts {
+		t.Errorf("alertas de stock = %d/%v, want 7/true", config.LowStockThreshold, config.EnableStockAlerts)
+	}
+	if config.WhatsAppOrderMessage != "Hola {cliente}, su pedido {pedido} está {estado}" {
+		t.Errorf("WhatsAppOrderMessage = %q", config.WhatsAppOrderMessage)
+	}
+	for _, rule := range config.PaymentMethods {
+		if rule.Method == models.PaymentMethodCash && rule.AdjustmentPercent != -10 {
+			t.Errorf("descuento en efectivo = %v, want -10", rule.AdjustmentPercent)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// TestCreateOrder_CheckoutDoesNotOverwriteCustomer verifica que un pedido del checkout
+// público no reemplace el nombre ni la dirección del cliente; un pedido de un admin sí
+func TestCreateOrder_CheckoutDoesNotOverwriteCustomer(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	service := newTestOrderService()
+	product := createTestProduct(t, 10)
+	createTestOrder(t, service, product.ID, 1)
+
+	customers := repositories.NewCustomerRepository(database.DB)
+	customer := func() *models.Customer {
+		t.Helper()
+		c, err := customers.FindByPhone("1155551234")
+		if err != nil || c == nil {
+			t.Fatalf("FindByPhone() = %v, %v", c, err)
+		}
+		return c
+	}
+
+	order := &models.Order{
+		CustomerName:    "Otra Persona",
+		CustomerPhone:   "1155551234",
+		CustomerAddress: "Calle Falsa 123",
+		PaymentMethod:   models.PaymentMethodCash,
+		Items:           []models.OrderItem{{ProductID: product.ID, Quantity: 1}},
+	}
+	if err := service.CreateOrder(order, models.SystemActor("checkout")); err != nil {
+		t.Fatalf("CreateOrder(checkout) error = %v", err)
+	}
+	got := customer()
+	if got.Name != "Ana Pérez" {
+		t.Errorf("Name = %q, want Ana Pérez", got.Name)
+	}
+	if got.Address != "Calle Falsa 123" {
+		t.Errorf("Address = %q, want la dirección que faltaba", got.Address)
+	}
+
+	adminID := uint(1)
+	order = &models.Order{
+		CustomerName:  "Ana María Pérez",
+		CustomerPhone: "1155551234",
+		PaymentMethod: models.PaymentMethodCash,
+		Items:         []models.OrderItem{{ProductID: product.ID, Quantity: 1}},
+	}
+	if err := service.CreateOrder(order, models.Actor{UserID: &adminID, Username: "admin"}); err != nil {
+		t.Fatalf("CreateOrder(admin) error = %v", err)
+	}
+	if got := customer(); got.Name != "Ana María Pérez" {
+		t.Errorf("Name = %q, want el nombre cargado por el admin", got.Name)
+	}
+}
@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/middleware"
+	"tiendaedgar/backend/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestIdempotency_BodyTooLarge verifica que el middleware no lea cuerpos sin límite
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	calls := 0
+	r := gin.New()
+	r.POST("/api/orders", middleware.Idempotency(repositories.NewIdempotencyRepository(database.DB), time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	send := func(key string, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader(body))
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("clave-grande", bytes.Repeat([]byte("a"), middleware.IdempotencyMaxBodyBytes+1)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("cuerpo grande: status = %d, want 413", code)
+	}
+	if calls != 0 {
+		t.Errorf("el handler se ejecutó %d veces con un cuerpo demasiado grande", calls)
+	}
+
+	if code := send("clave-normal", []byte(`{"items":[]}`)); code != http.StatusCreated {
+		t.Errorf("cuerpo normal: status = %d, want 201", code)
+	}
+}
@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// TestUpdateOrderItems_KeepsShippingAndItemIDs verifica que editar los productos de un
+// pedido conserve el costo de envío del checkout y el ID de las líneas que siguen
+func TestUpdateOrderItems_KeepsShippingAndItemIDs(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	service := newTestOrderService()
+	product := createTestProduct(t, 10)
+	other := createTestProduct(t, 10)
+	order := createTestOrder(t, service, product.ID, 1)
+
+	// Envío pactado en el checkout, distinto de lo que cotizarían las tarifas vigentes
+	if _, err := database.DB.Exec("UPDATE orders SET shipping_cost = 1500, total_amount = total_amount + 1500 WHERE id = ?", order.ID); err != nil {
+		t.Fatalf("error al fijar el envío: %v", err)
+	}
+	lineID := order.Items[0].ID
+
+	items := []models.OrderItem{
+		{ProductID: product.ID, Quantity: 3},
+		{ProductID: other.ID, Quantity: 1},
+	}
+	if _, err := service.UpdateOrderItems(order.ID, items, testAdmin, ""); err != nil {
+		t.Fatalf("UpdateOrderItems() error = %v", err)
+	}
+
+	updated, err := repositories.NewOrderRepository(database.DB).GetByID(order.ID)
+	if err != nil || updated == nil {
+		t.Fatalf("GetByID() = %v, %v", updated, err)
+	}
+	if updated.ShippingCost != 1500 {
+		t.Errorf("ShippingCost = %v, want 1500", updated.ShippingCost)
+	}
+	if updated.TotalAmount != 5500 {
+		t.Errorf("TotalAmount = %v, want 5500", updated.TotalAmount)
+	}
+	if len(updated.Items) != 2 {
+		t.Fatalf("len(Items) = %d, want 2", len(updated.Items))
+	}
+	for _, item := range updated.Items {
+		if item.ProductID == product.ID && item.ID != lineID {
+			t.Errorf("la línea editada cambió de ID: %d, want %d", item.ID, lineID)
+		}
+	}
+}
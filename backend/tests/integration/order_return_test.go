@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+	"tiendaedgar/backend/services"
+)
+
+// newTestReturnService crea el servicio de devoluciones sobre la base de test
+func newTestReturnService() *services.ReturnService {
+	return services.NewReturnService(
+		repositories.NewOrderRepository(database.DB),
+		repositories.NewOrderReturnRepository(database.DB),
+		repositories.NewUnitOfWork(database.DB),
+	)
+}
+
+// createDeliveredOrderWithReturn crea un pedido entregado de 2 unidades con una unidad
+// devuelta en buen estado
+func createDeliveredOrderWithReturn(t *testing.T, orders *services.OrderService, productID uint) *models.Order {
+	t.Helper()
+	order := createTestOrder(t, orders, productID, 2)
+	for _, status := range []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusProcessing, models.OrderStatusShipped, models.OrderStatusDelivered} {
+		if err := orders.UpdateOrderStatus(order.ID, status, testAdmin, ""); err != nil {
+			t.Fatalf("UpdateOrderStatus(%s) error = %v", status, err)
+		}
+	}
+
+	req := &models.OrderReturnRequest{
+		Resolution: models.ReturnResolutionStoreCredit,
+		Items:      []models.ReturnItemRequest{{OrderItemID: order.Items[0].ID, Quantity: 1, Condition: models.ReturnConditionResellable}},
+	}
+	if _, err := newTestReturnService().CreateReturn(order.ID, req, testAdmin); err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+	return order
+}
+
+// TestCreateReturn_RequiresShippedOrder verifica que no se acepten devoluciones de pedidos sin despachar
+func TestCreateReturn_RequiresShippedOrder(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	orders := newTestOrderService()
+	product := createTestProduct(t, 10)
+	order := createTestOrder(t, orders, product.ID, 2)
+
+	req := &models.OrderReturnRequest{
+		Resolution: models.ReturnResolutionStoreCredit,
+		Items:      []models.ReturnItemRequest{{OrderItemID: order.Items[0].ID, Quantity: 1, Condition: models.ReturnConditionResellable}},
+	}
+	if _, err := newTestReturnService().CreateReturn(order.ID, req, testAdmin); !errors.Is(err, services.ErrOrderNotReturnable) {
+		t.Errorf("CreateReturn() error = %v, want ErrOrderNotReturnable", err)
+	}
+	if got := productStock(t, product.ID); got != 8 {
+		t.Errorf("stock = %d, want 8", got)
+	}
+}
+
+// TestCancelOrder_AfterReturn verifica que un pedido con devoluciones no se cancele
+// (el stock devuelto no debe reingresar dos veces)
+func TestCancelOrder_AfterReturn(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	orders := newTestOrderService()
+	product := createTestProduct(t, 10)
+	order := createDeliveredOrderWithReturn(t, orders, product.ID)
+
+	if got := productStock(t, product.ID); got != 9 {
+		t.Fatalf("stock después de la devolución = %d, want 9", got)
+	}
+	if err := orders.UpdateOrderStatus(order.ID, models.OrderStatusCancelled, testAdmin, ""); err == nil {
+		t.Fatal("UpdateOrderStatus(Cancelado) error = nil, want error")
+	}
+	if got := productStock(t, product.ID); got != 9 {
+		t.Errorf("stock = %d, want 9", got)
+	}
+}
+
+// TestDeleteOrder_AfterReturn verifica que un pedido con devoluciones no se elimine
+func TestDeleteOrder_AfterReturn(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	orders := newTestOrderService()
+	product := createTestProduct(t, 10)
+	order := createDeliveredOrderWithReturn(t, orders, product.ID)
+
+	if err := orders.DeleteOrder(order.ID, testAdmin); !errors.Is(err, services.ErrOrderHasReturns) {
+		t.Fatalf("DeleteOrder() error = %v, want ErrOrderHasReturns", err)
+	}
+	if got := productStock(t, product.ID); got != 9 {
+		t.Errorf("stock = %d, want 9", got)
+	}
+	if got, err := orders.GetOrderByID(order.ID); err != nil || got == nil {
+		t.Errorf("GetOrderByID() = %v, %v, want el pedido", got, err)
+	}
+}
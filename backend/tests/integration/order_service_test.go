@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+	"tiendaedgar/backend/services"
+)
+
+var testAdmin = models.Actor{Username: "admin"}
+
+// newTestOrderService crea el servicio de pedidos sobre la base de test
+func newTestOrderService() *services.OrderService {
+	configRepo := repositories.NewConfigRepository(database.DB)
+	return services.NewOrderService(
+		repositories.NewOrderRepository(database.DB),
+		repositories.NewOrderStatusHistoryRepository(database.DB),
+		repositories.NewOrderPaymentRepository(database.DB),
+		repositories.NewOrderRevisionRepository(database.DB),
+		repositories.NewUnitOfWork(database.DB),
+		services.NewOrderPricer(configRepo),
+		configRepo,
+	)
+}
+
+// createTestProduct crea un producto sin variantes con el stock indicado
+func createTestProduct(t *testing.T, stock int) *models.Product {
+	t.Helper()
+	product := &models.Product{Nombre: "Remera Básica", Categoria: "remeras", Precio: 1000, Stock: stock, Activo: true}
+	movement := models.NewStockMovement(models.StockMovementImport, testAdmin, "")
+	if err := repositories.NewProductRepository(database.DB).Create(product, movement); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return product
+}
+
+// productStock devuelve el stock actual del producto
+func productStock(t *testing.T, id uint) int {
+	t.Helper()
+	product, err := repositories.NewProductRepository(database.DB).GetByID(id)
+	if err != nil || product == nil {
+		t.Fatalf("GetByID(%d) = %v, %v", id, product, err)
+	}
+	return product.Stock
+}
+
+// createTestOrder crea un pedido manual de quantity unidades del producto
+func createTestOrder(t *testing.T, service *services.OrderService, productID uint, quantity int) *models.Order {
+	t.Helper()
+	order := &models.Order{
+		CustomerName:  "Ana Pérez",
+		CustomerPhone: "1155551234",
+		PaymentMethod: models.PaymentMethodCash,
+		Items:         []models.OrderItem{{ProductID: productID, Quantity: quantity}},
+	}
+	if err := service.CreateOrder(order, testAdmin); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	return order
+}
+
+// TestCreateOrder_InitialStatus verifica que un pedido nuevo solo pueda crearse Pendiente
+func TestCreateOrder_InitialStatus(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	service := newTestOrderService()
+	product := createTestProduct(t, 10)
+
+	for _, status := range []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusShipped, models.OrderStatusDelivered, models.OrderStatusCancelled} {
+		order := &models.Order{
+			CustomerName:  "Ana Pérez",
+			PaymentMethod: models.PaymentMethodCash,
+			Status:        status,
+			Items:         []models.OrderItem{{ProductID: product.ID, Quantity: 1}},
+		}
+		if err := service.CreateOrder(order, testAdmin); !errors.Is(err, services.ErrInvalidOrderStatus) {
+			t.Errorf("CreateOrder(%s) error = %v, want ErrInvalidOrderStatus", status, err)
+		}
+	}
+	if stock := productStock(t, product.ID); stock != 10 {
+		t.Errorf("stock = %d, want 10 (no debería descontarse)", stock)
+	}
+
+	order := createTestOrder(t, service, product.ID, 2)
+	if order.Status != models.OrderStatusPending {
+		t.Errorf("Status = %s, want %s", order.Status, models.OrderStatusPending)
+	}
+}
+
+// TestCancelOrder_RestoreFailureRollsBack verifica que si el stock no puede devolverse el
+// pedido no se cancele ni se elimine (la transacción completa se revierte)
+func TestCancelOrder_RestoreFailureRollsBack(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	service := newTestOrderService()
+	product := createTestProduct(t, 10)
+	order := createTestOrder(t, service, product.ID, 2)
+
+	// El producto pasa a tener variantes: la línea del pedido no indica a qué talla devolver
+	variant := &models.ProductVariant{ProductID: product.ID, Talla: "M", Stock: 5}
+	movement := models.NewStockMovement(models.StockMovementManualAdjust, testAdmin, "")
+	if err := repositories.NewProductVariantRepository(database.DB).Create(variant, movement); err != nil {
+		t.Fatalf("Create(variante) error = %v", err)
+	}
+
+	if err := service.UpdateOrderStatus(order.ID, models.OrderStatusCancelled, testAdmin, ""); !errors.Is(err, repositories.ErrStockManagedByVariants) {
+		t.Errorf("UpdateOrderStatus(Cancelado) error = %v, want ErrStockManagedByVariants", err)
+	}
+	if err := service.DeleteOrder(order.ID, testAdmin); !errors.Is(err, repositories.ErrStockManagedByVariants) {
+		t.Errorf("DeleteOrder() error = %v, want ErrStockManagedByVariants", err)
+	}
+
+	got, err := service.GetOrderByID(order.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetOrderByID() = %v, %v, want el pedido", got, err)
+	}
+	if got.Status != models.OrderStatusPending {
+		t.Errorf("Status = %s, want %s", got.Status, models.OrderStatusPending)
+	}
+	if stock := productStock(t, product.ID); stock != 5 {
+		t.Errorf("stock = %d, want 5", stock)
+	}
+}
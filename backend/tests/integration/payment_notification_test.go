@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/handlers"
+	"tiendaedgar/backend/mercadopago"
+	"tiendaedgar/backend/mercadopago/mercadopagotest"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+	"tiendaedgar/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestHandleNotification verifica el webhook de Mercado Pago contra el servidor falso:
+// el pago aprobado se registra una sola vez en el ledger y el pedido pasa a Pagado
+func TestHandleNotification(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	fake := mercadopagotest.NewServer()
+	defer fake.Close()
+
+	orderService := newTestOrderService()
+	client := mercadopago.NewClient("TEST-token", "", fake.URL)
+	service := services.NewPaymentService(repositories.NewOrderRepository(database.DB), orderService, client, "", "")
+
+	product := createTestProduct(t, 10)
+	order := createTestOrder(t, orderService, product.ID, 2)
+
+	// Un pago rechazado no toca el pedido
+	rejected := fake.AddPayment(order.Reference, models.GatewayPaymentRejected, order.TotalAmount)
+	result, err := service.HandleNotification(models.PaymentNotification{Type: "payment", DataID: rejected})
+	if err != nil || result.Processed {
+		t.Fatalf("pago rechazado: result = %+v, err = %v", result, err)
+	}
+
+	approved := fake.AddPayment(order.Reference, models.GatewayPaymentApproved, order.TotalAmount)
+	notification := models.PaymentNotification{Type: "payment", DataID: approved}
+	result, err = service.HandleNotification(notification)
+	if err != nil || !result.Processed || result.OrderID != order.ID {
+		t.Fatalf("pago aprobado: result = %+v, err = %v", result, err)
+	}
+
+	// El aviso repetido no registra el pago otra vez
+	result, err = service.HandleNotification(notification)
+	if err != nil || result.Processed {
+		t.Fatalf("aviso repetido: result = %+v, err = %v", result, err)
+	}
+
+	updated, err := orderService.GetOrderByID(order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderByID() error = %v", err)
+	}
+	if updated.Status != models.OrderStatusPaid {
+		t.Errorf("Status = %s, want %s", updated.Status, models.OrderStatusPaid)
+	}
+	if len(updated.Payments) != 1 || updated.Payments[0].Reference != "MP-"+approved || updated.BalanceDue != 0 {
+		t.Errorf("pagos = %+v, saldo = %v", updated.Payments, updated.BalanceDue)
+	}
+
+	// Un pago de otra referencia no se asocia a ningún pedido
+	other := fake.AddPayment("NO-EXISTE", models.GatewayPaymentApproved, 100)
+	if result, err := service.HandleNotification(models.PaymentNotification{Type: "payment", DataID: other}); err != nil || result.Processed {
+		t.Errorf("pago sin pedido: result = %+v, err = %v", result, err)
+	}
+
+	// Un ID no numérico no llega a consultarse y se ignora
+	if result, err := service.HandleNotification(models.PaymentNotification{Type: "payment", DataID: "../checkout/preferences"}); err != nil || result.Processed {
+		t.Errorf("ID inválido: result = %+v, err = %v", result, err)
+	}
+}
+
+// TestMercadoPagoWebhook_StatusCodes verifica que el webhook responda 200 a los avisos que
+// no se pueden procesar (así Mercado Pago no los reintenta) y 500 si falla la pasarela
+func TestMercadoPagoWebhook_StatusCodes(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	fake := mercadopagotest.NewServer()
+	client := mercadopago.NewClient("TEST-token", "", fake.URL)
+	service := services.NewPaymentService(repositories.NewOrderRepository(database.DB), newTestOrderService(), client, "", "")
+
+	r := gin.New()
+	r.POST("/api/webhooks/mercadopago", handlers.NewPaymentHandler(service).MercadoPagoWebhook)
+	send := func(query string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/webhooks/mercadopago?"+query, nil))
+		return w.Code
+	}
+
+	if code := send("type=payment&data.id=abc"); code != http.StatusOK {
+		t.Errorf("ID inválido: status = %d, want 200", code)
+	}
+	if code := send("topic=merchant_order&id=123"); code != http.StatusOK {
+		t.Errorf("otro tópico: status = %d, want 200", code)
+	}
+
+	fake.Close()
+	if code := send("type=payment&data.id=123"); code != http.StatusInternalServerError {
+		t.Errorf("pasarela caída: status = %d, want 500", code)
+	}
+}
@@ -202,7 +202,7 @@ func TestGetAllProducts_Pagination(t *testing.T) {
 
 	router := setupRouter()
 
-	repo := repositories.NewProductRepository()
+	repo := repositories.NewProductRepository(database.DB)
 
 	// Crear 10 productos
 	for i := 1; i <= 10; i++ {
@@ -211,7 +211,7 @@ func TestGetAllProducts_Pagination(t *testing.T) {
 			Categoria: "test",
 			Precio:    1000.00,
 		}
-		repo.Create(&product)
+		repo.Create(&product, models.NewStockMovement(models.StockMovementImport, models.Actor{}, ""))
 	}
 
 	// Obtener primera página simulando la request del usuario
@@ -0,0 +1,40 @@
+package integration
+
+import (
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+	"tiendaedgar/backend/services"
+)
+
+// newTestProductService crea el servicio de productos sobre la base de test
+func newTestProductService() *services.ProductService {
+	return services.NewProductService(
+		repositories.NewProductRepository(database.DB),
+		repositories.NewProductVariantRepository(database.DB),
+		repositories.NewUnitOfWork(database.DB),
+	)
+}
+
+// TestCreateProduct_DuplicateVariants verifica que un alta con tallas/colores repetidos
+// se rechace sin dejar un producto a medio crear
+func TestCreateProduct_DuplicateVariants(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	product := &models.Product{Nombre: "Zapa", Categoria: "zapatillas", Precio: 1000, Activo: true,
+		Variantes: []models.ProductVariant{{Talla: "42", Color: "Negro", Stock: 3}, {Talla: "42", Color: "negro ", Stock: 2}}}
+	if err := newTestProductService().CreateProduct(product, testAdmin); err == nil {
+		t.Fatal("CreateProduct() error = nil, want variante repetida")
+	}
+
+	var products, movements int
+	if err := database.DB.QueryRow("SELECT (SELECT COUNT(*) FROM products), (SELECT COUNT(*) FROM stock_movements)").Scan(&products, &movements); err != nil {
+		t.Fatalf("error al contar: %v", err)
+	}
+	if products != 0 || movements != 0 {
+		t.Errorf("quedaron %d productos y %d movimientos, want 0", products, movements)
+	}
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// TestDirectStockWrite_WithVariants verifica que el stock de un producto con variantes no
+// pueda modificarse directamente (es la suma de sus variantes)
+func TestDirectStockWrite_WithVariants(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	productRepo := repositories.NewProductRepository(database.DB)
+	variantRepo := repositories.NewProductVariantRepository(database.DB)
+	movement := models.NewStockMovement(models.StockMovementManualAdjust, testAdmin, "")
+
+	product := createTestProduct(t, 0)
+	variant := &models.ProductVariant{ProductID: product.ID, Talla: "42", Color: "negro", Stock: 4}
+	if err := variantRepo.Create(variant, movement); err != nil {
+		t.Fatalf("Create(variante) error = %v", err)
+	}
+
+	if err := productRepo.UpdateStock(product.ID, 50, movement); !errors.Is(err, repositories.ErrStockManagedByVariants) {
+		t.Errorf("UpdateStock() error = %v, want ErrStockManagedByVariants", err)
+	}
+	for _, field := range []string{"stock", "stock_by_size"} {
+		err := productRepo.PartialUpdate(product.ID, map[string]interface{}{field: 50}, movement)
+		if !errors.Is(err, repositories.ErrStockManagedByVariants) {
+			t.Errorf("PartialUpdate(%s) error = %v, want ErrStockManagedByVariants", field, err)
+		}
+	}
+	if err := productRepo.IncreaseStock(product.ID, 2, movement); !errors.Is(err, repositories.ErrStockManagedByVariants) {
+		t.Errorf("IncreaseStock() error = %v, want ErrStockManagedByVariants", err)
+	}
+	if err := productRepo.ReduceStock(product.ID, 2, movement); !errors.Is(err, repositories.ErrStockManagedByVariants) {
+		t.Errorf("ReduceStock() error = %v, want ErrStockManagedByVariants", err)
+	}
+	if stock := productStock(t, product.ID); stock != 4 {
+		t.Errorf("stock = %d, want 4 (suma de las variantes)", stock)
+	}
+
+	// Sin variantes el stock se sigue modificando directamente
+	plain := createTestProduct(t, 3)
+	if err := productRepo.UpdateStock(plain.ID, 8, movement); err != nil {
+		t.Fatalf("UpdateStock() error = %v", err)
+	}
+	if err := productRepo.PartialUpdate(plain.ID, map[string]interface{}{"stock": 6}, movement); err != nil {
+		t.Fatalf("PartialUpdate() error = %v", err)
+	}
+	if stock := productStock(t, plain.ID); stock != 6 {
+		t.Errorf("stock = %d, want 6", stock)
+	}
+}
+
+// TestUpdateProduct_IgnoresStockWithVariants verifica que un PUT completo con un
+// stock_by_size desactualizado no pise ni elimine las variantes
+func TestUpdateProduct_IgnoresStockWithVariants(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	service := newTestProductService()
+	product := &models.Product{Nombre: "Zapa", Categoria: "zapatillas", Precio: 1000, Activo: true,
+		StockBySize: map[string]int{"42": 4, "43": 6}}
+	if err := service.CreateProduct(product, testAdmin); err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+
+	update := *product
+	update.Precio = 1200
+	update.Stock = 50
+	update.StockBySize = map[string]int{"42": 50}
+	update.Variantes = nil
+	if err := service.UpdateProduct(&update, testAdmin); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+
+	if update.Stock != 10 || update.StockBySize["42"] != 4 || update.StockBySize["43"] != 6 {
+		t.Errorf("stock = %d %v, want 10 map[42:4 43:6]", update.Stock, update.StockBySize)
+	}
+	if update.Precio != 1200 {
+		t.Errorf("Precio = %v, want 1200", update.Precio)
+	}
+}
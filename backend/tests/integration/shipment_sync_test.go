@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"testing"
+
+	"tiendaedgar/backend/carriers"
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+	"tiendaedgar/backend/services"
+)
+
+// TestSyncDeliveries verifica que la entrega informada por el transportista marque el
+// pedido como Entregado y quede en el historial
+func TestSyncDeliveries(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	orders := newTestOrderService()
+	product := createTestProduct(t, 10)
+	order := createTestOrder(t, orders, product.ID, 1)
+	for _, status := range []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusProcessing} {
+		if err := orders.UpdateOrderStatus(order.ID, status, testAdmin, ""); err != nil {
+			t.Fatalf("UpdateOrderStatus(%s) error = %v", status, err)
+		}
+	}
+
+	fake := carriers.NewFake()
+	shipments := services.NewShipmentService(
+		repositories.NewOrderRepository(database.DB),
+		repositories.NewShipmentRepository(database.DB),
+		repositories.NewUnitOfWork(database.DB),
+		t.TempDir(),
+		map[string]services.CarrierTracker{"oca": fake},
+	)
+	req := &models.ShipmentRequest{Carrier: "oca", TrackingNumber: "OCA-123"}
+	if _, err := shipments.CreateShipment(order.ID, req, testAdmin); err != nil {
+		t.Fatalf("CreateShipment() error = %v", err)
+	}
+
+	// En tránsito: el pedido sigue Enviado
+	fake.SetStatus("OCA-123", models.CarrierStatus{Status: models.ShipmentStatusInTransit, Detail: "En sucursal de destino"})
+	if delivered, err := shipments.SyncDeliveries(); err != nil || delivered != 0 {
+		t.Fatalf("SyncDeliveries() = %d, %v, want 0", delivered, err)
+	}
+
+	fake.SetStatus("OCA-123", models.CarrierStatus{Status: models.ShipmentStatusDelivered})
+	if delivered, err := shipments.SyncDeliveries(); err != nil || delivered != 1 {
+		t.Fatalf("SyncDeliveries() = %d, %v, want 1", delivered, err)
+	}
+
+	got, err := orders.GetOrderByID(order.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetOrderByID() = %v, %v", got, err)
+	}
+	if got.Status != models.OrderStatusDelivered {
+		t.Errorf("Status = %s, want %s", got.Status, models.OrderStatusDelivered)
+	}
+
+	history, err := orders.GetOrderHistory(order.ID)
+	if err != nil || len(history) == 0 {
+		t.Fatalf("GetOrderHistory() = %v, %v", history, err)
+	}
+	last := history[len(history)-1]
+	if last.ToStatus != models.OrderStatusDelivered || last.ChangedBy != "transportista:oca" {
+		t.Errorf("último cambio = %s por %q, want Entregado por transportista:oca", last.ToStatus, last.ChangedBy)
+	}
+
+	// Un envío entregado no se vuelve a consultar
+	queries := fake.Queries()
+	if delivered, err := shipments.SyncDeliveries(); err != nil || delivered != 0 {
+		t.Errorf("SyncDeliveries() = %d, %v, want 0", delivered, err)
+	}
+	if fake.Queries() != queries {
+		t.Errorf("consultas = %d, want %d", fake.Queries(), queries)
+	}
+}
@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"testing"
+
+	"tiendaedgar/backend/database"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/repositories"
+)
+
+// TestStockAlerts_OnCreate verifica que los productos y variantes creados agotados o con
+// poco stock generen su alerta
+func TestStockAlerts_OnCreate(t *testing.T) {
+	setupTestDB()
+	defer teardownTestDB()
+
+	products := newTestProductService()
+	low := &models.Product{Nombre: "Gorra", Categoria: "accesorios", Precio: 500, Stock: 2, Activo: true}
+	if err := products.CreateProduct(low, testAdmin); err != nil {
+		t.Fatalf("CreateProduct(Gorra) error = %v", err)
+	}
+	stocked := &models.Product{Nombre: "Remera", Categoria: "remeras", Precio: 1000, Stock: 20, Activo: true}
+	if err := products.CreateProduct(stocked, testAdmin); err != nil {
+		t.Fatalf("CreateProduct(Remera) error = %v", err)
+	}
+	withVariants := &models.Product{Nombre: "Zapa", Categoria: "zapatillas", Precio: 1000, Activo: true,
+		Variantes: []models.ProductVariant{{Talla: "42", Stock: 0}, {Talla: "43", Stock: 10}}}
+	if err := products.CreateProduct(withVariants, testAdmin); err != nil {
+		t.Fatalf("CreateProduct(Zapa) error = %v", err)
+	}
+
+	alerts, err := repositories.NewStockAlertRepository(database.DB).GetAll(false, 10)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+
+	got := map[string]models.StockAlertKind{}
+	for _, alert := range alerts {
+		got[alert.ProductName+" "+alert.Talla] = alert.Kind
+	}
+	want := map[string]models.StockAlertKind{
+		"Gorra ":  models.StockAlertLow,
+		"Zapa 42": models.StockAlertOutOfStock,
+	}
+	if len(got) != len(want) || len(alerts) != len(want) {
+		t.Fatalf("alertas = %v, want %v", got, want)
+	}
+	for key, kind := range want {
+		if got[key] != kind {
+			t.Errorf("alerta de %q = %q, want %q", key, got[key], kind)
+		}
+	}
+}
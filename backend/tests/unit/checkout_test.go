@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestCheckoutRequestValidate verifica la validación del carrito público
+func TestCheckoutRequestValidate(t *testing.T) {
+	item := models.CheckoutItem{ProductID: 1, Talla: "42", Quantity: 1}
+	tooMany := make([]models.CheckoutItem, models.CheckoutMaxItems+1)
+	for i := range tooMany {
+		tooMany[i] = models.CheckoutItem{ProductID: uint(i + 1), Quantity: 1}
+	}
+
+	tests := []struct {
+		name    string
+		req     models.CheckoutRequest
+		wantErr bool
+	}{
+		{"carrito válido", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "+54 11 5555-1234", Items: []models.CheckoutItem{item}}, false},
+		{"con email", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234", CustomerEmail: "ana@mail.com", Items: []models.CheckoutItem{item}}, false},
+		{"sin nombre", models.CheckoutRequest{CustomerPhone: "1155551234", Items: []models.CheckoutItem{item}}, true},
+		{"sin teléfono", models.CheckoutRequest{CustomerName: "Ana", Items: []models.CheckoutItem{item}}, true},
+		{"teléfono inválido", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "abc", Items: []models.CheckoutItem{item}}, true},
+		{"email inválido", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234", CustomerEmail: "ana@", Items: []models.CheckoutItem{item}}, true},
+		{"carrito vacío", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234"}, true},
+		{"cantidad excesiva", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234", Items: []models.CheckoutItem{{ProductID: 1, Quantity: 11}}}, true},
+		{"cantidad máxima", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234", Items: []models.CheckoutItem{{ProductID: 1, Quantity: models.CheckoutMaxQuantity}}}, false},
+		{"cantidad sobre el máximo", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234", Items: []models.CheckoutItem{{ProductID: 1, Quantity: models.CheckoutMaxQuantity + 1}}}, true},
+		{"demasiados productos", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234", Items: tooMany}, true},
+		{"sin producto", models.CheckoutRequest{CustomerName: "Ana", CustomerPhone: "1155551234", Items: []models.CheckoutItem{{Quantity: 1}}}, true},
+		{"nombre demasiado largo", models.CheckoutRequest{CustomerName: strings.Repeat("a", 201), CustomerPhone: "1155551234", Items: []models.CheckoutItem{item}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestNewOrderReference verifica el formato de la referencia pública
+func TestNewOrderReference(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		ref := models.NewOrderReference()
+		if len(ref) != 9 || ref[4] != '-' {
+			t.Fatalf("Formato inesperado: %q", ref)
+		}
+		if strings.ContainsAny(ref, "01ILO") {
+			t.Errorf("La referencia %q contiene caracteres ambiguos", ref)
+		}
+		seen[ref] = true
+	}
+	if len(seen) < 100 {
+		t.Errorf("Se esperaban referencias distintas, hubo %d repetidas", 100-len(seen))
+	}
+}
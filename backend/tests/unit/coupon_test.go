@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+	"time"
+)
+
+// TestCouponValidate verifica la validación de la definición de un cupón
+func TestCouponValidate(t *testing.T) {
+	start := time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)
+	end := start.Add(72 * time.Hour)
+
+	tests := []struct {
+		name    string
+		coupon  models.Coupon
+		wantErr bool
+	}{
+		{"porcentaje válido", models.Coupon{Code: "HOTSALE", Type: models.CouponTypePercentage, Value: 20}, false},
+		{"monto fijo con vigencia", models.Coupon{Code: "CYBER-5000", Type: models.CouponTypeFixed, Value: 5000, StartsAt: &start, EndsAt: &end}, false},
+		{"código corto", models.Coupon{Code: "AB", Type: models.CouponTypeFixed, Value: 10}, true},
+		{"código con espacios", models.Coupon{Code: "HOT SALE", Type: models.CouponTypeFixed, Value: 10}, true},
+		{"porcentaje mayor a 100", models.Coupon{Code: "MAL", Type: models.CouponTypePercentage, Value: 120}, true},
+		{"sin valor", models.Coupon{Code: "CERO", Type: models.CouponTypeFixed}, true},
+		{"tipo inválido", models.Coupon{Code: "RARO", Type: "2x1", Value: 10}, true},
+		{"fechas invertidas", models.Coupon{Code: "FECHAS", Type: models.CouponTypeFixed, Value: 10, StartsAt: &end, EndsAt: &start}, true},
+		{"límite negativo", models.Coupon{Code: "LIMITE", Type: models.CouponTypeFixed, Value: 10, UsageLimit: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.coupon.Normalize()
+			err := tt.coupon.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCouponCheckAvailable verifica vigencia, estado y límite de usos
+func TestCouponCheckAvailable(t *testing.T) {
+	now := time.Date(2024, 11, 4, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		coupon  models.Coupon
+		wantErr bool
+	}{
+		{"vigente", models.Coupon{Code: "CYBER", Active: true, StartsAt: &before, EndsAt: &after}, false},
+		{"sin fechas", models.Coupon{Code: "SIEMPRE", Active: true}, false},
+		{"inactivo", models.Coupon{Code: "OFF", Active: false}, true},
+		{"no empezó", models.Coupon{Code: "FUTURO", Active: true, StartsAt: &after}, true},
+		{"vencido", models.Coupon{Code: "VENCIDO", Active: true, EndsAt: &before}, true},
+		{"usos agotados", models.Coupon{Code: "AGOTADO", Active: true, UsageLimit: 100, UsageCount: 100}, true},
+		{"usos disponibles", models.Coupon{Code: "QUEDAN", Active: true, UsageLimit: 100, UsageCount: 99}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.coupon.CheckAvailable(now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckAvailable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestPriceOrderWithCoupon verifica el descuento sobre los productos alcanzados
+func TestPriceOrderWithCoupon(t *testing.T) {
+	catalog := map[uint]*models.Product{
+		1: {ID: 1, Nombre: "Zapa", Categoria: "zapatillas", Precio: 10000},
+		2: {ID: 2, Nombre: "Gorra", Categoria: "accesorios", Precio: 2000},
+	}
+	newOrder := func(method models.PaymentMethod) *models.Order {
+		return &models.Order{PaymentMethod: method, Items: []models.OrderItem{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, Quantity: 1},
+		}}
+	}
+
+	tests := []struct {
+		name         string
+		coupon       models.Coupon
+		method       models.PaymentMethod
+		wantDiscount float64
+		wantTotal    float64
+		wantErr      bool
+	}{
+		{"porcentaje sobre todo", models.Coupon{Code: "HOTSALE", Type: models.CouponTypePercentage, Value: 10}, "", 2200, 19800, false},
+		{"solo una categoría", models.Coupon{Code: "ZAPAS", Type: models.CouponTypePercentage, Value: 10, Categories: []string{"Zapatillas"}}, "", 2000, 20000, false},
+		{"solo un producto", models.Coupon{Code: "GORRA", Type: models.CouponTypeFixed, Value: 5000, ProductIDs: []uint{2}}, "", 2000, 20000, false},
+		{"recargo sobre el total con descuento", models.Coupon{Code: "FIJO", Type: models.CouponTypeFixed, Value: 2000}, models.PaymentMethodCreditCard, 2000, 22000, false},
+		{"compra mínima no alcanzada", models.Coupon{Code: "MINIMO", Type: models.CouponTypeFixed, Value: 1000, MinPurchase: 50000}, "", 0, 0, true},
+		{"no aplica a ningún producto", models.Coupon{Code: "REMERAS", Type: models.CouponTypePercentage, Value: 10, Categories: []string{"remeras"}}, "", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newOrder(tt.method)
+			coupon := tt.coupon
+			err := services.PriceOrder(order, catalog, pricingRules(10), &coupon)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PriceOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if order.Discount != tt.wantDiscount || order.TotalAmount != tt.wantTotal || order.CouponCode != coupon.Code {
+				t.Errorf("discount = %v, total = %v, coupon = %q; want %v, %v, %q",
+					order.Discount, order.TotalAmount, order.CouponCode, tt.wantDiscount, tt.wantTotal, coupon.Code)
+			}
+		})
+	}
+}
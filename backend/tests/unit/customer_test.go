@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestNormalizePhone verifica que distintas formas de escribir un mismo teléfono coincidan
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+	}{
+		{"11 5555-1234", "1155551234"},
+		{"+54 9 11 5555-1234", "1155551234"},
+		{"+54 11 5555 1234", "1155551234"},
+		{"011 15 5555-1234", "1155551234"},
+		{"(0351) 15 123-4567", "3511234567"},
+		{"0221 15 444 5566", "2214445566"},
+		{"2214445566", "2214445566"},
+		{"1234", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			if got := models.NormalizePhone(tt.phone); got != tt.want {
+				t.Errorf("NormalizePhone(%q) = %q, want %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCustomerMergeContact verifica cómo se actualiza un cliente con los datos de un pedido nuevo
+func TestCustomerMergeContact(t *testing.T) {
+	c := models.Customer{Name: "Ana", Phone: "11 5555-1234", Address: "Calle 1"}
+
+	c.MergeContact("Ana Pérez", " ANA@Mail.com ", "011 15 4444-0000", "")
+	if c.Name != "Ana Pérez" {
+		t.Errorf("Name = %q, want el último informado", c.Name)
+	}
+	if c.Email != "ana@mail.com" {
+		t.Errorf("Email = %q, want ana@mail.com", c.Email)
+	}
+	if c.Phone != "11 5555-1234" {
+		t.Errorf("Phone = %q, no debe reemplazarse si ya tenía uno", c.Phone)
+	}
+	if c.Address != "Calle 1" {
+		t.Errorf("Address = %q, no debe borrarse con un valor vacío", c.Address)
+	}
+}
+
+// TestCustomerFillContact verifica que un pedido del checkout solo complete los datos faltantes
+func TestCustomerFillContact(t *testing.T) {
+	c := models.Customer{Name: "Ana", Phone: "11 5555-1234", Address: "Calle 1"}
+
+	c.FillContact("Otro Nombre", " ANA@Mail.com ", "011 15 4444-0000", "Calle 2")
+	if c.Name != "Ana" {
+		t.Errorf("Name = %q, no debe reemplazarse desde el checkout", c.Name)
+	}
+	if c.Address != "Calle 1" {
+		t.Errorf("Address = %q, no debe reemplazarse desde el checkout", c.Address)
+	}
+	if c.Email != "ana@mail.com" {
+		t.Errorf("Email = %q, want ana@mail.com", c.Email)
+	}
+	if c.Phone != "11 5555-1234" {
+		t.Errorf("Phone = %q, no debe reemplazarse si ya tenía uno", c.Phone)
+	}
+
+	empty := models.Customer{Phone: "11 5555-1234"}
+	empty.FillContact(" Ana Pérez ", "", "", "Calle 3")
+	if empty.Name != "Ana Pérez" || empty.Address != "Calle 3" {
+		t.Errorf("FillContact() = %q, %q, want los datos que faltaban", empty.Name, empty.Address)
+	}
+}
+
+// TestCustomerValidate verifica que el cliente tenga nombre y algún dato de contacto
+func TestCustomerValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		customer models.Customer
+		wantErr  bool
+	}{
+		{"con teléfono", models.Customer{Name: "Ana", Phone: "11 5555-1234"}, false},
+		{"con email", models.Customer{Name: "Ana", Email: "ana@mail.com"}, false},
+		{"sin nombre", models.Customer{Phone: "11 5555-1234"}, true},
+		{"sin contacto", models.Customer{Name: "Ana"}, true},
+		{"email inválido", models.Customer{Name: "Ana", Email: "ana@"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.customer.Normalize()
+			if err := tt.customer.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
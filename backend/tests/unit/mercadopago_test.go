@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"tiendaedgar/backend/mercadopago"
+	"tiendaedgar/backend/mercadopago/mercadopagotest"
+	"tiendaedgar/backend/models"
+)
+
+// TestMercadoPagoPreferenceAndPayment verifica el cliente contra el servidor falso
+func TestMercadoPagoPreferenceAndPayment(t *testing.T) {
+	fake := mercadopagotest.NewServer()
+	defer fake.Close()
+
+	client := mercadopago.NewClient("TEST-token", "", fake.URL)
+
+	pref, err := client.CreatePreference(models.PaymentPreferenceRequest{
+		ExternalReference: "7KQ2-M9XD",
+		Title:             "Pedido 7KQ2-M9XD",
+		Amount:            25000,
+	})
+	if err != nil {
+		t.Fatalf("CreatePreference() error = %v", err)
+	}
+	if pref.ID == "" || pref.InitPoint == "" {
+		t.Fatalf("preferencia incompleta: %+v", pref)
+	}
+
+	title, amount, ref, ok := fake.Preference(pref.ID)
+	if !ok || title != "Pedido 7KQ2-M9XD" || amount != 25000 || ref != "7KQ2-M9XD" {
+		t.Errorf("preferencia enviada = %q %v %q (ok=%v)", title, amount, ref, ok)
+	}
+
+	paymentID := fake.AddPayment("7KQ2-M9XD", models.GatewayPaymentApproved, 25000)
+	payment, err := client.GetPayment(paymentID)
+	if err != nil {
+		t.Fatalf("GetPayment() error = %v", err)
+	}
+	if payment.ID != paymentID || payment.Status != models.GatewayPaymentApproved ||
+		payment.ExternalReference != "7KQ2-M9XD" || payment.Amount != 25000 {
+		t.Errorf("pago = %+v", payment)
+	}
+
+	if _, err := client.GetPayment("999999"); err == nil {
+		t.Error("GetPayment() de un pago inexistente debería fallar")
+	}
+	for _, id := range []string{"../checkout/preferences", "123?x=1", ""} {
+		if _, err := client.GetPayment(id); !errors.Is(err, mercadopago.ErrInvalidPaymentID) {
+			t.Errorf("GetPayment(%q) error = %v, want ErrInvalidPaymentID", id, err)
+		}
+	}
+}
+
+// TestMercadoPagoVerifyNotification verifica la validación del header x-signature
+func TestMercadoPagoVerifyNotification(t *testing.T) {
+	const secret = "clave-webhook"
+	client := mercadopago.NewClient("TEST-token", secret, "http://localhost")
+
+	valid := "ts=1704908010,v1=" + mercadopago.Sign(secret, "123456", "req-1", "1704908010")
+
+	tests := []struct {
+		name    string
+		n       models.PaymentNotification
+		wantErr bool
+	}{
+		{"firma válida", models.PaymentNotification{Type: "payment", DataID: "123456", RequestID: "req-1", Signature: valid}, false},
+		{"otro pago", models.PaymentNotification{Type: "payment", DataID: "654321", RequestID: "req-1", Signature: valid}, true},
+		{"otro request-id", models.PaymentNotification{Type: "payment", DataID: "123456", RequestID: "req-2", Signature: valid}, true},
+		{"sin firma", models.PaymentNotification{Type: "payment", DataID: "123456", RequestID: "req-1"}, true},
+		{"firma mal formada", models.PaymentNotification{Type: "payment", DataID: "123456", RequestID: "req-1", Signature: "v1=abc"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.VerifyNotification(tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyNotification() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Sin clave configurada no se valida la firma
+	unsigned := mercadopago.NewClient("TEST-token", "", "http://localhost")
+	if err := unsigned.VerifyNotification(models.PaymentNotification{Type: "payment", DataID: "1"}); err != nil {
+		t.Errorf("sin clave no debería fallar: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"tiendaedgar/backend/mailer"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+	"time"
+)
+
+// TestNotificationForStatus verifica qué estados del pedido generan un email al cliente
+func TestNotificationForStatus(t *testing.T) {
+	cases := map[models.OrderStatus]models.OrderNotificationKind{
+		models.OrderStatusPending:   models.OrderNotificationReceived,
+		models.OrderStatusPaid:      models.OrderNotificationPaid,
+		models.OrderStatusShipped:   models.OrderNotificationShipped,
+		models.OrderStatusDelivered: models.OrderNotificationDelivered,
+	}
+	for status, want := range cases {
+		if kind, ok := models.NotificationForStatus(status); !ok || kind != want {
+			t.Errorf("NotificationForStatus(%s) = %s, %v; want %s", status, kind, ok, want)
+		}
+	}
+	if _, ok := models.NotificationForStatus(models.OrderStatusCancelled); ok {
+		t.Error("NotificationForStatus(Cancelado) no debería enviar email")
+	}
+}
+
+// TestRenderOrderEmail verifica el asunto, el detalle y el escapado del HTML
+func TestRenderOrderEmail(t *testing.T) {
+	order := &models.Order{
+		OrderNumber:  "CS-2026-000042",
+		CustomerName: "Ana <b>Pérez</b>",
+		TotalAmount:  15000,
+		ShippingCost: 3000,
+		Items: []models.OrderItem{
+			{ProductName: "Zapatilla <Urbana>", Talla: "40", Color: "negro", Quantity: 2, Subtotal: 12000},
+		},
+	}
+	shipment := &models.Shipment{Carrier: "correo_argentino", TrackingNumber: "CA123"}
+
+	msg, err := services.RenderOrderEmail(services.NewOrderEmail(models.OrderNotificationShipped, order, shipment, "Tienda", "https://tienda.test/seguimiento/abc"))
+	if err != nil {
+		t.Fatalf("RenderOrderEmail() error = %v", err)
+	}
+	if msg.Subject != "Tu pedido CS-2026-000042 está en camino" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	for _, want := range []string{"Correo argentino", "CA123", "2 x Zapatilla <Urbana> - Talla 40 / negro", "https://tienda.test/seguimiento/abc"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Text no contiene %q:\n%s", want, msg.Text)
+		}
+	}
+	if strings.Contains(msg.HTML, "<Urbana>") || !strings.Contains(msg.HTML, "Zapatilla &lt;Urbana&gt;") {
+		t.Errorf("HTML sin escapar:\n%s", msg.HTML)
+	}
+
+	// El transportista solo se informa en el email de despacho
+	msg, _ = services.RenderOrderEmail(services.NewOrderEmail(models.OrderNotificationPaid, order, shipment, "Tienda", ""))
+	if strings.Contains(msg.Text, "CA123") || strings.Contains(msg.Text, "Seguí tu pedido") {
+		t.Errorf("email de pago con datos de envío:\n%s", msg.Text)
+	}
+}
+
+// TestSMTPSenderSink verifica el envío por SMTP contra el servidor de prueba, con la copia oculta fuera de los encabezados
+func TestSMTPSenderSink(t *testing.T) {
+	sink, err := mailer.NewSink("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	host, port := sink.Addr()
+	sender := mailer.NewSMTPSender(host, port, "", "", "tienda@example.com")
+	err = sender.Send(models.EmailMessage{
+		FromName: "Tienda",
+		To:       []string{"ana@example.com"},
+		Bcc:      []string{"ventas@example.com"},
+		Subject:  "Recibimos tu pedido CS-2026-000042",
+		Text:     "Hola Ana,\n.\nGracias",
+		HTML:     "<p>Hola Ana</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case <-sink.Received():
+	case <-time.After(2 * time.Second):
+		t.Fatal("el servidor de prueba no recibió el email")
+	}
+	messages := sink.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("mensajes = %d, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.From != "tienda@example.com" || strings.Join(msg.To, ",") != "ana@example.com,ventas@example.com" {
+		t.Errorf("sobre = %s -> %v", msg.From, msg.To)
+	}
+	if strings.Contains(msg.Data, "ventas@example.com") {
+		t.Error("la copia oculta aparece en los encabezados")
+	}
+	for _, want := range []string{"To: <ana@example.com>", "Subject: Recibimos tu pedido CS-2026-000042", "multipart/alternative", "text/html"} {
+		if !strings.Contains(msg.Data, want) {
+			t.Errorf("mensaje no contiene %q:\n%s", want, msg.Data)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+	"time"
+)
+
+// TestOrderFilterValidate verifica la validación de los filtros del listado de pedidos
+func TestOrderFilterValidate(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	low, high := 1000.0, 5000.0
+
+	valid := models.OrderFilter{
+		Statuses:       []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusDelivered},
+		PaymentMethods: []models.PaymentMethod{models.PaymentMethodCash},
+		From:           &from,
+		To:             &to,
+		MinTotal:       &low,
+		MaxTotal:       &high,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	if valid.Sort != models.OrderSortNewest {
+		t.Errorf("Sort = %q, want %q por defecto", valid.Sort, models.OrderSortNewest)
+	}
+
+	tests := []struct {
+		name   string
+		filter models.OrderFilter
+	}{
+		{"estado desconocido", models.OrderFilter{Statuses: []models.OrderStatus{"Perdido"}}},
+		{"medio de pago vacío", models.OrderFilter{PaymentMethods: []models.PaymentMethod{""}}},
+		{"medio de pago desconocido", models.OrderFilter{PaymentMethods: []models.PaymentMethod{"cheque"}}},
+		{"fechas invertidas", models.OrderFilter{From: &to, To: &from}},
+		{"totales invertidos", models.OrderFilter{MinTotal: &high, MaxTotal: &low}},
+		{"orden desconocido", models.OrderFilter{Sort: "price"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); err == nil {
+				t.Error("Validate() = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestOrderNumberFormat verifica el armado del número de pedido visible
+func TestOrderNumberFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format models.OrderNumberFormat
+		seq    int
+		want   string
+	}{
+		{"por defecto", models.OrderNumberFormat{Prefix: "CS", Digits: 6}, 123, "CS-2026-000123"},
+		{"pocos dígitos", models.OrderNumberFormat{Prefix: "TE", Digits: 3}, 7, "TE-2026-007"},
+		{"secuencial más largo que los dígitos", models.OrderNumberFormat{Prefix: "CS", Digits: 3}, 12345, "CS-2026-12345"},
+		{"sin prefijo", models.OrderNumberFormat{Digits: 4}, 1, "2026-0001"},
+		{"sin dígitos configurados", models.OrderNumberFormat{Prefix: "CS"}, 1, "CS-2026-000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Format(2026, tt.seq); got != tt.want {
+				t.Errorf("Format(2026, %d) = %q, want %q", tt.seq, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSiteConfigOrderNumbering verifica los valores por defecto y la validación del formato
+func TestSiteConfigOrderNumbering(t *testing.T) {
+	config := models.SiteConfig{OrderNumberPrefix: " te "}
+	config.NormalizeOrderNumbering()
+	if config.OrderNumberPrefix != "TE" || config.OrderNumberDigits != models.DefaultOrderNumberDigits {
+		t.Errorf("normalizado = %q/%d, want TE/%d", config.OrderNumberPrefix, config.OrderNumberDigits, models.DefaultOrderNumberDigits)
+	}
+	if err := config.ValidateOrderNumbering(); err != nil {
+		t.Errorf("ValidateOrderNumbering() = %v, want nil", err)
+	}
+
+	invalid := []models.SiteConfig{
+		{OrderNumberPrefix: "C-S", OrderNumberDigits: 6},
+		{OrderNumberPrefix: "CERROSNEAKERS", OrderNumberDigits: 6},
+		{OrderNumberPrefix: "CS", OrderNumberDigits: 2},
+		{OrderNumberPrefix: "CS", OrderNumberDigits: 11},
+	}
+	for _, c := range invalid {
+		if err := c.ValidateOrderNumbering(); err == nil {
+			t.Errorf("ValidateOrderNumbering(%q, %d) = nil, want error", c.OrderNumberPrefix, c.OrderNumberDigits)
+		}
+	}
+
+	order := models.Order{Reference: "7KQ2-M9XD"}
+	if got := order.DisplayNumber(); got != "7KQ2-M9XD" {
+		t.Errorf("DisplayNumber() sin número = %q, want la referencia", got)
+	}
+	order.OrderNumber = "CS-2026-000001"
+	if got := order.DisplayNumber(); got != "CS-2026-000001" {
+		t.Errorf("DisplayNumber() = %q, want el número", got)
+	}
+}
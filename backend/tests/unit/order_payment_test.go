@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestSummarizePayments verifica el cálculo de lo cobrado y el saldo pendiente
+func TestSummarizePayments(t *testing.T) {
+	sena := models.OrderPayment{Kind: models.OrderPaymentKindPayment, Amount: 3000, Method: models.PaymentMethodTransfer}
+	saldo := models.OrderPayment{Kind: models.OrderPaymentKindPayment, Amount: 7000, Method: models.PaymentMethodCash}
+	devolucion := models.OrderPayment{Kind: models.OrderPaymentKindRefund, Amount: 1500, Method: models.PaymentMethodCash}
+
+	tests := []struct {
+		name         string
+		payments     []models.OrderPayment
+		wantPaid     float64
+		wantRefunded float64
+		wantBalance  float64
+	}{
+		{"sin pagos", nil, 0, 0, 10000},
+		{"seña del 30%", []models.OrderPayment{sena}, 3000, 0, 7000},
+		{"saldado", []models.OrderPayment{sena, saldo}, 10000, 0, 0},
+		{"con devolución", []models.OrderPayment{sena, saldo, devolucion}, 8500, 1500, 1500},
+		{"pagado de más", []models.OrderPayment{sena, saldo, sena}, 13000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := models.SummarizePayments(10000, tt.payments)
+			if got.AmountPaid != tt.wantPaid || got.AmountRefunded != tt.wantRefunded || got.BalanceDue != tt.wantBalance {
+				t.Errorf("SummarizePayments() = %+v, want paid=%v refunded=%v balance=%v",
+					got, tt.wantPaid, tt.wantRefunded, tt.wantBalance)
+			}
+		})
+	}
+}
+
+// TestOrderPaymentValidate verifica la validación de cobros y devoluciones
+func TestOrderPaymentValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payment models.OrderPayment
+		wantErr bool
+	}{
+		{"cobro válido", models.OrderPayment{Kind: models.OrderPaymentKindPayment, Amount: 100, Method: models.PaymentMethodCash}, false},
+		{"devolución válida", models.OrderPayment{Kind: models.OrderPaymentKindRefund, Amount: 100, Method: models.PaymentMethodTransfer}, false},
+		{"importe cero", models.OrderPayment{Kind: models.OrderPaymentKindPayment, Amount: 0, Method: models.PaymentMethodCash}, true},
+		{"importe negativo", models.OrderPayment{Kind: models.OrderPaymentKindPayment, Amount: -50, Method: models.PaymentMethodCash}, true},
+		{"sin medio de pago", models.OrderPayment{Kind: models.OrderPaymentKindPayment, Amount: 100}, true},
+		{"medio inválido", models.OrderPayment{Kind: models.OrderPaymentKindPayment, Amount: 100, Method: "cheque"}, true},
+		{"sin tipo", models.OrderPayment{Amount: 100, Method: models.PaymentMethodCash}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.payment.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+)
+
+func pricingCatalog() map[uint]*models.Product {
+	precioVariante := 1200.0
+	return map[uint]*models.Product{
+		1: {ID: 1, Nombre: "Zapa", Precio: 1000, Variantes: []models.ProductVariant{
+			{ID: 10, ProductID: 1, Talla: "42", Precio: &precioVariante},
+			{ID: 11, ProductID: 1, Talla: "43"},
+		}},
+		2: {ID: 2, Nombre: "Gorra", Precio: 333.33},
+	}
+}
+
+func pricingRules(creditCardSurcharge float64) services.PricingRules {
+	return services.PricingRules{PaymentMethods: models.DefaultPaymentMethods(creditCardSurcharge)}
+}
+
+// TestPriceOrderComputesFromCatalog verifica que precios y totales salen del catálogo
+func TestPriceOrderComputesFromCatalog(t *testing.T) {
+	v42, v43 := uint(10), uint(11)
+	order := &models.Order{Items: []models.OrderItem{
+		{ProductID: 1, VariantID: &v42, Quantity: 1, ProductName: "nombre del cliente"},
+		{ProductID: 1, VariantID: &v43, Quantity: 2},
+		{ProductID: 2, Quantity: 3},
+	}}
+
+	if err := services.PriceOrder(order, pricingCatalog(), pricingRules(15), nil); err != nil {
+		t.Fatalf("PriceOrder() error = %v", err)
+	}
+
+	if order.Items[0].UnitPrice != 1200 || order.Items[0].ProductName != "Zapa" {
+		t.Errorf("Expected variant price 1200 and catalog name, got %v %q", order.Items[0].UnitPrice, order.Items[0].ProductName)
+	}
+	if order.Items[1].Subtotal != 2000 {
+		t.Errorf("Expected subtotal 2000, got %v", order.Items[1].Subtotal)
+	}
+	if order.Items[2].Subtotal != 999.99 {
+		t.Errorf("Expected subtotal 999.99, got %v", order.Items[2].Subtotal)
+	}
+	if order.Subtotal != 4199.99 || order.Surcharge != 0 || order.TotalAmount != 4199.99 {
+		t.Errorf("Expected total 4199.99 without surcharge, got %v + %v = %v", order.Subtotal, order.Surcharge, order.TotalAmount)
+	}
+}
+
+// TestPriceOrderCreditCardSurcharge verifica el recargo por tarjeta de crédito
+func TestPriceOrderCreditCardSurcharge(t *testing.T) {
+	v43 := uint(11)
+	order := &models.Order{
+		PaymentMethod: models.PaymentMethodCreditCard,
+		TotalAmount:   2300,
+		Items:         []models.OrderItem{{ProductID: 1, VariantID: &v43, Quantity: 2}},
+	}
+
+	if err := services.PriceOrder(order, pricingCatalog(), pricingRules(15), nil); err != nil {
+		t.Fatalf("PriceOrder() error = %v", err)
+	}
+	if order.Surcharge != 300 || order.TotalAmount != 2300 {
+		t.Errorf("Expected surcharge 300 and total 2300, got %v / %v", order.Surcharge, order.TotalAmount)
+	}
+}
+
+// TestPriceOrderRejectsMismatch verifica que se rechacen importes que no coinciden
+func TestPriceOrderRejectsMismatch(t *testing.T) {
+	v42 := uint(10)
+	order := &models.Order{
+		TotalAmount: 1100,
+		Items:       []models.OrderItem{{ProductID: 1, VariantID: &v42, Quantity: 1, UnitPrice: 1100, Subtotal: 1100}},
+	}
+
+	err := services.PriceOrder(order, pricingCatalog(), services.PricingRules{}, nil)
+	var mismatch *services.PriceMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Expected PriceMismatchError, got %v", err)
+	}
+	if len(mismatch.Items) != 3 {
+		t.Errorf("Expected 3 mismatches (unit_price, subtotal, total), got %d", len(mismatch.Items))
+	}
+	if order.TotalAmount != 1100 {
+		t.Error("Un pedido rechazado no debe modificar el total enviado")
+	}
+}
+
+// TestPriceOrderPaymentMethodRules verifica descuentos, cuotas y medios deshabilitados
+func TestPriceOrderPaymentMethodRules(t *testing.T) {
+	rules := pricingRules(10)
+	rules.PaymentMethods[0].AdjustmentPercent = -10 // efectivo con 10% de descuento
+	rules.PaymentMethods[2].Enabled = false         // débito deshabilitado
+	rules.PaymentMethods[3].Installments = []models.InstallmentPlan{{Cuotas: 3, SurchargePercent: 20}}
+
+	v43 := uint(11)
+	newOrder := func(method models.PaymentMethod, installments int) *models.Order {
+		return &models.Order{
+			PaymentMethod: method,
+			Installments:  installments,
+			Items:         []models.OrderItem{{ProductID: 1, VariantID: &v43, Quantity: 1}},
+		}
+	}
+
+	cash := newOrder(models.PaymentMethodCash, 0)
+	if err := services.PriceOrder(cash, pricingCatalog(), rules, nil); err != nil {
+		t.Fatalf("PriceOrder() error = %v", err)
+	}
+	if cash.Surcharge != -100 || cash.TotalAmount != 900 {
+		t.Errorf("Expected discount -100 and total 900, got %v / %v", cash.Surcharge, cash.TotalAmount)
+	}
+
+	credit := newOrder(models.PaymentMethodCreditCard, 3)
+	if err := services.PriceOrder(credit, pricingCatalog(), rules, nil); err != nil {
+		t.Fatalf("PriceOrder() error = %v", err)
+	}
+	if credit.TotalAmount != 1200 || credit.Installments != 3 {
+		t.Errorf("Expected total 1200 in 3 cuotas, got %v in %d", credit.TotalAmount, credit.Installments)
+	}
+
+	if err := services.PriceOrder(newOrder(models.PaymentMethodCreditCard, 6), pricingCatalog(), rules, nil); err == nil {
+		t.Error("Expected error for an installment plan that is not offered")
+	}
+	if err := services.PriceOrder(newOrder(models.PaymentMethodDebitCard, 0), pricingCatalog(), rules, nil); err == nil {
+		t.Error("Expected error for a disabled payment method")
+	}
+}
+
+// TestPaymentQuote verifica la cotización por medio de pago y cuotas
+func TestPaymentQuote(t *testing.T) {
+	rules := pricingRules(15)
+	rules.PaymentMethods[3].Installments = []models.InstallmentPlan{{Cuotas: 6, SurchargePercent: 30}}
+
+	quotes := rules.Quote(1000)
+	if len(quotes) != 5 {
+		t.Fatalf("Expected 5 quotes (4 methods + 6 cuotas), got %d", len(quotes))
+	}
+
+	last := quotes[len(quotes)-1]
+	if last.Installments != 6 || last.Total != 1300 || last.InstallmentAmount != 216.67 {
+		t.Errorf("Unexpected 6-cuotas quote: %+v", last)
+	}
+}
@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestOrderReturnRequestValidate verifica la validación de devoluciones y cambios
+func TestOrderReturnRequestValidate(t *testing.T) {
+	item := []models.ReturnItemRequest{{OrderItemID: 1, Quantity: 1}}
+	replacement := []models.CheckoutItem{{ProductID: 1, Talla: "43", Quantity: 1}}
+
+	tests := []struct {
+		name    string
+		req     models.OrderReturnRequest
+		wantErr bool
+	}{
+		{"cambio de talla", models.OrderReturnRequest{Resolution: "exchange", Items: item, Replacements: replacement}, false},
+		{"crédito", models.OrderReturnRequest{Resolution: "store_credit", Items: item}, false},
+		{"reintegro", models.OrderReturnRequest{Resolution: "refund", RefundMethod: "efectivo", Items: item}, false},
+		{"cambio sin reemplazo", models.OrderReturnRequest{Resolution: "exchange", Items: item}, true},
+		{"crédito con reemplazo", models.OrderReturnRequest{Resolution: "store_credit", Items: item, Replacements: replacement}, true},
+		{"reintegro sin medio", models.OrderReturnRequest{Resolution: "refund", Items: item}, true},
+		{"resolución desconocida", models.OrderReturnRequest{Resolution: "regalo", Items: item}, true},
+		{"sin productos", models.OrderReturnRequest{Resolution: "store_credit"}, true},
+		{"estado inválido", models.OrderReturnRequest{Resolution: "store_credit", Items: []models.ReturnItemRequest{{OrderItemID: 1, Quantity: 1, Condition: "usado"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Normalize()
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestPaidUnitPrice verifica el prorrateo del descuento del cupón en lo devuelto
+func TestPaidUnitPrice(t *testing.T) {
+	item := models.OrderItem{UnitPrice: 1000, Quantity: 2}
+
+	if got := models.PaidUnitPrice(&models.Order{Subtotal: 2000}, item); got != 1000 {
+		t.Errorf("PaidUnitPrice() sin cupón = %v, want 1000", got)
+	}
+	if got := models.PaidUnitPrice(&models.Order{Subtotal: 2000, Discount: 200}, item); got != 900 {
+		t.Errorf("PaidUnitPrice() con 10%% de descuento = %v, want 900", got)
+	}
+}
+
+// TestOrderAmountDue verifica el saldo a pagar luego de devoluciones y cambios
+func TestOrderAmountDue(t *testing.T) {
+	order := models.Order{TotalAmount: 1800, ReturnAdjustment: 900}
+	if got := order.AmountDue(); got != 900 {
+		t.Errorf("AmountDue() con devolución = %v, want 900", got)
+	}
+
+	order.ReturnAdjustment = -100 // Cambio por un producto más caro
+	if got := order.AmountDue(); got != 1900 {
+		t.Errorf("AmountDue() con diferencia a cobrar = %v, want 1900", got)
+	}
+}
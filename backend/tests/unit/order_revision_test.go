@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestDiffOrderItems verifica el cálculo de cambios al editar los productos de un pedido
+func TestDiffOrderItems(t *testing.T) {
+	v1, v2 := uint(11), uint(12)
+	before := []models.OrderItem{
+		{ProductID: 1, VariantID: &v1, Talla: "42", ProductName: "Zapa", Quantity: 2},
+		{ProductID: 1, VariantID: &v2, Talla: "43", ProductName: "Zapa", Quantity: 1},
+		{ProductID: 2, ProductName: "Media", Quantity: 3},
+	}
+	after := []models.OrderItem{
+		{ProductID: 1, VariantID: &v1, Talla: "42", Quantity: 1},
+		{ProductID: 1, VariantID: &v1, Talla: "42", Quantity: 2}, // Misma variante en dos líneas
+		{ProductID: 2, Quantity: 3},
+		{ProductID: 3, ProductName: "Gorra", Quantity: 1},
+	}
+
+	changes := models.DiffOrderItems(before, after)
+	want := []struct {
+		productID     uint
+		before, after int
+	}{
+		{1, 2, 3}, // Talle 42: se agregó una unidad
+		{1, 1, 0}, // Talle 43: se quitó
+		{3, 0, 1}, // Gorra: nueva
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("DiffOrderItems() devolvió %d cambios, want %d: %+v", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.ProductID != w.productID || c.QuantityBefore != w.before || c.QuantityAfter != w.after {
+			t.Errorf("cambio %d = %+v, want producto %d de %d a %d", i, c, w.productID, w.before, w.after)
+		}
+	}
+	if changes[0].ProductName != "Zapa" || changes[0].Delta() != 1 || changes[1].Delta() != -1 {
+		t.Errorf("cambio de talle 42 = %+v, delta %d", changes[0], changes[0].Delta())
+	}
+
+	if diff := models.DiffOrderItems(before, before); len(diff) != 0 {
+		t.Errorf("DiffOrderItems() sin cambios = %+v, want vacío", diff)
+	}
+}
+
+// TestOrderStatusAllowsItemChanges verifica en qué estados se pueden editar los productos
+func TestOrderStatusAllowsItemChanges(t *testing.T) {
+	editable := map[models.OrderStatus]bool{
+		models.OrderStatusPending:    true,
+		models.OrderStatusPaid:       true,
+		models.OrderStatusProcessing: true,
+		models.OrderStatusShipped:    false,
+		models.OrderStatusDelivered:  false,
+		models.OrderStatusCancelled:  false,
+	}
+	for status, want := range editable {
+		if got := status.AllowsItemChanges(); got != want {
+			t.Errorf("%s.AllowsItemChanges() = %v, want %v", status, got, want)
+		}
+	}
+}
+
+// TestOrderItemsUpdateValidate verifica la validación del cuerpo de edición de items
+func TestOrderItemsUpdateValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		update  models.OrderItemsUpdate
+		wantErr bool
+	}{
+		{"válido", models.OrderItemsUpdate{Items: []models.CheckoutItem{{ProductID: 1, Quantity: 15}}}, false},
+		{"sin items", models.OrderItemsUpdate{}, true},
+		{"sin producto", models.OrderItemsUpdate{Items: []models.CheckoutItem{{Quantity: 1}}}, true},
+		{"cantidad cero", models.OrderItemsUpdate{Items: []models.CheckoutItem{{ProductID: 1}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.update.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
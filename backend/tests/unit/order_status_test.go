@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestOrderStatusTransitions verifica el grafo de estados de pedidos
+func TestOrderStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name string
+		from models.OrderStatus
+		to   models.OrderStatus
+		want bool
+	}{
+		{"pendiente a pagado", models.OrderStatusPending, models.OrderStatusPaid, true},
+		{"pagado a en preparación", models.OrderStatusPaid, models.OrderStatusProcessing, true},
+		{"en preparación a enviado", models.OrderStatusProcessing, models.OrderStatusShipped, true},
+		{"enviado a entregado", models.OrderStatusShipped, models.OrderStatusDelivered, true},
+		{"pendiente a cancelado", models.OrderStatusPending, models.OrderStatusCancelled, true},
+		{"en preparación a cancelado", models.OrderStatusProcessing, models.OrderStatusCancelled, true},
+		{"cancelado a pendiente (reactivar)", models.OrderStatusCancelled, models.OrderStatusPending, true},
+		{"pendiente a enviado (salteo)", models.OrderStatusPending, models.OrderStatusShipped, false},
+		{"enviado a cancelado", models.OrderStatusShipped, models.OrderStatusCancelled, false},
+		{"entregado a pendiente", models.OrderStatusDelivered, models.OrderStatusPending, false},
+		{"cancelado a pagado", models.OrderStatusCancelled, models.OrderStatusPaid, false},
+		{"mismo estado", models.OrderStatusPaid, models.OrderStatusPaid, false},
+		{"estado desconocido", models.OrderStatus("Perdido"), models.OrderStatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("CanTransitionTo(%s → %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOrderStatusReservesStock verifica qué estados mantienen el stock reservado
+func TestOrderStatusReservesStock(t *testing.T) {
+	if models.OrderStatusCancelled.ReservesStock() {
+		t.Error("Expected Cancelado to release stock")
+	}
+	for _, status := range []models.OrderStatus{models.OrderStatusPending, models.OrderStatusPaid, models.OrderStatusShipped, models.OrderStatusDelivered} {
+		if !status.ReservesStock() {
+			t.Errorf("Expected %s to reserve stock", status)
+		}
+	}
+	if models.OrderStatus("Perdido").IsValid() {
+		t.Error("Expected unknown status to be invalid")
+	}
+}
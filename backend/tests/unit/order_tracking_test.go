@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"tiendaedgar/backend/models"
+	"time"
+)
+
+// TestNewTrackingToken verifica que los tokens sean URL-safe y distintos entre sí
+func TestNewTrackingToken(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		token := models.NewTrackingToken()
+		if len(token) != 24 || strings.ContainsAny(token, "+/=") {
+			t.Fatalf("token %q: want 24 caracteres URL-safe", token)
+		}
+		if seen[token] {
+			t.Fatalf("token repetido: %s", token)
+		}
+		seen[token] = true
+	}
+}
+
+// TestNewOrderTracking verifica que el seguimiento público no exponga datos del cliente
+func TestNewOrderTracking(t *testing.T) {
+	created := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	order := &models.Order{
+		ID:              42,
+		OrderNumber:     "CS-2026-000042",
+		Reference:       "7KQ2-M9XD",
+		CustomerName:    "Ana Pérez",
+		CustomerPhone:   "11 5555-1234",
+		CustomerAddress: "Calle 1",
+		ShippingMethod:  "correo",
+		TrackingNumber:  "OCA-123",
+		TotalAmount:     25000,
+		Status:          models.OrderStatusShipped,
+		Notes:           "Cliente difícil",
+		Items: []models.OrderItem{
+			{ProductName: "Air Max", Talla: "42", Color: "Negro", Quantity: 1, UnitPrice: 25000, Subtotal: 25000},
+		},
+		CreatedAt: created,
+	}
+	history := []models.OrderStatusHistory{
+		{ToStatus: models.OrderStatusPending, ChangedBy: "checkout", CreatedAt: created},
+		{FromStatus: models.OrderStatusPending, ToStatus: models.OrderStatusPaid, ChangedBy: "admin", CreatedAt: created.Add(time.Hour)},
+		{FromStatus: models.OrderStatusPaid, ToStatus: models.OrderStatusPaid, Note: "Devolución", CreatedAt: created.Add(2 * time.Hour)},
+		{FromStatus: models.OrderStatusPaid, ToStatus: models.OrderStatusShipped, ChangedBy: "admin", Note: "Despachado", CreatedAt: created.Add(3 * time.Hour)},
+	}
+
+	tracking := models.NewOrderTracking(order, history, "Correo")
+
+	if tracking.OrderNumber != "CS-2026-000042" || tracking.TrackingNumber != "OCA-123" || tracking.ShippingLabel != "Correo" {
+		t.Errorf("tracking = %+v", tracking)
+	}
+	if len(tracking.Timeline) != 3 || tracking.Timeline[2].Status != models.OrderStatusShipped {
+		t.Errorf("Timeline = %+v, want 3 cambios de estado", tracking.Timeline)
+	}
+	if len(tracking.Items) != 1 || tracking.Items[0].Talla != "42" {
+		t.Errorf("Items = %+v", tracking.Items)
+	}
+
+	body, _ := json.Marshal(tracking)
+	for _, private := range []string{"Ana", "5555", "Calle 1", "7KQ2", "25000", "difícil", "admin", `"id"`} {
+		if strings.Contains(string(body), private) {
+			t.Errorf("el seguimiento público expone %q: %s", private, body)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"testing"
+	"tiendaedgar/backend/pdf"
+	"tiendaedgar/backend/services"
+)
+
+// TestPDFDocumentStructure verifica que el PDF generado tenga una tabla xref consistente
+func TestPDFDocumentStructure(t *testing.T) {
+	doc := pdf.New()
+	logo, err := doc.AddImage(image.NewRGBA(image.Rect(0, 0, 10, 5)))
+	if err != nil {
+		t.Fatalf("AddImage() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		doc.AddPage()
+		doc.DrawImage(logo, 40, 40, 20, 10)
+		doc.Text(40, 80, pdf.Bold, 12, "Pedido N° CS-2026-000001 (señado)")
+		doc.Line(40, 90, 200, 90, 1, 0.5)
+	}
+
+	content, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	if !bytes.HasPrefix(content, []byte("%PDF-1.4")) || !bytes.HasSuffix(content, []byte("%%EOF\n")) {
+		t.Fatal("el documento no tiene encabezado o cierre de PDF")
+	}
+
+	match := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(content)
+	if match == nil {
+		t.Fatal("falta startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(content[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d no apunta a la tabla xref", xref)
+	}
+
+	// Catálogo, páginas, 2 fuentes, 1 imagen y 2 páginas con su contenido
+	const objects = 9
+	entries := bytes.Split(content[xref:], []byte("\n"))[3 : 3+objects]
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[:10]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(content[offset:], []byte(want)) {
+			t.Errorf("xref del objeto %d apunta a %q", i+1, content[offset:offset+10])
+		}
+	}
+	if !bytes.Contains(content, []byte("/Count 2")) {
+		t.Error("el árbol de páginas no tiene 2 páginas")
+	}
+}
+
+// TestPDFTextWidth verifica el ancho de texto usado para alinear a la derecha
+func TestPDFTextWidth(t *testing.T) {
+	if got := pdf.TextWidth("0000", pdf.Regular, 10); got != 22.24 {
+		t.Errorf("TextWidth(0000) = %v, want 22.24", got)
+	}
+	if pdf.TextWidth("Ñandú", pdf.Regular, 10) != pdf.TextWidth("Nandu", pdf.Regular, 10) {
+		t.Error("las letras acentuadas deben medir como su letra base")
+	}
+	if pdf.TextWidth("Total", pdf.Bold, 10) <= pdf.TextWidth("Total", pdf.Regular, 10) {
+		t.Error("la negrita debe ser más ancha que la regular")
+	}
+}
+
+// TestFormatMoney verifica el formato de importes de los comprobantes
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "$ 0,00"},
+		{999.5, "$ 999,50"},
+		{12345.678, "$ 12.345,68"},
+		{1234567, "$ 1.234.567,00"},
+		{-1500, "-$ 1.500,00"},
+	}
+	for _, tt := range tests {
+		if got := services.FormatMoney(tt.amount); got != tt.want {
+			t.Errorf("FormatMoney(%v) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
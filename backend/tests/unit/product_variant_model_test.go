@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestVariantValidate verifica la validación de variantes
+func TestVariantValidate(t *testing.T) {
+	precioValido := 15000.0
+	precioInvalido := 0.0
+
+	tests := []struct {
+		name    string
+		variant models.ProductVariant
+		wantErr bool
+	}{
+		{"variante válida", models.ProductVariant{Talla: "42", Color: "negro", Stock: 3}, false},
+		{"sin color", models.ProductVariant{Talla: "M", Stock: 0}, false},
+		{"con precio propio", models.ProductVariant{Talla: "42", Precio: &precioValido}, false},
+		{"sin talla", models.ProductVariant{Color: "negro", Stock: 3}, true},
+		{"stock negativo", models.ProductVariant{Talla: "42", Stock: -1}, true},
+		{"precio propio inválido", models.ProductVariant{Talla: "42", Precio: &precioInvalido}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.variant.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestVariantNormalize verifica la normalización y generación de SKU
+func TestVariantNormalize(t *testing.T) {
+	v := models.ProductVariant{ProductID: 12, Talla: " 42 ", Color: " Negro Mate "}
+	v.Normalize()
+
+	if v.Talla != "42" {
+		t.Errorf("Expected talla 42, got %q", v.Talla)
+	}
+	if v.Color != "negro mate" {
+		t.Errorf("Expected color 'negro mate', got %q", v.Color)
+	}
+	if v.SKU != "CS-12-42-NEGRO_MATE" {
+		t.Errorf("Expected SKU CS-12-42-NEGRO_MATE, got %q", v.SKU)
+	}
+
+	sinColor := models.ProductVariant{ProductID: 3, Talla: "xl"}
+	sinColor.Normalize()
+	if sinColor.SKU != "CS-3-XL" {
+		t.Errorf("Expected SKU CS-3-XL, got %q", sinColor.SKU)
+	}
+
+	custom := models.ProductVariant{ProductID: 3, Talla: "40", SKU: " nk-af1-40 "}
+	custom.Normalize()
+	if custom.SKU != "NK-AF1-40" {
+		t.Errorf("Expected SKU NK-AF1-40, got %q", custom.SKU)
+	}
+}
+
+// TestVariantEffectivePrice verifica el precio efectivo de la variante
+func TestVariantEffectivePrice(t *testing.T) {
+	v := models.ProductVariant{Talla: "42"}
+	if got := v.EffectivePrice(1000); got != 1000 {
+		t.Errorf("Expected base price 1000, got %v", got)
+	}
+
+	precio := 1200.0
+	v.Precio = &precio
+	if got := v.EffectivePrice(1000); got != 1200 {
+		t.Errorf("Expected override price 1200, got %v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"testing"
+	"time"
+	"tiendaedgar/backend/carriers"
+	"tiendaedgar/backend/models"
+)
+
+// TestShipmentRequestValidate verifica la normalización y las validaciones del envío
+func TestShipmentRequestValidate(t *testing.T) {
+	shipped := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+	before := shipped.Add(-24 * time.Hour)
+
+	req := models.ShipmentRequest{Carrier: " Andreani ", TrackingNumber: " 360000123 "}
+	req.Normalize()
+	if req.Carrier != "andreani" || req.TrackingNumber != "360000123" {
+		t.Errorf("normalizado = %q/%q", req.Carrier, req.TrackingNumber)
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	invalid := []models.ShipmentRequest{
+		{TrackingNumber: "360000123"},
+		{Carrier: "oca", ShippedAt: &shipped, EstimatedDelivery: &before},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", r)
+		}
+	}
+}
+
+// TestShipmentApplyCarrierStatus verifica la actualización del envío con lo informado por el transportista
+func TestShipmentApplyCarrierStatus(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	deliveredAt := now.Add(-2 * time.Hour)
+	shipment := models.Shipment{Status: models.ShipmentStatusInTransit}
+
+	delivered, err := shipment.ApplyCarrierStatus(models.CarrierStatus{Status: models.ShipmentStatusInTransit, Detail: "En sucursal de destino"}, now)
+	if err != nil || delivered {
+		t.Fatalf("en tránsito: delivered=%v err=%v", delivered, err)
+	}
+	if shipment.StatusDetail != "En sucursal de destino" || shipment.LastCheckedAt == nil {
+		t.Errorf("envío = %+v", shipment)
+	}
+
+	delivered, err = shipment.ApplyCarrierStatus(models.CarrierStatus{Status: models.ShipmentStatusDelivered, DeliveredAt: &deliveredAt}, now)
+	if err != nil || !delivered {
+		t.Fatalf("entregado: delivered=%v err=%v", delivered, err)
+	}
+	if shipment.DeliveredAt == nil || !shipment.DeliveredAt.Equal(deliveredAt) {
+		t.Errorf("DeliveredAt = %v, want %v", shipment.DeliveredAt, deliveredAt)
+	}
+
+	// Una segunda consulta con el mismo estado no vuelve a marcarlo
+	delivered, _ = shipment.ApplyCarrierStatus(models.CarrierStatus{Status: models.ShipmentStatusDelivered}, now)
+	if delivered {
+		t.Error("envío ya entregado: delivered = true")
+	}
+
+	if _, err := shipment.ApplyCarrierStatus(models.CarrierStatus{Status: "perdido"}, now); err == nil {
+		t.Error("estado desconocido: want error")
+	}
+}
+
+// TestFakeCarrier verifica el transportista falso usado en tests
+func TestFakeCarrier(t *testing.T) {
+	fake := carriers.NewFake()
+	fake.SetStatus("OCA-1", models.CarrierStatus{Status: models.ShipmentStatusDelivered})
+
+	status, err := fake.TrackShipment("OCA-1")
+	if err != nil || status.Status != models.ShipmentStatusDelivered {
+		t.Errorf("TrackShipment(OCA-1) = %+v, %v", status, err)
+	}
+	if _, err := fake.TrackShipment("OCA-2"); err == nil {
+		t.Error("TrackShipment() de un envío desconocido debería fallar")
+	}
+	if fake.Queries() != 2 {
+		t.Errorf("Queries() = %d, want 2", fake.Queries())
+	}
+}
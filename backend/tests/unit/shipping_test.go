@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+)
+
+func shippingRules(freeShippingOver float64) services.PricingRules {
+	rules := pricingRules(10)
+	rules.ShippingMethods = models.DefaultShippingMethods()
+	rules.FreeShippingOver = freeShippingOver
+	return rules
+}
+
+// TestParsePostalCode verifica la lectura de códigos postales argentinos
+func TestParsePostalCode(t *testing.T) {
+	tests := map[string]int{
+		"1425":     1425,
+		"C1425ABC": 1425,
+		" b1636 ":  1636,
+		"x5000jxa": 5000,
+		"":         0,
+		"CABA":     0,
+		"123":      0,
+	}
+
+	for input, want := range tests {
+		if got := models.ParsePostalCode(input); got != want {
+			t.Errorf("ParsePostalCode(%q) = %d, want %d", input, got, want)
+		}
+	}
+}
+
+// TestShippingCostByZone verifica el costo de cada método según el destino
+func TestShippingCostByZone(t *testing.T) {
+	rules := shippingRules(0)
+
+	tests := []struct {
+		name       string
+		method     models.ShippingMethodCode
+		postalCode string
+		province   string
+		wantCost   float64
+		wantZone   string
+		wantErr    bool
+	}{
+		{"retiro sin destino", models.ShippingPickup, "", "", 0, "", false},
+		{"moto en CABA por CP", models.ShippingMoto, "C1425ABC", "", 3500, "CABA", false},
+		{"moto en CABA por provincia", models.ShippingMoto, "", "Ciudad Autónoma de Buenos Aires", 3500, "CABA", false},
+		{"moto fuera de CABA", models.ShippingMoto, "5000", "Córdoba", 0, "", true},
+		{"correo AMBA", models.ShippingCorreo, "B1636", "", 5500, "AMBA", false},
+		{"correo al interior", models.ShippingCorreo, "X5000JXA", "Cordoba", 8500, "Resto del país", false},
+		{"correo sin destino", models.ShippingCorreo, "", "", 0, "", true},
+		{"método inexistente", "drone", "1425", "", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := rules.ShippingCost(tt.method, tt.postalCode, tt.province, 10000)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ShippingCost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (quote.Cost != tt.wantCost || quote.Zone != tt.wantZone) {
+				t.Errorf("ShippingCost() = %v (%s), want %v (%s)", quote.Cost, quote.Zone, tt.wantCost, tt.wantZone)
+			}
+		})
+	}
+}
+
+// TestPriceOrderWithShipping verifica que el envío se suma al total y el envío gratis
+func TestPriceOrderWithShipping(t *testing.T) {
+	catalog := map[uint]*models.Product{1: {ID: 1, Nombre: "Zapa", Precio: 20000}}
+	newOrder := func(quantity int) *models.Order {
+		return &models.Order{
+			PaymentMethod:      models.PaymentMethodCreditCard,
+			ShippingMethod:     models.ShippingCorreo,
+			ShippingPostalCode: "5000",
+			Items:              []models.OrderItem{{ProductID: 1, Quantity: quantity}},
+		}
+	}
+
+	order := newOrder(1)
+	if err := services.PriceOrder(order, catalog, shippingRules(50000), nil); err != nil {
+		t.Fatalf("PriceOrder() error = %v", err)
+	}
+	// 20000 + 10% de recargo (solo sobre los productos) + 8500 de envío
+	if order.ShippingCost != 8500 || order.Surcharge != 2000 || order.TotalAmount != 30500 {
+		t.Errorf("envío = %v, recargo = %v, total = %v", order.ShippingCost, order.Surcharge, order.TotalAmount)
+	}
+
+	order = newOrder(3)
+	if err := services.PriceOrder(order, catalog, shippingRules(50000), nil); err != nil {
+		t.Fatalf("PriceOrder() error = %v", err)
+	}
+	if order.ShippingCost != 0 || order.TotalAmount != 66000 {
+		t.Errorf("con envío gratis: envío = %v, total = %v", order.ShippingCost, order.TotalAmount)
+	}
+
+	// El costo de envío enviado por el cliente debe coincidir
+	order = newOrder(1)
+	order.ShippingCost = 100
+	if err := services.PriceOrder(order, catalog, shippingRules(0), nil); err == nil {
+		t.Error("se esperaba error por costo de envío distinto al calculado")
+	}
+}
+
+// TestShippingMethodValidate verifica que los rangos de códigos postales tengan ambos extremos
+func TestShippingMethodValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    models.ShippingZone
+		wantErr bool
+	}{
+		{"rango válido", models.ShippingZone{PostalCodeFrom: 1000, PostalCodeTo: 1499}, false},
+		{"un solo código postal", models.ShippingZone{PostalCodeFrom: 5000, PostalCodeTo: 5000}, false},
+		{"solo provincias", models.ShippingZone{Provinces: []string{"Córdoba"}}, false},
+		{"todo el país", models.ShippingZone{}, false},
+		{"sin desde", models.ShippingZone{PostalCodeTo: 1499}, true},
+		{"sin hasta", models.ShippingZone{PostalCodeFrom: 1000}, true},
+		{"rango invertido", models.ShippingZone{PostalCodeFrom: 1499, PostalCodeTo: 1000}, true},
+		{"desde negativo", models.ShippingZone{PostalCodeFrom: -1, PostalCodeTo: 1000}, true},
+		{"más de 4 dígitos", models.ShippingZone{PostalCodeFrom: 1000, PostalCodeTo: 10000}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := models.ShippingMethod{Code: models.ShippingCorreo, Zones: []models.ShippingZone{tt.zone}}
+			err := method.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
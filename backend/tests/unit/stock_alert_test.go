@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"tiendaedgar/backend/models"
+	"tiendaedgar/backend/services"
+)
+
+// TestStockAlertFor verifica que solo se alerte al cruzar el umbral o al agotarse
+func TestStockAlertFor(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after int
+		threshold     int
+		want          models.StockAlertKind
+		wantOK        bool
+	}{
+		{"cruza el umbral", 6, 5, 5, models.StockAlertLow, true},
+		{"sigue bajo", 5, 3, 5, "", false},
+		{"se agota", 3, 0, 5, models.StockAlertOutOfStock, true},
+		{"se agota de golpe", 20, 0, 5, models.StockAlertOutOfStock, true},
+		{"ya estaba agotado", 0, 0, 5, "", false},
+		{"repone", 2, 10, 5, "", false},
+		{"repone por debajo del umbral", 0, 3, 5, "", false},
+		{"sin umbral", 2, 1, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, ok := models.StockAlertFor(tt.before, tt.after, tt.threshold)
+			if kind != tt.want || ok != tt.wantOK {
+				t.Errorf("StockAlertFor(%d, %d, %d) = %q, %v; want %q, %v", tt.before, tt.after, tt.threshold, kind, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestStockAlertOnCreate verifica que un producto o variante creado agotado o con poco
+// stock alerte como si hubiera cruzado el umbral
+func TestStockAlertOnCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		stock     int
+		threshold int
+		want      models.StockAlertKind
+		wantOK    bool
+	}{
+		{"creado agotado", 0, 5, models.StockAlertOutOfStock, true},
+		{"creado en el umbral", 5, 5, models.StockAlertLow, true},
+		{"creado por debajo del umbral", 2, 5, models.StockAlertLow, true},
+		{"creado con stock", 10, 5, "", false},
+		{"creado agotado sin umbral", 0, 0, models.StockAlertOutOfStock, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, ok := models.StockAlertOnCreate(tt.stock, tt.threshold)
+			if kind != tt.want || ok != tt.wantOK {
+				t.Errorf("StockAlertOnCreate(%d, %d) = %q, %v; want %q, %v", tt.stock, tt.threshold, kind, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestRenderStockAlertEmail verifica el asunto y el detalle del email de alertas a la tienda
+func TestRenderStockAlertEmail(t *testing.T) {
+	alerts := []models.StockAlert{
+		{ProductName: "Air <Max>", Talla: "42", Color: "negro", Kind: models.StockAlertOutOfStock, Stock: 0, Threshold: 5},
+		{ProductName: "Gorra", Kind: models.StockAlertLow, Stock: 3, Threshold: 5},
+	}
+
+	msg, err := services.RenderStockAlertEmail("Tienda", alerts)
+	if err != nil {
+		t.Fatalf("RenderStockAlertEmail() error = %v", err)
+	}
+	if msg.Subject != "Alertas de stock: 2 productos (agotados: 1)" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	for _, want := range []string{"- Air <Max> - Talla 42 / negro: agotado (stock 0)", "- Gorra: stock bajo (umbral 5) (stock 3)"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Text no contiene %q:\n%s", want, msg.Text)
+		}
+	}
+	if !strings.Contains(msg.HTML, "Air &lt;Max&gt;") {
+		t.Errorf("HTML sin escapar:\n%s", msg.HTML)
+	}
+
+	msg, _ = services.RenderStockAlertEmail("Tienda", alerts[:1])
+	if msg.Subject != "Agotado: Air <Max> - Talla 42 / negro" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+}
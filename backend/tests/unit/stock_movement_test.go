@@ -0,0 +1,47 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestNewStockMovement verifica la plantilla de movimientos de stock
+func TestNewStockMovement(t *testing.T) {
+	userID := uint(7)
+	mv := models.NewStockMovement(models.StockMovementSale, models.Actor{UserID: &userID, Username: "admin"}, "Pedido creado")
+
+	if mv.Reason != models.StockMovementSale {
+		t.Errorf("Expected reason sale, got %q", mv.Reason)
+	}
+	if mv.UserID == nil || *mv.UserID != 7 || mv.CreatedBy != "admin" {
+		t.Errorf("Expected admin user 7, got %v %q", mv.UserID, mv.CreatedBy)
+	}
+	if mv.OrderID != nil {
+		t.Errorf("Expected no order, got %v", *mv.OrderID)
+	}
+
+	forOrder := mv.ForOrder(15)
+	if forOrder.OrderID == nil || *forOrder.OrderID != 15 {
+		t.Errorf("Expected order 15, got %v", forOrder.OrderID)
+	}
+	if mv.OrderID != nil {
+		t.Error("ForOrder no debe modificar la plantilla original")
+	}
+
+	system := models.NewStockMovement(models.StockMovementImport, models.Actor{}, "")
+	if system.CreatedBy != "sistema" || system.UserID != nil {
+		t.Errorf("Expected sistema actor, got %q %v", system.CreatedBy, system.UserID)
+	}
+}
+
+// TestStockMovementReasonIsValid verifica los motivos admitidos
+func TestStockMovementReasonIsValid(t *testing.T) {
+	for _, reason := range []models.StockMovementReason{"sale", "cancel", "manual_adjust", "return", "import"} {
+		if !reason.IsValid() {
+			t.Errorf("Expected %q to be valid", reason)
+		}
+	}
+	if models.StockMovementReason("robo").IsValid() {
+		t.Error("Expected unknown reason to be invalid")
+	}
+}
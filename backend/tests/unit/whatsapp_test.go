@@ -0,0 +1,61 @@
+package unit
+
+import (
+	"testing"
+	"tiendaedgar/backend/models"
+)
+
+// TestRenderWhatsAppMessage verifica el reemplazo de placeholders y que se omitan las líneas sin valor
+func TestRenderWhatsAppMessage(t *testing.T) {
+	values := map[string]string{
+		"producto": "Air Max 90",
+		"precio":   "$ 150.000,00",
+		"talla":    "42",
+	}
+
+	got := models.RenderWhatsAppMessage(models.DefaultWhatsAppProductMessage, values)
+	want := "Hola! Me interesa este producto:\n\n*Air Max 90*\nTalla: 42\nPrecio: $ 150.000,00\n\n¿Está disponible?"
+	if got != want {
+		t.Errorf("RenderWhatsAppMessage() = %q, want %q", got, want)
+	}
+
+	got = models.RenderWhatsAppMessage("Pedido {pedido} {desconocido}", map[string]string{"pedido": "CS-2026-000001"})
+	if got != "" {
+		t.Errorf("línea con placeholder sin valor = %q, want omitida", got)
+	}
+}
+
+// TestWhatsAppLink verifica el número internacional y la codificación del mensaje en wa.me
+func TestWhatsAppLink(t *testing.T) {
+	phones := map[string]string{
+		"11 5555-1234":       "5491155551234",
+		"+54 9 11 5555-1234": "5491155551234",
+		"011 15 5555-1234":   "5491155551234",
+		"5491134567890":      "5491134567890",
+		"123":                "",
+	}
+	for phone, want := range phones {
+		if got := models.WhatsAppPhone(phone); got != want {
+			t.Errorf("WhatsAppPhone(%q) = %q, want %q", phone, got, want)
+		}
+	}
+
+	link := models.NewWhatsAppLink("5491155551234", "Hola! ¿Talla 42 & 43?\nGracias")
+	want := "https://wa.me/5491155551234?text=Hola%21%20%C2%BFTalla%2042%20%26%2043%3F%0AGracias"
+	if link.URL != want {
+		t.Errorf("URL = %q, want %q", link.URL, want)
+	}
+}
+
+// TestValidateWhatsAppMessage verifica que solo se acepten los placeholders disponibles
+func TestValidateWhatsAppMessage(t *testing.T) {
+	config := models.SiteConfig{}
+	config.NormalizeWhatsAppMessages()
+	if err := config.ValidateWhatsAppMessages(); err != nil {
+		t.Errorf("plantillas por defecto: %v", err)
+	}
+
+	if err := models.ValidateWhatsAppMessage("Tu pedido {pedido} por {precio}", models.WhatsAppOrderPlaceholders); err == nil {
+		t.Error("placeholder de producto en la plantilla de pedido: want error")
+	}
+}